@@ -0,0 +1,58 @@
+package goxtag
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+func isBigType(t reflect.Type) bool {
+	return t == bigIntType || t == bigFloatType || t == bigRatType
+}
+
+// unmarshalBig parses the value of the matched nodes into the big.Int,
+// big.Float or big.Rat v, like unmarshalLiteral does for numbers: the
+// xpath_numfmt separators are taken into account and empty values leave v
+// untouched. Floats get enough precision to hold every digit of the value.
+func (d *decodeState) unmarshalBig(doc *Document, v reflect.Value, tag xpathTag) error {
+	t := v.Type()
+	return d.unmarshalParsed(doc, v, tag, func(s string) (interface{}, error) {
+		if tag.numFmt != nil {
+			s = tag.numFmt.normalize(s)
+		}
+		var ok bool
+		switch t {
+		case bigIntType:
+			var i big.Int
+			_, ok = i.SetString(s, 10)
+			return i, parseBigErr(ok, s, t)
+		case bigFloatType:
+			prec := uint(64)
+			if p := uint(len(s)) * 4; p > prec {
+				prec = p
+			}
+			f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+			if err != nil {
+				return nil, err
+			}
+			return *f, nil
+		default:
+			var r big.Rat
+			_, ok = r.SetString(s)
+			return r, parseBigErr(ok, s, t)
+		}
+	})
+}
+
+func parseBigErr(ok bool, s string, t reflect.Type) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("cannot parse %q as %s", s, t)
+}
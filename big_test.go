@@ -0,0 +1,38 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestBigNumbers(t *testing.T) {
+	asrt := assert.New(t)
+
+	const page = `<p class="id">123456789012345678901234567890</p>
+		<p class="total">1.234.567.890.123.456.789,25</p>
+		<p class="price">19.99</p><p class="empty"></p>
+		<ul><li>1</li><li>2</li></ul>`
+
+	var a struct {
+		ID    big.Int    `xpath:"//p[@class='id']"`
+		Total *big.Float `xpath:"//p[@class='total']" xpath_numfmt:"de"`
+		Price big.Rat    `xpath:"//p[@class='price']"`
+		Exact *big.Rat   `xpath:"//p[@class='total']" xpath_numfmt:"de"`
+		Empty *big.Int   `xpath:"//p[@class='empty']"`
+		Items []*big.Int `xpath:"//li"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal("123456789012345678901234567890", a.ID.String())
+	asrt.Equal("1234567890123456789.25", a.Total.Text('f', 2))
+	asrt.Equal("1999/100", a.Price.String())
+	asrt.Equal("1234567890123456789.25", a.Exact.FloatString(2))
+	asrt.Nil(a.Empty)
+	asrt.Len(a.Items, 2)
+	asrt.Equal(int64(2), a.Items[1].Int64())
+
+	var b struct {
+		ID big.Int `xpath:"//p[@class='price']"`
+	}
+	asrt.Equal(ErrTypeConversion, checkErr(asrt, Unmarshal([]byte(page), &b)).Reason)
+}
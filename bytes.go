@@ -0,0 +1,43 @@
+package goxtag
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// unmarshalBytes captures the matched nodes into the byte slice v: their
+// rendered inner HTML, unless another mode, an attribute or an item property
+// is given. json.RawMessage values get it as a JSON string so that they stay
+// valid JSON, except for text that already is, e.g. the content of a
+// <script type="application/json"> read with xpath_mode:"text". v is left nil
+// when nothing matches.
+func (d *decodeState) unmarshalBytes(doc *Document, v reflect.Value, tag xpathTag) error {
+	if doc.IsEmpty() {
+		return nil
+	}
+	if tag.mode == "" && tag.attr == "" && tag.itemProp == "" {
+		tag.mode = modeHTML
+	}
+	val, err := d.value(doc, v, tag)
+	if err != nil {
+		return err
+	}
+	if v.Type() != rawMessageType ||
+		tag.mode != modeHTML && tag.mode != modeOuterHTML && json.Valid([]byte(val)) {
+		v.SetBytes([]byte(val))
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// Encoding a string can't fail
+	_ = enc.Encode(val)
+	v.SetBytes(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return nil
+}
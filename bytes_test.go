@@ -0,0 +1,43 @@
+package goxtag
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBytesCapture(t *testing.T) {
+	asrt := assert.New(t)
+
+	const page = `<div class="post"><p>Some <b>bold</b> &amp; text</p></div>
+		<script type="application/json">{"id": 1}</script><a href="/x">x</a>`
+
+	var a struct {
+		Body    []byte          `xpath:"//div[@class='post']"`
+		Text    []byte          `xpath:"//div[@class='post']" xpath_mode:"text"`
+		Href    []byte          `xpath:"//a/@href"`
+		Raw     json.RawMessage `xpath:"//div[@class='post']"`
+		Outer   json.RawMessage `xpath:"//p" xpath_mode:"outerhtml"`
+		Script  json.RawMessage `xpath:"//script" xpath_mode:"text"`
+		Missing json.RawMessage `xpath:"//table" xpath_required:"false"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal(`<p>Some <b>bold</b> &amp; text</p>`, string(a.Body))
+	asrt.Equal("Some bold & text", string(a.Text))
+	asrt.Equal("/x", string(a.Href))
+	asrt.Equal(`"<p>Some <b>bold</b> &amp; text</p>"`, string(a.Raw))
+	asrt.Equal(`"<p>Some <b>bold</b> &amp; text</p>"`, string(a.Outer))
+	asrt.Equal(`{"id": 1}`, string(a.Script))
+	asrt.Nil(a.Missing)
+
+	// The struct can be stored as JSON and the fragments read back later
+	bs, err := json.Marshal(a)
+	asrt.NoError(err)
+	var b struct {
+		Raw    string
+		Script struct{ ID int }
+	}
+	asrt.NoError(json.Unmarshal(bs, &b))
+	asrt.Equal(`<p>Some <b>bold</b> &amp; text</p>`, b.Raw)
+	asrt.Equal(1, b.Script.ID)
+}
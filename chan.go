@@ -0,0 +1,84 @@
+package goxtag
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// unmarshalChan decodes every node into the element type of the channel v
+// and sends it as soon as it is decoded. Sends wait for the value to be
+// received, so the channel is read while Unmarshal runs, and it is left open
+// for its owner to close once Unmarshal returns. A nil channel is replaced by
+// a closed one buffering every value, even if decoding stops with an error,
+// so that ranging over it never blocks.
+func (d *decodeState) unmarshalChan(doc *Document, v reflect.Value, tag xpathTag) error {
+	ch := v
+	if v.IsNil() {
+		ch = reflect.MakeChan(reflect.ChanOf(reflect.BothDir, v.Type().Elem()), doc.Length())
+		defer func() {
+			ch.Close()
+			v.Set(ch)
+		}()
+	} else if v.Type().ChanDir()&reflect.SendDir == 0 {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrNonChannel,
+			XPath:  tag.tag,
+		}
+	}
+
+	done := reflect.ValueOf((<-chan struct{})(nil))
+	if d.ctx != nil {
+		done = reflect.ValueOf(d.ctx.Done())
+	}
+
+	eleT := v.Type().Elem()
+	var errs UnmarshalErrors
+	for i := 0; i < doc.Length(); i++ {
+		if err := d.canceled(); err != nil {
+			return err
+		}
+
+		newV := reflect.New(TypeDeref(eleT))
+		pop := d.pushPath(fmt.Sprintf("[%d]", i))
+		err := d.unmarshalByType(doc.Eq(i), newV, tag)
+		pop()
+
+		if err != nil && tag.skipErrors {
+			d.note(tag.tag, fmt.Sprintf("element %d skipped: %v", i, err))
+			continue
+		}
+
+		if err != nil {
+			err = &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: i,
+			}
+			if !d.collectErrors {
+				return err
+			}
+			// Send the element as far as it could be decoded
+			errs = append(errs, err)
+		}
+
+		if eleT.Kind() != reflect.Ptr {
+			newV = newV.Elem()
+		}
+
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: ch, Send: newV},
+			{Dir: reflect.SelectRecv, Chan: done},
+		})
+		if chosen == 1 {
+			return d.canceled()
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package goxtag
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testChanPage = `<ul><li><b>a</b><i>1</i></li><li><b>b</b><i>2</i></li><li><b>c</b><i>x</i></li></ul>`
+
+type chanItem struct {
+	Name  string `xpath:"./b"`
+	Price int    `xpath:"./i"`
+}
+
+func TestChanField(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Items <-chan chanItem `xpath:"//li[position() < 3]"`
+		Names chan string     `xpath:"//li/b"`
+	}
+	asrt.NoError(Unmarshal([]byte(testChanPage), &a))
+
+	var items []chanItem
+	for it := range a.Items {
+		items = append(items, it)
+	}
+	asrt.Equal([]chanItem{{"a", 1}, {"b", 2}}, items)
+	asrt.Len(a.Names, 3)
+
+	ch := make(chan *chanItem)
+	b := struct {
+		Items chan *chanItem `xpath:"//li" xpath_on_error:"skip"`
+	}{Items: ch}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Unmarshal([]byte(testChanPage), &b)
+		close(ch)
+	}()
+	var names []string
+	for it := range ch {
+		names = append(names, it.Name)
+	}
+	asrt.NoError(<-errc)
+	asrt.Equal([]string{"a", "b"}, names)
+}
+
+func TestChanFieldErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Items chan chanItem `xpath:"//li"`
+	}
+	err := Unmarshal([]byte(testChanPage), &a)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Len(a.Items, 2)
+
+	recv := make(<-chan chanItem)
+	b := struct {
+		Items <-chan chanItem `xpath:"//li"`
+	}{Items: recv}
+	asrt.True(errors.Is(Unmarshal([]byte(testChanPage), &b), ErrNonChannel))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := struct {
+		Items chan chanItem `xpath:"//li[1]"`
+	}{Items: make(chan chanItem)}
+	cancel()
+	asrt.Equal(context.Canceled, UnmarshalContext(ctx, []byte(testChanPage), &c))
+
+	_, err = Marshal(&struct {
+		Items chan chanItem `xpath:"//li"`
+	}{Items: make(chan chanItem)})
+	asrt.Error(err)
+}
@@ -0,0 +1,160 @@
+package goxtag
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithContentType passes the Content-Type header the document was served
+// with (e.g. "text/html; charset=windows-1251") as a charset hint. Without
+// it, the charset is read from the byte order mark and the <meta> tags of the
+// document. Documents declaring none are taken for UTF-8, unless their first
+// bytes are invalid UTF-8, which falls back to windows-1252.
+func WithContentType(contentType string) Option {
+	return func(c *config) {
+		c.contentType = contentType
+	}
+}
+
+// prescanSize is the size of the start of a document the charset is looked up
+// in, as in charset.DetermineEncoding.
+const prescanSize = 1024
+
+// utf8Reader transcodes the document read from r into UTF-8. Unlike
+// charset.NewReader, it doesn't take documents declaring no charset whose
+// start is plain ASCII for windows-1252, so that UTF-8 pages having their
+// first non-ASCII character further down decode like html.Parse does.
+func (c config) utf8Reader(r io.Reader) (io.Reader, error) {
+	preview := make([]byte, prescanSize)
+	n, err := io.ReadFull(r, preview)
+	switch {
+	case err == io.EOF:
+		// An empty document is a valid one
+		return bytes.NewReader(nil), nil
+	case err == io.ErrUnexpectedEOF:
+		preview = preview[:n]
+		r = bytes.NewReader(preview)
+	case err != nil:
+		return nil, err
+	default:
+		r = io.MultiReader(bytes.NewReader(preview), r)
+	}
+
+	e, _, certain := charset.DetermineEncoding(preview, c.contentType)
+	if e == encoding.Nop || !certain && !isHighBit(preview) && !declaresCharset(preview) {
+		return r, nil
+	}
+	return transform.NewReader(r, e.NewDecoder()), nil
+}
+
+// isHighBit reports whether bs holds a byte outside of the ASCII range.
+func isHighBit(bs []byte) bool {
+	for _, b := range bs {
+		if b >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresCharset reports whether the <meta> tags at the start of a document
+// declare a known charset, either with a charset attribute or with the
+// content attribute of an http-equiv="Content-Type" one.
+func declaresCharset(preview []byte) bool {
+	z := html.NewTokenizer(bytes.NewReader(preview))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "meta" {
+				continue
+			}
+			var label, content string
+			var contentType bool
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					label = string(val)
+				case "content":
+					content = string(val)
+				case "http-equiv":
+					contentType = strings.EqualFold(string(val), "content-type")
+				}
+			}
+			if label == "" && contentType {
+				if _, params, err := mime.ParseMediaType(content); err == nil {
+					label = params["charset"]
+				}
+			}
+			if e, _ := charset.Lookup(label); e != nil {
+				return true
+			}
+		}
+	}
+}
+
+// parse transcodes the document read from r into UTF-8 and parses it, or the
+// fragment it holds with WithFragment. With WithSource, the UTF-8 source is
+// read into memory first and returned as well, see Position; parseBytes
+// always keeps it. Documents
+// exceeding the limits of c are rejected with a LimitError, the others are
+// passed to the sanitizers of c.
+func (c config) parse(r io.Reader) (*html.Node, []byte, error) {
+	root, src, err := c.parseTree(c.limitReader(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkLimits(root); err != nil {
+		return nil, nil, err
+	}
+	c.sanitize(root)
+	return root, src, nil
+}
+
+// parseBytes is parse for documents already held in memory, whose source is
+// kept whatever WithSource says.
+func (c config) parseBytes(bs []byte) (*html.Node, []byte, error) {
+	c.keepSource = true
+	return c.parse(bytes.NewReader(bs))
+}
+
+func (c config) parseTree(r io.Reader) (*html.Node, []byte, error) {
+	var err error
+	// The XML parser reads the encoding from the XML declaration
+	if c.syntax != SyntaxXML {
+		if r, err = c.utf8Reader(r); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var src []byte
+	if c.keepSource {
+		if src, err = ioutil.ReadAll(r); err != nil {
+			return nil, nil, err
+		}
+		r = bytes.NewReader(src)
+	}
+
+	var root *html.Node
+	switch {
+	case c.syntax == SyntaxXML:
+		root, err = parseXML(r)
+	case c.fragment:
+		root, err = parseFragment(r, c.fragmentContext)
+	default:
+		root, err = html.Parse(r)
+	}
+	return root, src, err
+}
@@ -0,0 +1,80 @@
+package goxtag
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"io"
+	"strings"
+	"testing"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	bs, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
+
+func TestCharset(t *testing.T) {
+	asrt := assert.New(t)
+
+	type page struct {
+		Title string `xpath:"//h1"`
+	}
+
+	// Declared by a <meta> tag
+	cp1251 := encode(t, charmap.Windows1251, `<html><head><meta charset="windows-1251"></head><body><h1>Привет</h1></body></html>`)
+	var a page
+	asrt.NoError(Unmarshal(cp1251, &a))
+	asrt.Equal("Привет", a.Title)
+
+	a = page{}
+	asrt.NoError(NewDecoder(bytes.NewReader(cp1251)).Decode(&a))
+	asrt.Equal("Привет", a.Title)
+
+	// Passed as a Content-Type hint
+	sjis := encode(t, japanese.ShiftJIS, `<h1>こんにちは</h1>`)
+	a = page{}
+	asrt.NoError(NewDecoder(bytes.NewReader(sjis), WithContentType("text/html; charset=Shift_JIS")).Decode(&a))
+	asrt.Equal("こんにちは", a.Title)
+
+	a = page{}
+	asrt.NoError(UnmarshalWithOptions(sjis, &a, WithContentType("text/html; charset=shift_jis")))
+	asrt.Equal("こんにちは", a.Title)
+
+	dec, err := NewStreamDecoder(bytes.NewReader(sjis), "h1", WithContentType("text/html; charset=shift_jis"))
+	asrt.NoError(err)
+	var rec struct {
+		Text string `xpath:"."`
+	}
+	asrt.NoError(dec.DecodeNext(&rec))
+	asrt.Equal("こんにちは", rec.Text)
+	asrt.Equal(io.EOF, dec.DecodeNext(&rec))
+
+	// UTF-8 stays as is
+	a = page{}
+	asrt.NoError(Unmarshal([]byte(`<h1>Grüße</h1>`), &a))
+	asrt.Equal("Grüße", a.Title)
+
+	// Even when the first non-ASCII character comes after the bytes the
+	// charset is looked up in
+	padded := []byte("<p>" + strings.Repeat("x", 2000) + "</p><h1>Grüße</h1>")
+	a = page{}
+	asrt.NoError(Unmarshal(padded, &a))
+	asrt.Equal("Grüße", a.Title)
+
+	a = page{}
+	asrt.NoError(NewDecoder(bytes.NewReader(padded)).Decode(&a))
+	asrt.Equal("Grüße", a.Title)
+
+	// Unless the page declares another charset
+	cp1252 := encode(t, charmap.Windows1252, `<meta http-equiv="Content-Type" content="text/html; charset=windows-1252"><p>`+
+		strings.Repeat("x", 2000)+`</p><h1>Grüße</h1>`)
+	a = page{}
+	asrt.NoError(Unmarshal(cp1252, &a))
+	asrt.Equal("Grüße", a.Title)
+}
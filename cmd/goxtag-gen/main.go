@@ -0,0 +1,56 @@
+// Command goxtag-gen generates reflection-free decoders for xpath-tagged
+// structs, see package github.com/azlotnikov/goxtag/gen.
+//
+// Usage:
+//
+//	goxtag-gen -type Product,Offer [-output product_goxtag.go] [dir]
+//
+// It is meant to be run by go generate from the package of the types:
+//
+//	//go:generate go run github.com/azlotnikov/goxtag/cmd/goxtag-gen -type Product,Offer
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/azlotnikov/goxtag/gen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	types := flag.String("type", "", "comma-separated list of the struct types to generate decoders for")
+	output := flag.String("output", "", "output file name; default <dir>/<type>_goxtag.go")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: goxtag-gen -type T[,T...] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *types == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	names := strings.Split(*types, ",")
+	src, err := gen.Generate(dir, names...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goxtag-gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(names[0])+"_goxtag.go")
+	}
+	if err := ioutil.WriteFile(out, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "goxtag-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
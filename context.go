@@ -0,0 +1,39 @@
+package goxtag
+
+import (
+	"context"
+)
+
+// UnmarshalContext is like UnmarshalWithOptions but stops with ctx.Err() as
+// soon as ctx is done. The context is checked between struct fields, slice,
+// array and map elements and table rows.
+func UnmarshalContext(ctx context.Context, bs []byte, v interface{}, opts ...Option) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c := newConfig(opts)
+	root, src, err := c.parseBytes(bs)
+
+	if err != nil {
+		return err
+	}
+
+	d := &decodeState{ctx: ctx, config: c, source: src}
+	return d.unmarshal(NewDocumentWithNode(root), v)
+}
+
+// UnmarshalSelectionContext is like UnmarshalSelectionWithOptions but stops
+// with ctx.Err() as soon as ctx is done, see UnmarshalContext.
+func UnmarshalSelectionContext(ctx context.Context, doc *Document, v interface{}, opts ...Option) error {
+	d := &decodeState{ctx: ctx, config: newConfig(opts)}
+	return d.unmarshal(doc, v)
+}
+
+// canceled returns the error of the context of the run once it is done.
+func (d *decodeState) canceled() error {
+	if d.ctx == nil {
+		return nil
+	}
+	return d.ctx.Err()
+}
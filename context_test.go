@@ -0,0 +1,58 @@
+package goxtag
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+// cancelOnUnmarshal cancels the context of the run once it is decoded.
+type cancelOnUnmarshal struct {
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnUnmarshal) UnmarshalHTML([]*html.Node) error {
+	c.cancel()
+	return nil
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<ul><li>1</li><li>2</li><li>3</li></ul>`
+
+	var a struct {
+		Items []int `xpath:"//li"`
+	}
+	asrt.NoError(UnmarshalContext(context.Background(), []byte(page), &a))
+	asrt.Equal([]int{1, 2, 3}, a.Items)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	asrt.Equal(context.Canceled, UnmarshalContext(ctx, []byte(page), &a))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	b := struct {
+		Cancel *cancelOnUnmarshal `xpath:"//ul"`
+		Items  []int              `xpath:"//li"`
+	}{Cancel: &cancelOnUnmarshal{cancel: cancel}}
+	asrt.Equal(context.Canceled, UnmarshalSelectionContext(ctx, parseTestDocument(t, page), &b))
+	asrt.Empty(b.Items)
+}
+
+func TestDecodeContext(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Items []int `xpath:"//li"`
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dec := NewDecoder(strings.NewReader(`<ul><li>1</li></ul>`))
+	asrt.Equal(context.Canceled, dec.DecodeContext(ctx, &a))
+	asrt.NoError(dec.DecodeContext(context.Background(), &a))
+	asrt.Equal([]int{1}, a.Items)
+}
@@ -0,0 +1,97 @@
+package goxtag
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ConverterFunc decodes the text of a node into a value of the type it was
+// registered for, see RegisterConverter.
+type ConverterFunc func(s string) (interface{}, error)
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[reflect.Type]ConverterFunc{}
+)
+
+// RegisterConverter makes fields of type t (or pointers to it) be decoded by
+// fn from the value of their node, e.g. for decimal or money types of other
+// packages that can't implement Unmarshaler. It takes precedence over every
+// other way of decoding t but a custom Unmarshaler. Registering a nil fn
+// removes the converter of t. Converters set with WithConverter or
+// Decoder.RegisterConverter override the registered ones.
+func RegisterConverter(t reflect.Type, fn ConverterFunc) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	if fn == nil {
+		delete(converters, t)
+		return
+	}
+	converters[t] = fn
+}
+
+// WithConverter decodes fields of type t with fn for this run only, see
+// RegisterConverter.
+func WithConverter(t reflect.Type, fn ConverterFunc) Option {
+	return func(c *config) {
+		c.setConverter(t, fn)
+	}
+}
+
+// RegisterConverter decodes fields of type t with fn for the documents of
+// this decoder only, see RegisterConverter.
+func (d *Decoder) RegisterConverter(t reflect.Type, fn ConverterFunc) {
+	d.config.setConverter(t, fn)
+}
+
+// setConverter sets the converter of t, copying the map first since configs
+// are copied by value.
+func (c *config) setConverter(t reflect.Type, fn ConverterFunc) {
+	m := make(map[reflect.Type]ConverterFunc, len(c.converters)+1)
+	for k, v := range c.converters {
+		m[k] = v
+	}
+	m[t] = fn
+	c.converters = m
+}
+
+// converter returns the converter of t, if any. A nil converter set by an
+// option hides the registered one.
+func (d *decodeState) converter(t reflect.Type) ConverterFunc {
+	if fn, ok := d.converters[t]; ok {
+		return fn
+	}
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	return converters[t]
+}
+
+// unmarshalConverted sets v to the value fn converts the value of doc into.
+func (d *decodeState) unmarshalConverted(doc *Document, v reflect.Value, tag xpathTag, fn ConverterFunc) error {
+	str, err := d.value(doc, v, tag)
+	if err != nil {
+		return err
+	}
+	val, err := fn(str)
+	if err == nil && val != nil && !reflect.TypeOf(val).AssignableTo(v.Type()) {
+		err = fmt.Errorf("converter returned a %T", val)
+	}
+	if err != nil {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTypeConversion,
+			XPath:  tag.tag,
+			Err:    err,
+			Val:    str,
+			Pos:    d.position(doc),
+		}
+	}
+
+	if val == nil {
+		v.Set(reflect.Zero(v.Type()))
+	} else {
+		v.Set(reflect.ValueOf(val))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package goxtag
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+var moneyType = reflect.TypeOf(money{})
+
+func parseMoney(s string) (interface{}, error) {
+	var m money
+	var units, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%d %s", &units, &cents, &m.Currency); err != nil {
+		return nil, err
+	}
+	m.Cents = units*100 + cents
+	return m, nil
+}
+
+func TestRegisterConverter(t *testing.T) {
+	asrt := assert.New(t)
+
+	RegisterConverter(moneyType, parseMoney)
+	defer RegisterConverter(moneyType, nil)
+
+	page := []byte(`<ul><li data-price="5.00 EUR">9.99 USD</li><li>oops</li></ul>`)
+
+	var a struct {
+		Price  money   `xpath:"//li[1]"`
+		Attr   *money  `xpath:"//li[1]" xpath_attr:"data-price"`
+		Prices []money `xpath:"//li[1]"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal(money{999, "USD"}, a.Price)
+	asrt.Equal(&money{500, "EUR"}, a.Attr)
+	asrt.Equal([]money{{999, "USD"}}, a.Prices)
+
+	var b struct {
+		Price money `xpath:"//li[2]"`
+	}
+	err := Unmarshal(page, &b)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Contains(err.Error(), "oops")
+
+	var c struct {
+		Price money `xpath:"//li"`
+	}
+	asrt.True(errors.Is(Unmarshal(page, &c), ErrMultipleNodes))
+
+	asrt.NoError(ValidateType(reflect.TypeOf(a)))
+}
+
+func TestConverterOverrides(t *testing.T) {
+	asrt := assert.New(t)
+
+	RegisterConverter(moneyType, parseMoney)
+	defer RegisterConverter(moneyType, nil)
+
+	page := `<p>9.99 USD</p>`
+	var a struct {
+		Price money `xpath:"//p"`
+	}
+
+	free := func(string) (interface{}, error) {
+		return money{Currency: "FREE"}, nil
+	}
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &a, WithConverter(moneyType, free)))
+	asrt.Equal("FREE", a.Price.Currency)
+
+	d := NewDecoder(strings.NewReader(page))
+	d.RegisterConverter(moneyType, func(string) (interface{}, error) {
+		return "not money", nil
+	})
+	asrt.True(errors.Is(d.Decode(&a), ErrTypeConversion))
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal(money{999, "USD"}, a.Price)
+}
@@ -0,0 +1,110 @@
+package goxtag
+
+import (
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+	"reflect"
+)
+
+// Coverage records which parts of a document the selectors of a run touched,
+// see WithCoverage. The zero value is ready to use; a Coverage is not safe for
+// concurrent runs.
+type Coverage struct {
+	// roots are the nodes the runs decoded
+	roots []*html.Node
+	// read holds the nodes whose whole subtree was read as a value, matched
+	// the nodes selected for structs, whose fields may only read part of them
+	read    map[*html.Node]bool
+	matched map[*html.Node]bool
+}
+
+// WithCoverage records the nodes matched by the selectors of every field into
+// c, so that Coverage.Unmatched can tell which parts of the document the
+// struct doesn't look at, e.g. to find data it is missing or to notice a site
+// redesign.
+func WithCoverage(c *Coverage) Option {
+	return func(cfg *config) {
+		cfg.coverage = c
+	}
+}
+
+// Unmatched returns the element subtrees of the decoded documents that no
+// selector touched: neither the elements nor any of their descendants were
+// matched, and they are not part of a matched node whose value was read (like
+// the text of a string field or the HTML of a Unmarshaler). Only the topmost
+// such elements are returned, in document order.
+func (c *Coverage) Unmatched() *Document {
+	// Ancestors of touched nodes are partly covered: their other children
+	// are looked at
+	partial := map[*html.Node]bool{}
+	for _, set := range []map[*html.Node]bool{c.read, c.matched} {
+		for n := range set {
+			for p := n.Parent; p != nil && !partial[p]; p = p.Parent {
+				partial[p] = true
+			}
+		}
+	}
+
+	var nodes []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case c.read[n]:
+			return
+		case n.Type == html.ElementNode && !c.matched[n] && !partial[n]:
+			nodes = append(nodes, n)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, root := range c.roots {
+		walk(root)
+	}
+	return NewDocumentWithNodes(nodes)
+}
+
+func (c *Coverage) mark(nodes []*html.Node, read bool) {
+	if c.read == nil {
+		c.read = map[*html.Node]bool{}
+		c.matched = map[*html.Node]bool{}
+	}
+	for _, n := range nodes {
+		if read {
+			c.read[n] = true
+		} else {
+			c.matched[n] = true
+		}
+	}
+}
+
+// cover records the nodes selected for a field of type t, if coverage is
+// recorded. Attributes are selected as detached copies, so the elements
+// holding them are looked up from doc and recorded instead.
+func (d *decodeState) cover(doc, sel *Document, t reflect.Type, tag xpathTag) {
+	if d.coverage == nil {
+		return
+	}
+	if !tag.css && attrSelRegEx.MatchString(tag.tag) {
+		owner := attrSelRegEx.ReplaceAllString(tag.tag, "")
+		for _, n := range doc.Nodes {
+			if found, err := htmlquery.QueryAll(n, owner); err == nil {
+				d.coverage.mark(found, false)
+			}
+		}
+		return
+	}
+	d.coverage.mark(sel.Nodes, readsSubtree(t))
+}
+
+// readsSubtree reports whether values of type t are decoded from the whole
+// subtree of their nodes rather than field by field.
+func readsSubtree(t reflect.Type) bool {
+	t = TypeDeref(t)
+	for (t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Chan) && t.Elem().Kind() != reflect.Uint8 {
+		t = TypeDeref(t.Elem())
+	}
+	return t.Kind() != reflect.Struct || isScalarType(t) || isNullType(t) ||
+		reflect.PtrTo(t).Implements(unmarshalerType)
+}
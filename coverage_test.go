@@ -0,0 +1,58 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testCoverage = `<html><head><title>Shop</title></head><body>
+<h1>Widget</h1>
+<div class="price"><b>9.99</b><span class="old">12.00</span></div>
+<a class="buy" href="/buy">Buy</a>
+<ul><li><b>Red</b><i>new</i></li><li><b>Blue</b></li></ul>
+<div id="reviews"><p>Great</p></div>
+</body></html>`
+
+func TestCoverage(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Name   string `xpath:"//h1"`
+		Price  string `xpath:"//div[@class='price']/b"`
+		Buy    string `xpath:"//a[@class='buy']/@href"`
+		Colors []struct {
+			Name string `xpath:"./b"`
+		} `xpath:"//li"`
+	}
+
+	var c Coverage
+	asrt.NoError(UnmarshalWithOptions([]byte(testCoverage), &a, WithCoverage(&c)))
+
+	var html []string
+	unmatched := c.Unmatched()
+	for i := range unmatched.Nodes {
+		s, _ := unmatched.Eq(i).Html()
+		html = append(html, s)
+	}
+	asrt.Equal([]string{
+		`<head><title>Shop</title></head>`,
+		`<span class="old">12.00</span>`,
+		`<i>new</i>`,
+		`<div id="reviews"><p>Great</p></div>`,
+	}, html)
+
+	// A table: the header is read, the unused columns are not
+	var b struct {
+		Rows []priceRow `xpath:"//table[@id='prices']" xpath_table:"true"`
+	}
+	var tc Coverage
+	asrt.NoError(UnmarshalWithOptions([]byte(testTable), &b, WithCoverage(&tc)))
+	for _, n := range tc.Unmatched().Nodes {
+		asrt.NotEqual("tr", n.Data)
+		asrt.NotEqual("thead", n.Data)
+	}
+	asrt.Equal("Prices", tc.Unmatched().Find("//caption").Text())
+
+	var empty Coverage
+	asrt.Equal(0, empty.Unmatched().Length())
+}
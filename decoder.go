@@ -1,8 +1,10 @@
 package goxtag
 
 import (
+	"context"
 	"golang.org/x/net/html"
 	"io"
+	"reflect"
 )
 
 // Decoder implements the same API you will see in encoding/xml and
@@ -11,12 +13,14 @@ import (
 type Decoder struct {
 	err     error
 	topNode *html.Node
+	source  []byte
+	config  config
 }
 
-// NewDecoder returns a new decoder given an io.Reader
-func NewDecoder(r io.Reader) *Decoder {
-	d := &Decoder{}
-	d.topNode, d.err = html.Parse(r)
+// NewDecoder returns a new decoder given an io.Reader, configured with opts.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	d := &Decoder{config: newConfig(opts)}
+	d.topNode, d.source, d.err = d.config.parse(r)
 	return d
 }
 
@@ -24,14 +28,171 @@ func NewDecoder(r io.Reader) *Decoder {
 // an annotated type as its argument. It will return any errors encountered
 // during either parsing the document or unmarshaling into the given object.
 func (d *Decoder) Decode(dest interface{}) error {
+	return d.DecodeContext(context.Background(), dest)
+}
+
+// DecodeContext is like Decode but stops with ctx.Err() as soon as ctx is
+// done, see UnmarshalContext.
+func (d *Decoder) DecodeContext(ctx context.Context, dest interface{}) error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.topNode == nil {
+		return &CannotUnmarshalError{
+			Reason: ErrNilDocument,
+		}
+	}
+
+	return d.decode(ctx, NewDocumentWithNode(d.topNode), dest)
+}
+
+// DecodeSelection unmarshals dest against the nodes matching selector rather
+// than the whole document, so the fields of dest are evaluated from them. It
+// returns an ErrNodeNotFound error if nothing matches, and the query error
+// as is for an invalid selector.
+func (d *Decoder) DecodeSelection(selector string, dest interface{}) error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.topNode == nil {
+		return &CannotUnmarshalError{
+			Reason: ErrNilDocument,
+		}
+	}
+
+	state := &decodeState{config: d.config}
+	sel, err := state.findAll(NewDocumentWithNode(d.topNode), selector)
+	if err != nil {
+		return err
+	}
+	if sel.IsEmpty() {
+		return &CannotUnmarshalError{
+			V:      reflect.ValueOf(dest),
+			Reason: ErrNodeNotFound,
+			XPath:  selector,
+		}
+	}
+	return d.decode(context.Background(), sel, dest)
+}
+
+// Stream decodes the nodes matching recordSelector one at a time into new
+// values of the element type of ch, a channel, and sends each record as soon
+// as it is decoded so that consumers can process a long listing while the
+// rest of it is decoded. The channel is closed when Stream returns, which is
+// at the first record that cannot be decoded.
+//
+// The document is still parsed as a whole by NewDecoder, see StreamDecoder to
+// avoid building the tree of huge pages.
+func (d *Decoder) Stream(recordSelector string, ch interface{}) error {
+	return d.StreamContext(context.Background(), recordSelector, ch)
+}
+
+// StreamContext is like Stream but stops with ctx.Err() as soon as ctx is
+// done, including while waiting for the consumer to receive a record.
+func (d *Decoder) StreamContext(ctx context.Context, recordSelector string, ch interface{}) error {
+	chv, err := sendChan(ch)
+	if err != nil {
+		return err
+	}
+	defer chv.Close()
+
 	if d.err != nil {
 		return d.err
 	}
 	if d.topNode == nil {
 		return &CannotUnmarshalError{
-			Reason: "resulting document was nil",
+			Reason: ErrNilDocument,
+		}
+	}
+
+	state := &decodeState{ctx: ctx, config: d.config}
+	sel, err := state.findAll(NewDocumentWithNode(d.topNode), recordSelector)
+	if err != nil {
+		return err
+	}
+	return sendRecords(ctx, chv, sel, recordSelector, func(doc *Document, rec interface{}) error {
+		return d.decode(ctx, doc, rec)
+	}, nil)
+}
+
+// sendChan returns ch as a channel values can be sent on.
+func sendChan(ch interface{}) (reflect.Value, error) {
+	chv := reflect.ValueOf(ch)
+	if chv.Kind() != reflect.Chan || chv.Type().ChanDir()&reflect.SendDir == 0 {
+		return chv, &CannotUnmarshalError{
+			V:      chv,
+			Reason: ErrNonChannel,
+		}
+	}
+	if chv.IsNil() {
+		return chv, &CannotUnmarshalError{
+			V:      chv,
+			Reason: ErrNilDestination,
 		}
 	}
+	return chv, nil
+}
 
-	return UnmarshalSelection(NewDocumentWithNode(d.topNode), dest)
+// sendRecords decodes the nodes of sel one at a time into new values of the
+// element type of chv and sends them, skipping the ones keep, if set, rejects.
+func sendRecords(ctx context.Context, chv reflect.Value, sel *Document, selector string,
+	decode func(doc *Document, rec interface{}) error, keep func(rec interface{}) bool) error {
+	elemT := chv.Type().Elem()
+	for i := range sel.Nodes {
+		rec := reflect.New(elemT)
+		if err := decode(sel.Eq(i), rec.Interface()); err != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				return cerr
+			}
+			return &CannotUnmarshalError{
+				V:        chv,
+				Reason:   ErrTypeConversion,
+				XPath:    selector,
+				Err:      err,
+				FldOrIdx: i,
+			}
+		}
+		if keep != nil && !keep(rec.Elem().Interface()) {
+			continue
+		}
+
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: chv, Send: rec.Elem()},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		})
+		if chosen == 1 {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) decode(ctx context.Context, doc *Document, dest interface{}) error {
+	state := &decodeState{ctx: ctx, config: d.config, source: d.source}
+	return state.unmarshal(doc, dest)
+}
+
+// SetAttrGetter overrides how attribute values are read while decoding, e.g.
+// to unescape HTML-entity-encoded JSON kept in a data attribute. Passing nil
+// restores the default getter.
+func (d *Decoder) SetAttrGetter(getter AttrGetter) {
+	d.config.attrGetter = getter
+}
+
+// Title returns the trimmed text of the page <title>, or an empty string if the
+// document could not be parsed.
+func (d *Decoder) Title() string {
+	if d.err != nil || d.topNode == nil {
+		return ""
+	}
+	return NewDocumentWithNode(d.topNode).Title()
+}
+
+// Meta returns the content of the <meta> tag with the given name or property,
+// or an empty string if the document could not be parsed. See Document.Meta.
+func (d *Decoder) Meta(name string) string {
+	if d.err != nil || d.topNode == nil {
+		return ""
+	}
+	return NewDocumentWithNode(d.topNode).Meta(name)
 }
@@ -1,7 +1,10 @@
 package goxtag
 
 import (
+	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
 	"strings"
 	"testing"
 )
@@ -14,3 +17,92 @@ func TestDecoder(t *testing.T) {
 	asrt.NoError(NewDecoder(strings.NewReader(testPage)).Decode(&p))
 	asrt.Len(p.Resources, 5)
 }
+
+func TestDecoderTitleAndMeta(t *testing.T) {
+	asrt := assert.New(t)
+
+	d := NewDecoder(strings.NewReader(testPage))
+
+	asrt.Equal("", d.Title())
+	asrt.Equal("utf-8", d.Meta("charset"))
+}
+
+func TestDecoderAttrGetter(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Links map[string]string `xpath:"//link" xpath_linkmap:"rel"`
+	}
+
+	d := NewDecoder(strings.NewReader(`<link rel="config" href="{&amp;quot;a&amp;quot;:1}">`))
+	d.SetAttrGetter(func(node *html.Node, name string) (string, bool) {
+		val, ok := getAttributeValue(name, node)
+		return html.UnescapeString(val), ok
+	})
+
+	asrt.NoError(d.Decode(&a))
+	asrt.Equal(`{"a":1}`, a.Links["config"])
+}
+
+func TestDecoderDecodeSelection(t *testing.T) {
+	asrt := assert.New(t)
+
+	d := NewDecoder(strings.NewReader(`<div id="main"><h1>Main</h1><ul><li>a</li><li>b</li></ul></div>
+		<div id="side"><h1>Side</h1></div>`))
+
+	var main struct {
+		Title string   `xpath:"./h1"`
+		Items []string `xpath:".//li"`
+	}
+	asrt.NoError(d.DecodeSelection("//div[@id='main']", &main))
+	asrt.Equal("Main", main.Title)
+	asrt.Equal([]string{"a", "b"}, main.Items)
+
+	var items []string
+	asrt.NoError(d.DecodeSelection("//li", &items))
+	asrt.Equal([]string{"a", "b"}, items)
+
+	var side struct {
+		Title string `xpath:"./h1"`
+	}
+	err := d.DecodeSelection("//div[@id='footer']", &side)
+	asrt.True(errors.Is(err, ErrNodeNotFound))
+	asrt.Error(d.DecodeSelection("//div[", &side))
+}
+
+func TestDecoderStream(t *testing.T) {
+	asrt := assert.New(t)
+
+	type item struct {
+		Name  string `xpath:"./b"`
+		Price int    `xpath:"./i"`
+	}
+	page := `<ul><li><b>a</b><i>1</i></li><li><b>b</b><i>2</i></li><li><b>c</b><i>x</i></li></ul>`
+
+	ch := make(chan item)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- NewDecoder(strings.NewReader(page)).Stream("//li", ch)
+	}()
+	var got []item
+	for it := range ch {
+		got = append(got, it)
+	}
+	asrt.Equal([]item{{"a", 1}, {"b", 2}}, got)
+	err := <-errc
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Equal(2, err.(*CannotUnmarshalError).FldOrIdx)
+
+	ptrs := make(chan *item, 3)
+	asrt.NoError(NewDecoder(strings.NewReader(page)).Stream("//li[position() < 3]", ptrs))
+	asrt.Len(ptrs, 2)
+	asrt.Equal("a", (<-ptrs).Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	asrt.Equal(context.Canceled, NewDecoder(strings.NewReader(page)).StreamContext(ctx, "//li", make(chan item)))
+
+	var recv <-chan item
+	asrt.True(errors.Is(NewDecoder(strings.NewReader(page)).Stream("//li", recv), ErrNonChannel))
+	asrt.True(errors.Is(NewDecoder(strings.NewReader(page)).Stream("//li", []item{}), ErrNonChannel))
+}
@@ -2,15 +2,70 @@ package goxtag
 
 import (
 	"bytes"
+	"fmt"
+	"github.com/andybalholm/cascadia"
 	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
 	"golang.org/x/net/html"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 const (
 	maxUint = ^uint(0)
 	maxInt  = int(maxUint >> 1)
+
+	titleSelector = "(//title)[1]"
+
+	// maxPooledBuffer is the capacity above which buffers are dropped rather
+	// than pooled, so that a huge page doesn't keep its memory alive
+	maxPooledBuffer = 64 << 10
+)
+
+var (
+	bufferPool   = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	documentPool = sync.Pool{New: func() interface{} { return new(Document) }}
 )
 
+// getBuffer returns an empty buffer from the pool. It is given back with
+// putBuffer once its content has been copied out.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// pooledEq is like Eq with a Document from the pool, for the decoding loops
+// that don't keep the selection of an element. It is given back with
+// putDocument.
+func (doc *Document) pooledEq(index int) *Document {
+	elem := documentPool.Get().(*Document)
+	elem.Nodes = doc.Nodes[index : index+1]
+	return elem
+}
+
+func putDocument(doc *Document) {
+	doc.Nodes = nil
+	documentPool.Put(doc)
+}
+
+// metaSelector returns a selector for the content of the <meta> tag with the
+// given name or property. As a fallback it matches a <meta> attribute with the
+// same name, so metaSelector("charset") finds <meta charset="...">.
+func metaSelector(name string) string {
+	lit := xpathLiteral(name)
+	return fmt.Sprintf("(//meta[@name=%s or @property=%s]/@content | //meta/@*[name()=%s])[1]", lit, lit, lit)
+}
+
 type Document struct {
 	Nodes []*html.Node
 }
@@ -35,47 +90,136 @@ func (doc *Document) IsEmpty() bool {
 	return doc.Nodes == nil || len(doc.Nodes) == 0
 }
 
+// Html renders every node of the selection, the nodes themselves included.
+// Unlike goquery, where Html renders the children of the first node only, it
+// is the same as OuterHtml.
 func (doc *Document) Html() (ret string, e error) {
+	return doc.OutputHtml(true)
+}
+
+// OuterHtml renders every node of the selection, the nodes themselves
+// included, e.g. "<li>a</li><li>b</li>".
+func (doc *Document) OuterHtml() (string, error) {
+	return doc.OutputHtml(true)
+}
+
+// OutputHtml renders every node of the selection one after the other: the
+// nodes themselves if self is set, their children only otherwise, e.g.
+// "ab" rather than "<li>a</li><li>b</li>".
+func (doc *Document) OutputHtml(self bool) (string, error) {
 	// Since there is no .innerHtml, the HTML content must be re-created from
 	// the nodes using html.Render.
-	var buf bytes.Buffer
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	if len(doc.Nodes) > 0 {
-		for _, node := range doc.Nodes {
-			e = html.Render(&buf, node)
-			if e != nil {
-				return
+	for _, node := range doc.Nodes {
+		if self {
+			if err := html.Render(buf, node); err != nil {
+				return "", err
 			}
+			continue
 		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(buf, c); err != nil {
+				return "", err
+			}
+		}
+	}
+	return buf.String(), nil
+}
 
-		ret = buf.String()
+// Text returns the text of the selection: the data of the text nodes among
+// the selected nodes and their descendants, newlines and spaces included,
+// like jQuery. A lone text node is returned as is, otherwise the text is
+// copied once.
+func (doc *Document) Text() string {
+	var first string
+	count, size := 0, 0
+	doc.eachText(func(s string) {
+		first = s
+		count++
+		size += len(s)
+	})
+	if count <= 1 {
+		return first
 	}
 
-	return
+	var b strings.Builder
+	b.Grow(size)
+	doc.eachText(func(s string) {
+		b.WriteString(s)
+	})
+	return b.String()
 }
 
-func (doc *Document) Text() string {
-	var buf bytes.Buffer
+// WriteText writes the text of the selection, as returned by Text, to w
+// without building it in memory, e.g. to save multi-megabyte article bodies.
+func (doc *Document) WriteText(w io.Writer) error {
+	var err error
+	doc.eachText(func(s string) {
+		if err == nil {
+			_, err = io.WriteString(w, s)
+		}
+	})
+	return err
+}
 
+// eachText calls f with the data of every text node of the selection, in
+// document order.
+func (doc *Document) eachText(f func(string)) {
 	// Slightly optimized vs calling Each: no single selection object created
-	var f func(*html.Node)
-	f = func(n *html.Node) {
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
 		if n.Type == html.TextNode {
-			// Keep newlines and spaces, like jQuery
-			buf.WriteString(n.Data)
+			f(n.Data)
 		}
-		if n.FirstChild != nil {
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				f(c)
-			}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
 		}
 	}
 
 	for _, n := range doc.Nodes {
-		f(n)
+		walk(n)
+	}
+}
+
+// TextTruncate returns the text of the document cut to at most max runes. The
+// ellipsis is appended only when the text was actually cut. Unlike slicing the
+// result of Text, it never splits a multibyte rune.
+func (doc *Document) TextTruncate(max int, ellipsis string) string {
+	text := doc.Text()
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+
+	n := 0
+	for i := range text {
+		if n >= max {
+			return text[:i] + ellipsis
+		}
+		n++
 	}
+	return text
+}
 
-	return buf.String()
+// Title returns the trimmed text of the page <title>.
+func (doc *Document) Title() string {
+	if doc.IsEmpty() {
+		return ""
+	}
+	title, _ := doc.FindOne(titleSelector)
+	return strings.TrimSpace(title.Text())
+}
+
+// Meta returns the content of the <meta> tag with the given name or property,
+// e.g. Meta("description") or Meta("og:title"). Attributes of <meta> itself are
+// matched too, so Meta("charset") returns the declared charset.
+func (doc *Document) Meta(name string) string {
+	if doc.IsEmpty() {
+		return ""
+	}
+	meta, _ := doc.FindOne(metaSelector(name))
+	return strings.TrimSpace(meta.Text())
 }
 
 func (doc *Document) Attr(attrName string) (val string, exists bool) {
@@ -85,17 +229,196 @@ func (doc *Document) Attr(attrName string) (val string, exists bool) {
 	return getAttributeValue(attrName, doc.Nodes[0])
 }
 
+// AttrOr returns the value of the named attribute of the first node, or def
+// if the selection is empty or the node doesn't have the attribute.
+func (doc *Document) AttrOr(attrName, def string) string {
+	if val, ok := doc.Attr(attrName); ok {
+		return val
+	}
+	return def
+}
+
+// Attrs returns all the attributes of the first node by name. The map is
+// empty if the selection is.
+func (doc *Document) Attrs() map[string]string {
+	attrs := map[string]string{}
+	if len(doc.Nodes) == 0 {
+		return attrs
+	}
+	for _, a := range doc.Nodes[0].Attr {
+		attrs[a.Key] = a.Val
+	}
+	return attrs
+}
+
+// Find returns the nodes matching the selector evaluated from every node of
+// the selection, without duplicates and in document order. It panics if the
+// selector is invalid, see FindErr.
 func (doc *Document) Find(selector string) *Document {
-	return NewDocumentWithNodes(htmlquery.Find(doc.Nodes[0], selector))
+	sel, err := doc.FindErr(selector)
+	if err != nil {
+		panic(err)
+	}
+	return sel
 }
 
+// FindErr is like Find but returns an error for an invalid selector instead
+// of panicking.
+func (doc *Document) FindErr(selector string) (*Document, error) {
+	if doc.IsEmpty() {
+		_, err := xpath.Compile(selector)
+		return &Document{}, err
+	}
+
+	var err error
+	sel := doc.findAll(func(n *html.Node) []*html.Node {
+		var nodes []*html.Node
+		if err == nil {
+			nodes, err = htmlquery.QueryAll(n, selector)
+		}
+		return nodes
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// FindExpr is like Find for a compiled selector, as used by the decoders
+// generated by goxtag-gen.
+func (doc *Document) FindExpr(expr *xpath.Expr) *Document {
+	return doc.findAll(func(n *html.Node) []*html.Node {
+		return htmlquery.QuerySelectorAll(n, expr)
+	})
+}
+
+// FindOne returns the first node matching the selector evaluated from the
+// nodes of the selection, trying them in order. It returns an error for an
+// invalid selector.
 func (doc *Document) FindOne(selector string) (*Document, error) {
-	node := htmlquery.FindOne(doc.Nodes[0], selector)
+	if doc.IsEmpty() {
+		_, err := xpath.Compile(selector)
+		return &Document{}, err
+	}
+
+	var err error
+	sel := doc.findFirst(func(n *html.Node) *html.Node {
+		var node *html.Node
+		if err == nil {
+			node, err = htmlquery.Query(n, selector)
+		}
+		return node
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// findAll runs query from every node of the selection and merges the results,
+// dropping duplicates and keeping them in document order.
+func (doc *Document) findAll(query func(*html.Node) []*html.Node) *Document {
+	switch len(doc.Nodes) {
+	case 0:
+		return &Document{}
+	case 1:
+		return NewDocumentWithNodes(query(doc.Nodes[0]))
+	}
+
 	var nodes []*html.Node
-	if node != nil {
-		nodes = []*html.Node{node}
+	seen := map[*html.Node]bool{}
+	for _, n := range doc.Nodes {
+		for _, found := range query(n) {
+			if !seen[found] {
+				seen[found] = true
+				nodes = append(nodes, found)
+			}
+		}
+	}
+	sortInDocumentOrder(nodes)
+	return NewDocumentWithNodes(nodes)
+}
+
+// findFirst returns the first node query finds from the nodes of the
+// selection, trying them in order.
+func (doc *Document) findFirst(query func(*html.Node) *html.Node) *Document {
+	for _, n := range doc.Nodes {
+		if found := query(n); found != nil {
+			return NewDocumentWithNode(found)
+		}
 	}
-	return NewDocumentWithNodes(nodes), nil
+	return &Document{}
+}
+
+// sortInDocumentOrder sorts nodes of the same tree in document order. Nodes
+// detached from any tree, like the ones standing for attributes, have no
+// order, so nodes are left as they are if there are any.
+func sortInDocumentOrder(nodes []*html.Node) {
+	paths := make(map[*html.Node][]int, len(nodes))
+	for _, n := range nodes {
+		if n.Parent == nil && n.Type != html.DocumentNode {
+			return
+		}
+		paths[n] = nodePath(n)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := paths[nodes[i]], paths[nodes[j]]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+// nodePath returns the positions of a node and its ancestors among their
+// siblings, starting from the root.
+func nodePath(n *html.Node) []int {
+	var path []int
+	for ; n.Parent != nil; n = n.Parent {
+		i := 0
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			i++
+		}
+		path = append(path, i)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Root returns the root of the tree the first node belongs to, which is the
+// document node for parsed pages. It lets custom unmarshalers run absolute
+// queries (e.g. against <head>) from the subtree they were given.
+func (doc *Document) Root() *Document {
+	if doc.IsEmpty() {
+		return &Document{}
+	}
+
+	root := doc.Nodes[0]
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return NewDocumentWithNode(root)
+}
+
+// findCSS is the CSS counterpart of Find: it returns the descendants of the
+// nodes of the selection matching a CSS selector.
+func (doc *Document) findCSS(selector string) (*Document, error) {
+	sel, err := cascadia.ParseGroup(selector)
+	if err != nil {
+		return nil, err
+	}
+	return doc.findCSSSelector(sel), nil
+}
+
+func (doc *Document) findCSSSelector(sel cascadia.SelectorGroup) *Document {
+	return doc.findAll(func(n *html.Node) []*html.Node {
+		return cascadia.QueryAll(n, sel)
+	})
 }
 
 func (doc *Document) Eq(index int) *Document {
@@ -122,6 +445,32 @@ func (doc *Document) Slice(start, end int) *Document {
 	return NewDocumentWithNodes(doc.Nodes[start:end])
 }
 
+// IsHidden reports whether the first node is hidden by the hidden attribute or
+// by an inline display:none or visibility:hidden style. Styles coming from
+// classes and stylesheets are not taken into account.
+func (doc *Document) IsHidden() bool {
+	if doc.IsEmpty() {
+		return false
+	}
+	return isHidden(doc.Nodes[0], defaultAttrGetter)
+}
+
+func isHidden(n *html.Node, attr AttrGetter) bool {
+	if _, ok := attr(n, "hidden"); ok {
+		return true
+	}
+
+	style, _ := attr(n, "style")
+	style = strings.ToLower(strings.Join(strings.Fields(style), ""))
+	for _, decl := range strings.Split(style, ";") {
+		switch strings.TrimSuffix(decl, "!important") {
+		case "display:none", "visibility:hidden":
+			return true
+		}
+	}
+	return false
+}
+
 func getAttributeValue(attrName string, n *html.Node) (val string, exists bool) {
 	if a := getAttributePtr(attrName, n); a != nil {
 		val = a.Val
@@ -0,0 +1,229 @@
+package goxtag
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func parseTestDocument(t *testing.T, page string) *Document {
+	root, err := html.Parse(bytes.NewReader([]byte(page)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDocumentWithNode(root)
+}
+
+func TestTextTruncate(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<p>Привет, мир</p>`).Find(".//p")
+
+	asrt.Equal("Привет…", doc.TextTruncate(6, "…"))
+	asrt.Equal("Пр...", doc.TextTruncate(2, "..."))
+	asrt.Equal("Привет, мир", doc.TextTruncate(11, "…"))
+	asrt.Equal("Привет, мир", doc.TextTruncate(100, "…"))
+	asrt.Equal("…", doc.TextTruncate(0, "…"))
+}
+
+func TestTitleAndMeta(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<html><head>
+		<title> Hello </title>
+		<meta charset="utf-8">
+		<meta name="description" content="A test page">
+		<meta property="og:title" content="It's a title">
+	</head><body><svg><title>Icon</title></svg></body></html>`)
+
+	asrt.Equal("Hello", doc.Title())
+	asrt.Equal("utf-8", doc.Meta("charset"))
+	asrt.Equal("A test page", doc.Meta("description"))
+	asrt.Equal("It's a title", doc.Meta("og:title"))
+	asrt.Equal("", doc.Meta("keywords"))
+	asrt.Equal("", (&Document{}).Title())
+}
+
+func TestIsHidden(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<div id="a" style="color: red; DISPLAY: none"></div>
+		<div id="b" style="visibility:hidden !important"></div>
+		<div id="c" hidden></div>
+		<div id="d" style="display: block"></div>`)
+
+	asrt.True(doc.Find("//*[@id='a']").IsHidden())
+	asrt.True(doc.Find("//*[@id='b']").IsHidden())
+	asrt.True(doc.Find("//*[@id='c']").IsHidden())
+	asrt.False(doc.Find("//*[@id='d']").IsHidden())
+	asrt.False((&Document{}).IsHidden())
+}
+
+func TestRoot(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, testPage)
+	resource := doc.Find(".//*[contains(concat(' ',normalize-space(@class),' '),' resource ')]").Eq(2)
+
+	root := resource.Root()
+	asrt.Equal(doc.Nodes, root.Nodes)
+	asrt.Equal(html.DocumentNode, root.Nodes[0].Type)
+	asrt.Equal(1, root.Find(".//head/title").Length())
+	asrt.True((&Document{}).Root().IsEmpty())
+}
+
+func TestFindAllNodes(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<div id="a"><p>1</p><div id="b"><p>2</p></div></div><div id="c"><p>3</p></div>`)
+
+	// Nested and reversed selections give each node once, in document order
+	divs := NewDocumentWithNodes([]*html.Node{
+		doc.Find("//div[@id='c']").Nodes[0],
+		doc.Find("//div[@id='b']").Nodes[0],
+		doc.Find("//div[@id='a']").Nodes[0],
+	})
+	ps := divs.Find(".//p")
+	asrt.Equal(3, ps.Length())
+	for i, want := range []string{"1", "2", "3"} {
+		asrt.Equal(want, ps.Eq(i).Text())
+	}
+
+	css, err := divs.findCSS("p")
+	asrt.NoError(err)
+	asrt.Equal(ps.Nodes, css.Nodes)
+
+	one, err := divs.FindOne("./p")
+	asrt.NoError(err)
+	asrt.Equal("3", one.Text())
+
+	// Attribute results are detached from the tree and keep selection order
+	ids := divs.Find("./@id")
+	asrt.Equal(3, ids.Length())
+	asrt.Equal("c", ids.Eq(0).Text())
+	asrt.Equal("a", ids.Eq(2).Text())
+
+	asrt.True((&Document{}).Find("//p").IsEmpty())
+	empty, err := (&Document{}).FindOne("//p")
+	asrt.NoError(err)
+	asrt.True(empty.IsEmpty())
+}
+
+func TestFindErr(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<p>1</p><p>2</p>`)
+
+	ps, err := doc.FindErr("//p")
+	asrt.NoError(err)
+	asrt.Equal(2, ps.Length())
+
+	_, err = doc.FindErr("//p[")
+	asrt.Error(err)
+	_, err = doc.FindOne("//p[")
+	asrt.Error(err)
+	_, err = (&Document{}).FindErr("//p[")
+	asrt.Error(err)
+	_, err = (&Document{}).FindOne("//p[")
+	asrt.Error(err)
+	asrt.Panics(func() { doc.Find("//p[") })
+
+	// Invalid tags make Unmarshal fail instead of panicking
+	var a struct {
+		P []string `xpath:"//p["`
+	}
+	asrt.Error(Unmarshal([]byte(`<p>1</p>`), &a))
+
+	var b struct {
+		P []string `xpath:"//body" xpath_flatten:"true" xpath_child:"./p["`
+	}
+	asrt.Error(Unmarshal([]byte(`<p>1</p>`), &b))
+}
+
+func TestAttrOrAndAttrs(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<a href="/x" data-empty="" title="X">x</a><a href="/y">y</a>`)
+	links := doc.Find("//a")
+
+	asrt.Equal("/x", links.AttrOr("href", "#"))
+	asrt.Equal("", links.AttrOr("data-empty", "def"))
+	asrt.Equal("def", links.AttrOr("rel", "def"))
+	asrt.Equal("def", (&Document{}).AttrOr("href", "def"))
+
+	asrt.Equal(map[string]string{"href": "/x", "data-empty": "", "title": "X"}, links.Attrs())
+	asrt.Equal(map[string]string{"href": "/y"}, links.Eq(1).Attrs())
+	asrt.Empty((&Document{}).Attrs())
+}
+
+func TestPooledBuffers(t *testing.T) {
+	asrt := assert.New(t)
+
+	big := parseTestDocument(t, "<p>"+strings.Repeat("x", maxPooledBuffer+1)+"</p>")
+	small := parseTestDocument(t, "<p>a<b>b</b></p><p>c</p>")
+
+	text := big.Text()
+	asrt.Len(text, maxPooledBuffer+1)
+	asrt.Equal("abc", small.Text())
+
+	// Results don't share the pooled buffers
+	first := small.Find("//p").Eq(0).Text()
+	second := small.Find("//p").Eq(1).Text()
+	asrt.Equal("ab", first)
+	asrt.Equal("c", second)
+
+	h1, err := small.Find("//b").Html()
+	asrt.NoError(err)
+	h2, err := small.Find("//p").Html()
+	asrt.NoError(err)
+	asrt.Equal("<b>b</b>", h1)
+	asrt.Equal("<p>a<b>b</b></p><p>c</p>", h2)
+}
+
+func TestDocument_OutputHtml(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<ul><li>a <b>1</b></li><li class="x">b</li></ul>`)
+	items := doc.Find("//li")
+
+	outer, err := items.OuterHtml()
+	asrt.NoError(err)
+	asrt.Equal(`<li>a <b>1</b></li><li class="x">b</li>`, outer)
+
+	inner, err := items.OutputHtml(false)
+	asrt.NoError(err)
+	asrt.Equal(`a <b>1</b>b`, inner)
+
+	empty, err := doc.Find("//table").OuterHtml()
+	asrt.NoError(err)
+	asrt.Empty(empty)
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestWriteText(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, "<article><h1>Title</h1>\n<p>One <b>two</b></p><p>three</p></article>")
+	article := doc.Find("//article")
+
+	var buf bytes.Buffer
+	asrt.NoError(article.WriteText(&buf))
+	asrt.Equal("Title\nOne twothree", buf.String())
+	asrt.Equal(buf.String(), article.Text())
+	asrt.Equal("three", doc.Find("//p[2]").Text())
+	asrt.Equal("", doc.Find("//br").Text())
+
+	asrt.EqualError(article.WriteText(&failingWriter{n: 2}), "disk full")
+}
@@ -0,0 +1,67 @@
+package goxtag
+
+import (
+	"strings"
+	"time"
+)
+
+// Feed is an RSS 2.0, RSS 1.0 (RDF) or Atom feed, see ParseFeed.
+type Feed struct {
+	Title       string    `xpath:"(/rss/channel/title | /*[name()='rdf:RDF']/channel/title | /feed/title)[1]" xpath_required:"false"`
+	Link        string    `xpath:"(/rss/channel/link | /*[name()='rdf:RDF']/channel/link | /feed/link[not(@rel) or @rel='alternate']/@href)[1]" xpath_required:"false"`
+	Description string    `xpath:"(/rss/channel/description | /*[name()='rdf:RDF']/channel/description | /feed/subtitle)[1]" xpath_required:"false"`
+	Updated     time.Time `xpath:"(/rss/channel/lastBuildDate | /rss/channel/pubDate | /*[name()='rdf:RDF']/channel/*[name()='dc:date'] | /feed/updated)[1]" xpath_required:"false"`
+	Items       []Item    `xpath:"/rss/channel/item | /*[name()='rdf:RDF']/item | /feed/entry" xpath_required:"false"`
+}
+
+// Item is an item of an RSS feed or an entry of an Atom feed.
+type Item struct {
+	Title       string    `xpath:"./title" xpath_required:"false"`
+	Link        string    `xpath:"(./link[not(@href)] | ./link[not(@rel) or @rel='alternate']/@href)[1]" xpath_required:"false"`
+	ID          string    `xpath:"(./guid | ./id | ./@*[name()='rdf:about'])[1]" xpath_required:"false"`
+	Description string    `xpath:"(./description | ./summary)[1]" xpath_required:"false"`
+	Content     string    `xpath:"(./*[name()='content:encoded'] | ./content)[1]" xpath_required:"false"`
+	Author      string    `xpath:"(./author/name | ./author[not(name)] | ./*[name()='dc:creator'])[1]" xpath_required:"false"`
+	Categories  []string  `xpath:"./category[not(@term)] | ./category/@term | ./*[name()='dc:subject']" xpath_required:"false"`
+	Published   time.Time `xpath:"(./pubDate | ./published | ./*[name()='dc:date'] | ./updated)[1]" xpath_required:"false"`
+	Updated     time.Time `xpath:"(./updated | ./*[name()='atom:updated'])[1]" xpath_required:"false"`
+}
+
+// feedTimeLayouts are the date formats found in feeds: RFC 822 dates (with
+// 4-digit years or not) in RSS and RFC 3339 ones in Atom and Dublin Core.
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// parseFeedTime parses s with the first of feedTimeLayouts that fits. Dates
+// that fit none are left as zero: feeds are too often sloppy about them to
+// give up on the whole feed.
+func parseFeedTime(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// ParseFeed decodes an RSS 2.0, RSS 1.0 (RDF) or Atom feed with the XML
+// syntax, see WithSyntax. Missing elements are left empty and the dates of
+// every format used by feeds are recognized.
+func ParseFeed(bs []byte, opts ...Option) (*Feed, error) {
+	var feed Feed
+	opts = append([]Option{WithConverter(timeType, parseFeedTime)}, opts...)
+	if err := UnmarshalXML(bs, &feed, opts...); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
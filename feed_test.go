@@ -0,0 +1,93 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Blog</title>
+  <subtitle>Notes</subtitle>
+  <link href="https://example.com/feed.atom" rel="self"/>
+  <link href="https://example.com/"/>
+  <updated>2021-02-03T04:05:06Z</updated>
+  <entry>
+    <title>Hello</title>
+    <link rel="alternate" href="https://example.com/hello"/>
+    <id>urn:uuid:1</id>
+    <published>2021-02-01T00:00:00+01:00</published>
+    <updated>2021-02-02T00:00:00Z</updated>
+    <author><name>Ann</name></author>
+    <category term="go"/>
+    <category term="html"/>
+    <summary>Short</summary>
+    <content type="html">&lt;p&gt;Long&lt;/p&gt;</content>
+  </entry>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	asrt := assert.New(t)
+
+	feed, err := ParseFeed([]byte(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Feed</title>
+    <link>https://example.com/</link>
+    <description>News</description>
+    <lastBuildDate>Sat, 02 Jan 2021 03:04:05 +0000</lastBuildDate>
+    <item>
+      <title>First</title>
+      <link>https://example.com/1</link>
+      <guid isPermaLink="false">1</guid>
+      <description>Short</description>
+      <content:encoded><![CDATA[<p>Long</p>]]></content:encoded>
+      <dc:creator>Bob</dc:creator>
+      <category>go</category>
+      <pubDate>Fri, 1 Jan 2021 10:00:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Second</title>
+      <pubDate>yesterday</pubDate>
+    </item>
+  </channel>
+</rss>`))
+	asrt.NoError(err)
+	asrt.Equal("Feed", feed.Title)
+	asrt.Equal("https://example.com/", feed.Link)
+	asrt.Equal("News", feed.Description)
+	asrt.True(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC).Equal(feed.Updated))
+	asrt.Len(feed.Items, 2)
+	asrt.Equal(Item{
+		Title:       "First",
+		Link:        "https://example.com/1",
+		ID:          "1",
+		Description: "Short",
+		Content:     "<p>Long</p>",
+		Author:      "Bob",
+		Categories:  []string{"go"},
+		Published:   feed.Items[0].Published,
+	}, feed.Items[0])
+	asrt.True(time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC).Equal(feed.Items[0].Published))
+	asrt.True(feed.Items[1].Published.IsZero())
+
+	feed, err = ParseFeed([]byte(testAtom))
+	asrt.NoError(err)
+	asrt.Equal("Blog", feed.Title)
+	asrt.Equal("https://example.com/", feed.Link)
+	asrt.Equal("Notes", feed.Description)
+	asrt.Len(feed.Items, 1)
+	item := feed.Items[0]
+	asrt.Equal("https://example.com/hello", item.Link)
+	asrt.Equal("urn:uuid:1", item.ID)
+	asrt.Equal("Ann", item.Author)
+	asrt.Equal([]string{"go", "html"}, item.Categories)
+	asrt.Equal("Short", item.Description)
+	asrt.Equal("<p>Long</p>", item.Content)
+	asrt.True(time.Date(2021, 1, 31, 23, 0, 0, 0, time.UTC).Equal(item.Published))
+	asrt.True(time.Date(2021, 2, 2, 0, 0, 0, 0, time.UTC).Equal(item.Updated))
+
+	_, err = ParseFeed([]byte(`<rss><channel>`))
+	asrt.Error(err)
+}
@@ -0,0 +1,180 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+var urlValuesType = reflect.TypeOf(url.Values{})
+
+// Form is the state of a <form> as a browser would submit it, e.g. to replay
+// a login or search form. Values holds the successful controls: named
+// inputs, selects and textareas that are not disabled, checked checkboxes and
+// radio buttons only, and no buttons or file inputs.
+type Form struct {
+	// Action is the action attribute as written
+	Action string
+	// Method is the upper-cased method, GET by default
+	Method string
+	Values url.Values
+}
+
+// Form reads the form of the first node of the selection, with the controls
+// found in all of its nodes.
+func (doc *Document) Form() Form {
+	f := Form{Method: "GET", Values: url.Values{}}
+	if doc.IsEmpty() {
+		return f
+	}
+
+	f.Action, _ = getAttributeValue("action", doc.Nodes[0])
+	if method, ok := getAttributeValue("method", doc.Nodes[0]); ok && strings.TrimSpace(method) != "" {
+		f.Method = strings.ToUpper(strings.TrimSpace(method))
+	}
+	for _, n := range doc.Nodes {
+		collectFormValues(n, f.Values)
+	}
+	return f
+}
+
+// UnmarshalHTML implements Unmarshaler.
+func (f *Form) UnmarshalHTML(nodes []*html.Node) error {
+	*f = NewDocumentWithNodes(nodes).Form()
+	return nil
+}
+
+func collectFormValues(n *html.Node, values url.Values) {
+	if n.Type == html.ElementNode {
+		if _, disabled := getAttributeValue("disabled", n); disabled {
+			return
+		}
+		if name, _ := getAttributeValue("name", n); name != "" {
+			for _, val := range controlValues(n) {
+				values.Add(name, val)
+			}
+		}
+		if n.DataAtom == atom.Select || n.DataAtom == atom.Textarea {
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectFormValues(c, values)
+	}
+}
+
+// controlValues returns the values a form control submits.
+func controlValues(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.Input:
+		typ, _ := getAttributeValue("type", n)
+		val, _ := getAttributeValue("value", n)
+		switch strings.ToLower(typ) {
+		case "submit", "button", "image", "reset", "file":
+			return nil
+		case "checkbox", "radio":
+			if _, checked := getAttributeValue("checked", n); !checked {
+				return nil
+			}
+			if val == "" {
+				val = "on"
+			}
+		}
+		return []string{val}
+	case atom.Textarea:
+		return []string{strings.TrimPrefix(NewDocumentWithNode(n).Text(), "\n")}
+	case atom.Select:
+		return selectValues(n)
+	}
+	return nil
+}
+
+// selectValues returns the values of the selected options of a <select>, or
+// of its first option if none is selected and it is a single choice.
+func selectValues(n *html.Node) []string {
+	var options []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Option {
+				options = append(options, c)
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+
+	optionValue := func(o *html.Node) string {
+		if val, ok := getAttributeValue("value", o); ok {
+			return val
+		}
+		return strings.TrimSpace(NewDocumentWithNode(o).Text())
+	}
+
+	var vals []string
+	for _, o := range options {
+		_, selected := getAttributeValue("selected", o)
+		_, disabled := getAttributeValue("disabled", o)
+		if selected && !disabled {
+			vals = append(vals, optionValue(o))
+		}
+	}
+	_, multiple := getAttributeValue("multiple", n)
+	if len(vals) == 0 && !multiple && len(options) > 0 {
+		vals = append(vals, optionValue(options[0]))
+	}
+	if !multiple && len(vals) > 1 {
+		vals = vals[len(vals)-1:]
+	}
+	return vals
+}
+
+// unmarshalInput fills a field tagged with xpath_input from the values the
+// named control of the form in doc submits. Bools tell whether the control
+// submits a value at all (e.g. a checked checkbox), slices get every value
+// and other types the first one. Fields of controls that submit nothing are
+// left untouched.
+func (d *decodeState) unmarshalInput(doc *Document, v reflect.Value, tag xpathTag) error {
+	vals := doc.Form().Values[tag.input]
+	if len(vals) == 0 && TypeDeref(v.Type()).Kind() != reflect.Bool {
+		return nil
+	}
+
+	_, v = indirect(v)
+	switch {
+	case v.Kind() == reflect.Bool:
+		v.SetBool(len(vals) > 0)
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			_, ev := indirect(slice.Index(i))
+			if err := unmarshalText(val, ev); err != nil {
+				return &CannotUnmarshalError{
+					V:        v,
+					Reason:   ErrTypeConversion,
+					XPath:    tag.input,
+					Err:      err,
+					Val:      val,
+					FldOrIdx: i,
+				}
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	if err := unmarshalText(vals[0], v); err != nil {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTypeConversion,
+			XPath:  tag.input,
+			Err:    err,
+			Val:    vals[0],
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+const testForm = `<form id="login" action="/session" method="post">
+	<input type="hidden" name="csrf" value="t0k3n">
+	<input name="user" value="ann">
+	<input type="password" name="pass">
+	<input type="checkbox" name="remember" checked>
+	<input type="checkbox" name="newsletter" value="yes">
+	<input type="radio" name="plan" value="free">
+	<input type="radio" name="plan" value="pro" checked>
+	<input name="locked" value="x" disabled>
+	<select name="lang"><option value="en">English</option><option selected>Deutsch</option></select>
+	<select name="tags" multiple><option selected>a</option><option>b</option><option selected>c</option></select>
+	<select name="size"><option value="s">S</option><option value="m">M</option></select>
+	<textarea name="bio">
+Hello</textarea>
+	<input name="age" value="42">
+	<input type="submit" name="go" value="Sign in">
+</form>`
+
+func TestForm(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Form   Form       `xpath:"//form"`
+		Values url.Values `xpath:"//form[@id='login']"`
+		Login  struct {
+			CSRF       string   `xpath_input:"csrf"`
+			User       *string  `xpath_input:"user"`
+			Remember   bool     `xpath_input:"remember"`
+			Newsletter bool     `xpath_input:"newsletter"`
+			Plan       string   `xpath_input:"plan"`
+			Tags       []string `xpath_input:"tags"`
+			Age        int      `xpath_input:"age"`
+			Missing    *string  `xpath_input:"missing"`
+		} `xpath:"//form"`
+	}
+	asrt.NoError(Unmarshal([]byte(testForm), &a))
+
+	asrt.Equal("/session", a.Form.Action)
+	asrt.Equal("POST", a.Form.Method)
+	asrt.Equal(url.Values{
+		"csrf":     {"t0k3n"},
+		"user":     {"ann"},
+		"pass":     {""},
+		"remember": {"on"},
+		"plan":     {"pro"},
+		"lang":     {"Deutsch"},
+		"tags":     {"a", "c"},
+		"size":     {"s"},
+		"bio":      {"Hello"},
+		"age":      {"42"},
+	}, a.Form.Values)
+	asrt.Equal(a.Form.Values, a.Values)
+
+	asrt.Equal("t0k3n", a.Login.CSRF)
+	asrt.Equal("ann", *a.Login.User)
+	asrt.True(a.Login.Remember)
+	asrt.False(a.Login.Newsletter)
+	asrt.Equal("pro", a.Login.Plan)
+	asrt.Equal([]string{"a", "c"}, a.Login.Tags)
+	asrt.Equal(42, a.Login.Age)
+	asrt.Nil(a.Login.Missing)
+}
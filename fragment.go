@@ -0,0 +1,46 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+)
+
+// WithFragment parses documents as HTML fragments found in the context
+// element instead of complete pages, see UnmarshalFragment. A nil context
+// stands for <body>.
+func WithFragment(context *html.Node) Option {
+	return func(c *config) {
+		c.fragment = true
+		c.fragmentContext = context
+	}
+}
+
+// UnmarshalFragment is like Unmarshal for an HTML snippet: it is parsed with
+// html.ParseFragment as if it was found in the context element (<body> if
+// context is nil), so no implicit <html>, <head> and <body> are added around
+// it and elements like <td> or <option> are kept as they are. The nodes of the
+// fragment are the children of the document root, so absolute selectors like
+// "/p" match its top level elements.
+func UnmarshalFragment(bs []byte, context *html.Node, v interface{}, opts ...Option) error {
+	return UnmarshalWithOptions(bs, v, append(opts, WithFragment(context))...)
+}
+
+// parseFragment parses the fragment read from r in context and returns a
+// document node holding its nodes.
+func parseFragment(r io.Reader, context *html.Node) (*html.Node, error) {
+	if context == nil {
+		context = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	}
+
+	nodes, err := html.ParseFragment(r, context)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &html.Node{Type: html.DocumentNode}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return root, nil
+}
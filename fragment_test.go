@@ -0,0 +1,39 @@
+package goxtag
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"testing"
+)
+
+func TestUnmarshalFragment(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Top   []string `xpath:"/p"`
+		Cells []string `xpath:"//td" xpath_required:"false"`
+	}
+
+	snippet := []byte(`<p>one</p><p>two</p><td>cell</td>`)
+	asrt.NoError(UnmarshalFragment(snippet, nil, &a))
+	asrt.Equal([]string{"one", "two"}, a.Top)
+	asrt.Empty(a.Cells)
+
+	// A full parse wraps the snippet into <html><body>
+	a.Top = nil
+	e := checkErr(asrt, Unmarshal(snippet, &a))
+	asrt.Equal(ErrNodeNotFound, e.Reason)
+
+	row := &html.Node{Type: html.ElementNode, Data: "tr", DataAtom: atom.Tr}
+	var b struct {
+		Cells []int `xpath:"/td"`
+	}
+	asrt.NoError(UnmarshalFragment([]byte(`<td>1</td><td>2</td>`), row, &b))
+	asrt.Equal([]int{1, 2}, b.Cells)
+
+	b.Cells = nil
+	asrt.NoError(NewDecoder(bytes.NewReader([]byte(`<td>3</td>`)), WithFragment(row)).Decode(&b))
+	asrt.Equal([]int{3}, b.Cells)
+}
@@ -0,0 +1,413 @@
+// Package gen generates reflection-free decoders for xpath-tagged structs.
+//
+// Generate reads the struct definitions of a package from its source and
+// writes an UnmarshalHTML method for each of them, so that goxtag uses the
+// generated code instead of walking the struct with reflection. Selectors are
+// compiled once, when the package is loaded, and invalid ones are reported by
+// the generator, i.e. at build time. It is usually run through the
+// goxtag-gen command:
+//
+//	//go:generate go run github.com/azlotnikov/goxtag/cmd/goxtag-gen -type Product,Offer
+//
+// The generated decoders support a subset of goxtag: fields of string, bool,
+// integer and float types, of the generated struct types, of pointers to
+// those structs and slices of all of these, tagged with xpath and optionally
+// xpath_required. Untagged fields of generated struct types are decoded
+// against the selection of their parent, other untagged fields are left
+// alone. Other field types and tags are rejected, those structs have to be
+// decoded with goxtag.Unmarshal.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/antchfx/xpath"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	tagName     = "xpath"
+	requiredTag = "xpath_required"
+	ignoreTag   = "-"
+)
+
+var (
+	tagKeyRegEx = regexp.MustCompile(`(?:^|\s)([^\s:"]+):"`)
+	indexRegEx  = regexp.MustCompile(`\[\d+\]$`)
+
+	// basicTypes maps the supported literal types to the strconv call
+	// parsing them
+	basicTypes = map[string]string{
+		"string":  "",
+		"bool":    "strconv.ParseBool(s)",
+		"int":     "strconv.ParseInt(s, 10, 64)",
+		"int8":    "strconv.ParseInt(s, 10, 64)",
+		"int16":   "strconv.ParseInt(s, 10, 64)",
+		"int32":   "strconv.ParseInt(s, 10, 64)",
+		"int64":   "strconv.ParseInt(s, 10, 64)",
+		"uint":    "strconv.ParseUint(s, 10, 64)",
+		"uint8":   "strconv.ParseUint(s, 10, 64)",
+		"uint16":  "strconv.ParseUint(s, 10, 64)",
+		"uint32":  "strconv.ParseUint(s, 10, 64)",
+		"uint64":  "strconv.ParseUint(s, 10, 64)",
+		"float32": "strconv.ParseFloat(s, 64)",
+		"float64": "strconv.ParseFloat(s, 64)",
+	}
+)
+
+// field is a struct field the generator knows how to decode.
+type field struct {
+	name string
+	// sel is the xpath selector, empty for untagged struct fields
+	sel      string
+	required bool
+	// typ is the basic type or the name of the generated struct
+	typ    string
+	isStrc bool
+	ptr    bool
+	slice  bool
+	// many allows scalars to match several nodes, like goxtag does for
+	// selectors ending in an index or in text()
+	many bool
+}
+
+// Generate returns the source of the decoders of the named struct types of
+// the package in dir.
+func Generate(dir string, typeNames ...string) ([]byte, error) {
+	if len(typeNames) == 0 {
+		return nil, fmt.Errorf("no types to generate")
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	structs := map[string]*ast.StructType{}
+	for _, f := range pkg.Files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					structs[ts.Name.Name] = st
+				}
+			}
+		}
+	}
+
+	generated := map[string]bool{}
+	for _, name := range typeNames {
+		if structs[name] == nil {
+			return nil, fmt.Errorf("struct type %s not found in %s", name, dir)
+		}
+		generated[name] = true
+	}
+
+	g := &generator{imports: map[string]bool{}}
+	for _, name := range typeNames {
+		fields, err := structFields(name, structs[name], generated)
+		if err != nil {
+			return nil, err
+		}
+		g.decoder(name, fields)
+	}
+	return g.source(pkg.Name)
+}
+
+// structFields checks the fields of the struct type name and returns the ones
+// to decode.
+func structFields(name string, st *ast.StructType, generated map[string]bool) ([]field, error) {
+	var fields []field
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(s)
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			// Embedded fields are named after their type
+			names = []*ast.Ident{{Name: strings.TrimPrefix(typeName(f.Type), "*")}}
+		}
+		for _, n := range names {
+			fld, ok, err := newField(n.Name, f.Type, tag, generated)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", name, n.Name, err)
+			}
+			if ok {
+				fields = append(fields, fld)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// newField reads a field. It reports false for the fields that are left
+// alone.
+func newField(name string, expr ast.Expr, tag reflect.StructTag, generated map[string]bool) (field, bool, error) {
+	for _, m := range tagKeyRegEx.FindAllStringSubmatch(string(tag), -1) {
+		key := m[1]
+		if key != tagName && key != requiredTag && (strings.HasPrefix(key, tagName) ||
+			key == "css" || key == "th" || key == "itemprop" || key == "itemtype" || key == "jsonld") {
+			return field{}, false, fmt.Errorf("tag %s is not supported by the generator", key)
+		}
+	}
+
+	fld := field{name: name, sel: tag.Get(tagName), required: true}
+	if fld.sel == ignoreTag {
+		return fld, false, nil
+	}
+	if required := tag.Get(requiredTag); required != "" {
+		var err error
+		fld.required, err = strconv.ParseBool(required)
+		if err != nil {
+			return fld, false, fmt.Errorf("%s: %v", requiredTag, err)
+		}
+	}
+
+	if arr, ok := expr.(*ast.ArrayType); ok && arr.Len == nil {
+		fld.slice = true
+		expr = arr.Elt
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		fld.ptr = true
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	switch {
+	case !ok:
+	case generated[ident.Name]:
+		fld.typ, fld.isStrc = ident.Name, true
+	case !fld.ptr:
+		if _, basic := basicTypes[ident.Name]; basic {
+			fld.typ = ident.Name
+		}
+	}
+
+	if fld.sel == "" {
+		// Like goxtag, untagged fields are only decoded when they are
+		// Unmarshalers, which the generated structs are
+		return fld, fld.isStrc && !fld.slice, nil
+	}
+	if fld.typ == "" {
+		return fld, false, fmt.Errorf("type %s is not supported by the generator", typeName(expr))
+	}
+
+	switch {
+	case strings.HasPrefix(fld.sel, "meta:"), strings.Contains(fld.sel, ",attr="):
+		return fld, false, fmt.Errorf("selector %q uses goxtag shorthands not supported by the generator", fld.sel)
+	}
+	if _, err := xpath.Compile(fld.sel); err != nil {
+		return fld, false, fmt.Errorf("invalid selector %q: %v", fld.sel, err)
+	}
+	fld.many = indexRegEx.MatchString(fld.sel) || strings.HasSuffix(fld.sel, "text()")
+	return fld, true, nil
+}
+
+func typeName(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+// generator accumulates the generated code.
+type generator struct {
+	vars    bytes.Buffer
+	funcs   bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) source(pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by goxtag-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, strconv.Quote(imp))
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+
+	if g.vars.Len() > 0 {
+		fmt.Fprintf(&buf, "var (\n%s)\n\n", g.vars.String())
+	}
+	buf.Write(g.funcs.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+func (g *generator) use(imports ...string) {
+	for _, imp := range imports {
+		g.imports[imp] = true
+	}
+}
+
+// decoder writes the UnmarshalHTML method of the struct type name.
+func (g *generator) decoder(name string, fields []field) {
+	g.use("golang.org/x/net/html")
+	w := &g.funcs
+
+	fmt.Fprintf(w, "// UnmarshalHTML implements goxtag.Unmarshaler.\n")
+	fmt.Fprintf(w, "func (v *%s) UnmarshalHTML(nodes []*html.Node) error {\n", name)
+	for _, f := range fields {
+		if f.sel != "" {
+			g.use("github.com/azlotnikov/goxtag")
+			fmt.Fprintf(w, "doc := goxtag.NewDocumentWithNodes(nodes)\n")
+			break
+		}
+	}
+
+	for _, f := range fields {
+		fmt.Fprintf(w, "\n// %s\n", f.name)
+		if f.sel == "" {
+			g.structVal(w, f, "v."+f.name, "nodes", "")
+			continue
+		}
+
+		g.use("github.com/antchfx/xpath", "github.com/azlotnikov/goxtag", "reflect")
+		expr := "goxtag" + name + f.name
+		fmt.Fprintf(&g.vars, "%s = xpath.MustCompile(%s)\n", expr, strconv.Quote(f.sel))
+
+		if f.required {
+			fmt.Fprintf(w, "{\nsel := doc.FindExpr(%s)\n", expr)
+			fmt.Fprintf(w, "if sel.IsEmpty() {\nreturn &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: %q}\n}\n", f.sel)
+		} else {
+			fmt.Fprintf(w, "if sel := doc.FindExpr(%s); !sel.IsEmpty() {\n", expr)
+		}
+
+		switch {
+		case f.slice:
+			g.sliceVal(w, f)
+		case f.isStrc:
+			g.structVal(w, f, "v."+f.name, "sel.Nodes", "")
+		default:
+			if !f.many {
+				fmt.Fprintf(w, "if sel.Length() > 1 {\nreturn &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: %q}\n}\n", f.sel)
+			}
+			g.literalVal(w, f, "v."+f.name, "sel")
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+	fmt.Fprintf(w, "return nil\n}\n\n")
+}
+
+// fieldErr returns the error of field f wrapping the error of the value ptr
+// points to (or of its element at index idx), like goxtag does.
+func fieldErr(f field, ptr, idx, str string) string {
+	inner := fmt.Sprintf("&goxtag.CannotUnmarshalError{V: reflect.ValueOf(%s).Elem(), Reason: goxtag.ErrTypeConversion, Err: err", ptr)
+	if str != "" {
+		inner += ", Val: " + str
+	}
+	if idx != "" {
+		inner += ", FldOrIdx: " + idx
+	}
+	inner += "}"
+
+	outer := "&goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion"
+	if f.sel != "" {
+		outer += fmt.Sprintf(", XPath: %q", f.sel)
+	}
+	return fmt.Sprintf("%s, Err: %s, FldOrIdx: %q}", outer, inner, f.name)
+}
+
+// structVal decodes the nodes into the generated struct target.
+func (g *generator) structVal(w *bytes.Buffer, f field, target, nodes, idx string) {
+	g.use("github.com/azlotnikov/goxtag", "reflect")
+	if f.ptr && idx == "" {
+		fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", target, target, f.typ)
+	}
+	errVal := "&v." + f.name
+	if idx == "" && f.ptr {
+		errVal = "v." + f.name
+	}
+	fmt.Fprintf(w, "if err := %s.UnmarshalHTML(%s); err != nil {\nreturn %s\n}\n", target, nodes, fieldErr(f, errVal, idx, ""))
+}
+
+// sliceVal decodes every node of sel into an element of the slice field.
+func (g *generator) sliceVal(w *bytes.Buffer, f field) {
+	target := "v." + f.name
+	fmt.Fprintf(w, "%s = %s[:0]\n", target, target)
+	fmt.Fprintf(w, "for i := range sel.Nodes {\n")
+	switch {
+	case f.isStrc && f.ptr:
+		fmt.Fprintf(w, "e := new(%s)\n", f.typ)
+		g.structVal(w, f, "e", "sel.Nodes[i:i+1]", "i")
+	case f.isStrc:
+		fmt.Fprintf(w, "var e %s\n", f.typ)
+		g.structVal(w, f, "e", "sel.Nodes[i:i+1]", "i")
+	case f.typ == "string":
+		g.use("strings")
+		fmt.Fprintf(w, "e := strings.TrimSpace(sel.Eq(i).Text())\n")
+	default:
+		fmt.Fprintf(w, "var e %s\n", f.typ)
+		g.literalVal(w, f, "e", "sel.Eq(i)")
+	}
+	fmt.Fprintf(w, "%s = append(%s, e)\n}\n", target, target)
+}
+
+// literalVal converts the trimmed text of sel into the basic type of target.
+// Like goxtag, empty numbers are left as zero, as are numbers that fail to
+// parse in optional fields.
+func (g *generator) literalVal(w *bytes.Buffer, f field, target, sel string) {
+	g.use("strings")
+	parse := basicTypes[f.typ]
+	if parse == "" {
+		fmt.Fprintf(w, "%s = strings.TrimSpace(%s.Text())\n", target, sel)
+		return
+	}
+	fmt.Fprintf(w, "s := strings.TrimSpace(%s.Text())\n", sel)
+
+	idx := ""
+	errVal := "&" + target
+	if f.slice {
+		idx, errVal = "i", "&v."+f.name
+	}
+	conv := f.typ + "(n)"
+	if f.typ == "int64" || f.typ == "uint64" || f.typ == "float64" {
+		conv = "n"
+	}
+	switch f.typ {
+	case "bool":
+		g.use("strconv")
+		fmt.Fprintf(w, "b, err := %s\nif err != nil {\nreturn %s\n}\n%s = b\n", parse, fieldErr(f, errVal, idx, "s"), target)
+		return
+	}
+
+	g.use("strconv")
+	if f.required {
+		fmt.Fprintf(w, "if s != \"\" {\nn, err := %s\nif err != nil {\nreturn %s\n}\n%s = %s\n}\n", parse, fieldErr(f, errVal, idx, "s"), target, conv)
+	} else {
+		fmt.Fprintf(w, "if n, err := %s; err == nil {\n%s = %s\n}\n", parse, target, conv)
+	}
+}
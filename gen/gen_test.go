@@ -0,0 +1,55 @@
+package gen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	asrt := assert.New(t)
+
+	// The example decoders must be regenerated along with the generator
+	src, err := Generate("internal/example", "Product", "Offer", "Review", "Page")
+	asrt.NoError(err)
+	want, err := ioutil.ReadFile("internal/example/product_goxtag.go")
+	asrt.NoError(err)
+	asrt.Equal(string(want), string(src))
+}
+
+func TestGenerateErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "goxtag-gen")
+	if !asrt.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	src := "package shop\n\nimport \"time\"\n\n" +
+		"type BadSelector struct {\n\tName string `xpath:\"//h1[\"`\n}\n\n" +
+		"type BadTag struct {\n\tName string `xpath:\"//h1\" xpath_regex:\"\\\\d+\"`\n}\n\n" +
+		"type BadType struct {\n\tAt time.Time `xpath:\"//time\"`\n}\n\n" +
+		"type Shorthand struct {\n\tTitle string `xpath:\"meta:title\"`\n}\n\n" +
+		"type Other struct {\n\tBad BadType `xpath:\"//div\"`\n}\n"
+	asrt.NoError(ioutil.WriteFile(filepath.Join(dir, "shop.go"), []byte(src), 0644))
+
+	for typ, msg := range map[string]string{
+		"BadSelector": `BadSelector.Name: invalid selector "//h1["`,
+		"BadTag":      "BadTag.Name: tag xpath_regex is not supported",
+		"BadType":     "BadType.At: type time.Time is not supported",
+		"Shorthand":   `Shorthand.Title: selector "meta:title" uses goxtag shorthands`,
+		"Other":       "Other.Bad: type BadType is not supported",
+		"Missing":     "struct type Missing not found",
+	} {
+		_, err := Generate(dir, typ)
+		if asrt.Error(err, typ) {
+			asrt.Contains(err.Error(), msg)
+		}
+	}
+
+	_, err = Generate(dir, "BadType", "Other")
+	asrt.Error(err)
+}
@@ -0,0 +1,41 @@
+// Package example holds structs decoded by the code of goxtag-gen.
+package example
+
+//go:generate go run github.com/azlotnikov/goxtag/cmd/goxtag-gen -type Product,Offer,Review,Page
+
+// Product is a product page.
+type Product struct {
+	Name    string   `xpath:"//h1"`
+	SKU     int      `xpath:"//*[@id='sku']"`
+	Rating  float32  `xpath:"//*[@id='rating']" xpath_required:"false"`
+	InStock bool     `xpath:"//*[@id='stock']/@data-available"`
+	Tags    []string `xpath:"//ul[@class='tags']/li"`
+	Sizes   []uint8  `xpath:"//select/option/@value" xpath_required:"false"`
+	Offer   *Offer   `xpath:"//div[@class='offer']"`
+	Reviews []Review `xpath:"//div[@class='review']" xpath_required:"false"`
+	Note    string   `xpath:"-"`
+	Page
+}
+
+// Offer is the price of a product.
+type Offer struct {
+	Price    float64 `xpath:"./span[@class='price']"`
+	Currency string  `xpath:"./span[@class='price']/@data-currency"`
+}
+
+// Review is a customer review.
+type Review struct {
+	Author string `xpath:"./b"`
+	Stars  int    `xpath:"./i" xpath_required:"false"`
+}
+
+// Page holds the fields shared by every page.
+type Page struct {
+	Title string `xpath:"//title"`
+	Links []*Link
+}
+
+// Link is not generated and left alone.
+type Link struct {
+	URL string `xpath:"./@href"`
+}
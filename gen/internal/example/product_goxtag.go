@@ -0,0 +1,234 @@
+// Code generated by goxtag-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"github.com/antchfx/xpath"
+	"github.com/azlotnikov/goxtag"
+	"golang.org/x/net/html"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	goxtagProductName    = xpath.MustCompile("//h1")
+	goxtagProductSKU     = xpath.MustCompile("//*[@id='sku']")
+	goxtagProductRating  = xpath.MustCompile("//*[@id='rating']")
+	goxtagProductInStock = xpath.MustCompile("//*[@id='stock']/@data-available")
+	goxtagProductTags    = xpath.MustCompile("//ul[@class='tags']/li")
+	goxtagProductSizes   = xpath.MustCompile("//select/option/@value")
+	goxtagProductOffer   = xpath.MustCompile("//div[@class='offer']")
+	goxtagProductReviews = xpath.MustCompile("//div[@class='review']")
+	goxtagOfferPrice     = xpath.MustCompile("./span[@class='price']")
+	goxtagOfferCurrency  = xpath.MustCompile("./span[@class='price']/@data-currency")
+	goxtagReviewAuthor   = xpath.MustCompile("./b")
+	goxtagReviewStars    = xpath.MustCompile("./i")
+	goxtagPageTitle      = xpath.MustCompile("//title")
+)
+
+// UnmarshalHTML implements goxtag.Unmarshaler.
+func (v *Product) UnmarshalHTML(nodes []*html.Node) error {
+	doc := goxtag.NewDocumentWithNodes(nodes)
+
+	// Name
+	{
+		sel := doc.FindExpr(goxtagProductName)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "//h1"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "//h1"}
+		}
+		v.Name = strings.TrimSpace(sel.Text())
+	}
+
+	// SKU
+	{
+		sel := doc.FindExpr(goxtagProductSKU)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "//*[@id='sku']"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "//*[@id='sku']"}
+		}
+		s := strings.TrimSpace(sel.Text())
+		if s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion, XPath: "//*[@id='sku']", Err: &goxtag.CannotUnmarshalError{V: reflect.ValueOf(&v.SKU).Elem(), Reason: goxtag.ErrTypeConversion, Err: err, Val: s}, FldOrIdx: "SKU"}
+			}
+			v.SKU = int(n)
+		}
+	}
+
+	// Rating
+	if sel := doc.FindExpr(goxtagProductRating); !sel.IsEmpty() {
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "//*[@id='rating']"}
+		}
+		s := strings.TrimSpace(sel.Text())
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			v.Rating = float32(n)
+		}
+	}
+
+	// InStock
+	{
+		sel := doc.FindExpr(goxtagProductInStock)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "//*[@id='stock']/@data-available"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "//*[@id='stock']/@data-available"}
+		}
+		s := strings.TrimSpace(sel.Text())
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion, XPath: "//*[@id='stock']/@data-available", Err: &goxtag.CannotUnmarshalError{V: reflect.ValueOf(&v.InStock).Elem(), Reason: goxtag.ErrTypeConversion, Err: err, Val: s}, FldOrIdx: "InStock"}
+		}
+		v.InStock = b
+	}
+
+	// Tags
+	{
+		sel := doc.FindExpr(goxtagProductTags)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "//ul[@class='tags']/li"}
+		}
+		v.Tags = v.Tags[:0]
+		for i := range sel.Nodes {
+			e := strings.TrimSpace(sel.Eq(i).Text())
+			v.Tags = append(v.Tags, e)
+		}
+	}
+
+	// Sizes
+	if sel := doc.FindExpr(goxtagProductSizes); !sel.IsEmpty() {
+		v.Sizes = v.Sizes[:0]
+		for i := range sel.Nodes {
+			var e uint8
+			s := strings.TrimSpace(sel.Eq(i).Text())
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				e = uint8(n)
+			}
+			v.Sizes = append(v.Sizes, e)
+		}
+	}
+
+	// Offer
+	{
+		sel := doc.FindExpr(goxtagProductOffer)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "//div[@class='offer']"}
+		}
+		if v.Offer == nil {
+			v.Offer = new(Offer)
+		}
+		if err := v.Offer.UnmarshalHTML(sel.Nodes); err != nil {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion, XPath: "//div[@class='offer']", Err: &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v.Offer).Elem(), Reason: goxtag.ErrTypeConversion, Err: err}, FldOrIdx: "Offer"}
+		}
+	}
+
+	// Reviews
+	if sel := doc.FindExpr(goxtagProductReviews); !sel.IsEmpty() {
+		v.Reviews = v.Reviews[:0]
+		for i := range sel.Nodes {
+			var e Review
+			if err := e.UnmarshalHTML(sel.Nodes[i : i+1]); err != nil {
+				return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion, XPath: "//div[@class='review']", Err: &goxtag.CannotUnmarshalError{V: reflect.ValueOf(&v.Reviews).Elem(), Reason: goxtag.ErrTypeConversion, Err: err, FldOrIdx: i}, FldOrIdx: "Reviews"}
+			}
+			v.Reviews = append(v.Reviews, e)
+		}
+	}
+
+	// Page
+	if err := v.Page.UnmarshalHTML(nodes); err != nil {
+		return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion, Err: &goxtag.CannotUnmarshalError{V: reflect.ValueOf(&v.Page).Elem(), Reason: goxtag.ErrTypeConversion, Err: err}, FldOrIdx: "Page"}
+	}
+	return nil
+}
+
+// UnmarshalHTML implements goxtag.Unmarshaler.
+func (v *Offer) UnmarshalHTML(nodes []*html.Node) error {
+	doc := goxtag.NewDocumentWithNodes(nodes)
+
+	// Price
+	{
+		sel := doc.FindExpr(goxtagOfferPrice)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "./span[@class='price']"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "./span[@class='price']"}
+		}
+		s := strings.TrimSpace(sel.Text())
+		if s != "" {
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrTypeConversion, XPath: "./span[@class='price']", Err: &goxtag.CannotUnmarshalError{V: reflect.ValueOf(&v.Price).Elem(), Reason: goxtag.ErrTypeConversion, Err: err, Val: s}, FldOrIdx: "Price"}
+			}
+			v.Price = n
+		}
+	}
+
+	// Currency
+	{
+		sel := doc.FindExpr(goxtagOfferCurrency)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "./span[@class='price']/@data-currency"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "./span[@class='price']/@data-currency"}
+		}
+		v.Currency = strings.TrimSpace(sel.Text())
+	}
+	return nil
+}
+
+// UnmarshalHTML implements goxtag.Unmarshaler.
+func (v *Review) UnmarshalHTML(nodes []*html.Node) error {
+	doc := goxtag.NewDocumentWithNodes(nodes)
+
+	// Author
+	{
+		sel := doc.FindExpr(goxtagReviewAuthor)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "./b"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "./b"}
+		}
+		v.Author = strings.TrimSpace(sel.Text())
+	}
+
+	// Stars
+	if sel := doc.FindExpr(goxtagReviewStars); !sel.IsEmpty() {
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "./i"}
+		}
+		s := strings.TrimSpace(sel.Text())
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			v.Stars = int(n)
+		}
+	}
+	return nil
+}
+
+// UnmarshalHTML implements goxtag.Unmarshaler.
+func (v *Page) UnmarshalHTML(nodes []*html.Node) error {
+	doc := goxtag.NewDocumentWithNodes(nodes)
+
+	// Title
+	{
+		sel := doc.FindExpr(goxtagPageTitle)
+		if sel.IsEmpty() {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrNodeNotFound, XPath: "//title"}
+		}
+		if sel.Length() > 1 {
+			return &goxtag.CannotUnmarshalError{V: reflect.ValueOf(v).Elem(), Reason: goxtag.ErrMultipleNodes, XPath: "//title"}
+		}
+		v.Title = strings.TrimSpace(sel.Text())
+	}
+	return nil
+}
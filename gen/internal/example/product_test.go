@@ -0,0 +1,65 @@
+package example
+
+import (
+	"errors"
+	"github.com/azlotnikov/goxtag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testPage = `<html><head><title> Widget | Shop </title></head><body>
+<h1> Widget </h1>
+<span id="sku">1042</span>
+<span id="rating">n/a</span>
+<span id="stock" data-available="true"></span>
+<ul class="tags"><li>tools</li><li> home </li></ul>
+<select><option value="1">S</option><option value="x">?</option></select>
+<div class="offer"><span class="price" data-currency="EUR">9.50</span></div>
+<div class="review"><b>Ann</b><i>5</i></div>
+<div class="review"><b>Bob</b></div>
+<a href="/more">more</a>
+</body></html>`
+
+func TestGeneratedDecoder(t *testing.T) {
+	asrt := assert.New(t)
+
+	var p Product
+	asrt.NoError(goxtag.Unmarshal([]byte(testPage), &p))
+	asrt.Equal(Product{
+		Name:    "Widget",
+		SKU:     1042,
+		InStock: true,
+		Tags:    []string{"tools", "home"},
+		Sizes:   []uint8{1, 0},
+		Offer:   &Offer{Price: 9.5, Currency: "EUR"},
+		Reviews: []Review{{Author: "Ann", Stars: 5}, {Author: "Bob"}},
+		Page:    Page{Title: "Widget | Shop"},
+	}, p)
+
+	// Like with goxtag.Unmarshal, selectors are evaluated from the matched
+	// node, which is the root of // selectors
+	var q struct {
+		Products []Product `xpath:"//html"`
+	}
+	asrt.NoError(goxtag.Unmarshal([]byte(testPage), &q))
+	asrt.Len(q.Products, 1)
+}
+
+func TestGeneratedDecoderErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	var p Product
+	err := goxtag.Unmarshal([]byte(`<html><body><h1>Widget</h1><span id="sku">x</span></body></html>`), &p)
+	asrt.True(errors.Is(err, goxtag.ErrTypeConversion))
+	asrt.Contains(err.Error(), `"x"`)
+
+	var o Offer
+	err = goxtag.Unmarshal([]byte(`<div class="offer"></div>`), &o)
+	asrt.True(errors.Is(err, goxtag.ErrNodeNotFound))
+
+	var r struct {
+		Review Review `xpath:"//p"`
+	}
+	err = goxtag.Unmarshal([]byte(`<p><b>a</b><b>b</b></p>`), &r)
+	asrt.True(errors.Is(err, goxtag.ErrMultipleNodes))
+}
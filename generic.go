@@ -0,0 +1,102 @@
+package goxtag
+
+import (
+	"reflect"
+	"sync"
+)
+
+// planCache holds the plans of the types decoded by UnmarshalT and DecodeT,
+// by planKey, so that their tags are parsed and compiled once per process.
+var planCache sync.Map
+
+// planKey identifies the plans of a type parsed with the settings of a config
+// the tags depend on.
+type planKey struct {
+	t       reflect.Type
+	tagName string
+	strict  bool
+	numFmt  string
+}
+
+// cachedPlans returns the plans of t for the tag settings of c, parsing them
+// the first time. Types with invalid tags are not cached.
+func cachedPlans(t reflect.Type, c config) (map[reflect.Type][]fieldPlan, error) {
+	key := planKey{t: TypeDeref(t), tagName: c.tagName, strict: c.strict, numFmt: c.numFmt}
+	if plans, ok := planCache.Load(key); ok {
+		return plans.(map[reflect.Type][]fieldPlan), nil
+	}
+
+	plans := map[reflect.Type][]fieldPlan{}
+	d := &decodeState{config: c}
+	if err := d.planType(key.t, plans); err != nil {
+		return nil, err
+	}
+	actual, _ := planCache.LoadOrStore(key, plans)
+	return actual.(map[reflect.Type][]fieldPlan), nil
+}
+
+// UnmarshalT unmarshals the document into a new value of type T and returns
+// it, see UnmarshalWithOptions. The tags of T are parsed and compiled on the
+// first call only, like with a TypeDecoder.
+func UnmarshalT[T any](bs []byte, opts ...Option) (T, error) {
+	var v T
+	c := newConfig(opts)
+	plans, err := cachedPlans(reflect.TypeOf(&v).Elem(), c)
+	if err != nil {
+		return v, err
+	}
+
+	root, src, err := c.parseBytes(bs)
+	if err != nil {
+		return v, err
+	}
+
+	d := &decodeState{config: c, plans: plans, source: src}
+	err = d.unmarshal(NewDocumentWithNode(root), &v)
+	return v, err
+}
+
+// DecodeT is like Decoder.Decode into a new value of type T, which it
+// returns. The tags of T are cached as with UnmarshalT.
+func DecodeT[T any](dec *Decoder) (T, error) {
+	var v T
+	if dec.err != nil {
+		return v, dec.err
+	}
+	if dec.topNode == nil {
+		return v, &CannotUnmarshalError{
+			Reason: ErrNilDocument,
+		}
+	}
+
+	plans, err := cachedPlans(reflect.TypeOf(&v).Elem(), dec.config)
+	if err != nil {
+		return v, err
+	}
+
+	d := &decodeState{config: dec.config, plans: plans, source: dec.source}
+	err = d.unmarshal(NewDocumentWithNode(dec.topNode), &v)
+	return v, err
+}
+
+// DecodeAll decodes every node matching selector, evaluated from doc, into a
+// value of type T, the most common scraping operation, without a wrapper
+// struct holding a slice. The tags of T are cached as with UnmarshalT. It
+// returns the query error as is for an invalid selector.
+func DecodeAll[T any](doc *Document, selector string, opts ...Option) ([]T, error) {
+	c := newConfig(opts)
+	plans, err := cachedPlans(reflect.TypeOf((*T)(nil)).Elem(), c)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decodeState{config: c, plans: plans}
+	sel, err := d.findAll(doc, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var vs []T
+	err = d.unmarshal(sel, &vs)
+	return vs, err
+}
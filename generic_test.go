@@ -0,0 +1,96 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalT(t *testing.T) {
+	asrt := assert.New(t)
+
+	type item struct {
+		Name  string `xpath:"./b"`
+		Price int    `xpath:"./i"`
+	}
+	type page struct {
+		Title string `xpath:"//h1"`
+		Items []item `xpath:"//li"`
+	}
+	src := []byte(`<h1>Shop</h1><ul><li><b>a</b><i>1</i></li><li><b>b</b><i>2</i></li></ul>`)
+
+	p, err := UnmarshalT[page](src)
+	asrt.NoError(err)
+	asrt.Equal("Shop", p.Title)
+	asrt.Equal([]item{{"a", 1}, {"b", 2}}, p.Items)
+
+	_, ok := planCache.Load(planKey{t: reflect.TypeOf(page{}), tagName: tagName})
+	asrt.True(ok)
+
+	ptr, err := UnmarshalT[*page](src)
+	asrt.NoError(err)
+	asrt.Equal("Shop", ptr.Title)
+
+	type htmlPage struct {
+		Title string `html:"//h1"`
+	}
+	hp, err := UnmarshalT[htmlPage](src, WithTagName("html"))
+	asrt.NoError(err)
+	asrt.Equal("Shop", hp.Title)
+
+	_, err = UnmarshalT[struct {
+		Title string `xpath:"//h1["`
+	}](src)
+	asrt.True(errors.Is(err, ErrInvalidTag))
+
+	_, err = UnmarshalT[struct {
+		Missing string `xpath:"//h2"`
+	}](src)
+	asrt.True(errors.Is(err, ErrNodeNotFound))
+}
+
+func TestDecodeT(t *testing.T) {
+	asrt := assert.New(t)
+
+	type page struct {
+		Title string `xpath:"//h1"`
+	}
+
+	p, err := DecodeT[page](NewDecoder(strings.NewReader(`<h1>Shop</h1>`)))
+	asrt.NoError(err)
+	asrt.Equal("Shop", p.Title)
+
+	_, err = DecodeT[page](NewDecoder(strings.NewReader(`<h2>Shop</h2>`)))
+	asrt.True(errors.Is(err, ErrNodeNotFound))
+}
+
+func TestDecodeAll(t *testing.T) {
+	asrt := assert.New(t)
+
+	type item struct {
+		Name  string `xpath:"./b"`
+		Price int    `xpath:"./i"`
+	}
+	doc := parseTestDocument(t, `<ul><li><b>a</b><i>1</i></li><li><b>b</b><i>2</i></li><li><b>c</b><i>x</i></li></ul>`)
+
+	items, err := DecodeAll[item](doc, "//li[position() < 3]")
+	asrt.NoError(err)
+	asrt.Equal([]item{{"a", 1}, {"b", 2}}, items)
+
+	names, err := DecodeAll[string](doc, "//li/b")
+	asrt.NoError(err)
+	asrt.Equal([]string{"a", "b", "c"}, names)
+
+	none, err := DecodeAll[item](doc, "//tr")
+	asrt.NoError(err)
+	asrt.Empty(none)
+
+	_, err = DecodeAll[item](doc, "//li")
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Equal(2, err.(*CannotUnmarshalError).FldOrIdx)
+
+	_, err = DecodeAll[item](doc, "//li[")
+	asrt.Error(err)
+}
@@ -0,0 +1,237 @@
+package goxtag
+
+import (
+	"fmt"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+	"time"
+)
+
+// deadlineCheckInterval is the number of node visits between two checks of
+// the deadline of a selector, so that the clock isn't read at every step
+const deadlineCheckInterval = 256
+
+// WithSelectorTimeout bounds the time the XPath selector of a field may take
+// to be evaluated, so that a pathological expression over a large document
+// fails with ErrSelectorTimeout instead of running for seconds. The error
+// names the field and its selector.
+func WithSelectorTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.selectorTimeout = timeout
+	}
+}
+
+// WithSelectorBudget bounds the number of nodes the XPath selector of a field
+// may visit while it is evaluated, see WithSelectorTimeout. Unlike a timeout
+// the budget doesn't depend on the load of the machine.
+func WithSelectorBudget(visits int) Option {
+	return func(c *config) {
+		c.selectorBudget = visits
+	}
+}
+
+func (c config) guardsSelectors() bool {
+	return c.selectorTimeout > 0 || c.selectorBudget > 0
+}
+
+// guardError is the error of a selector stopped by its guard.
+type guardError string
+
+func (e guardError) Error() string {
+	return string(e)
+}
+
+func (e guardError) Is(target error) bool {
+	return target == ErrSelectorTimeout
+}
+
+// queryGuard counts the nodes visited by a selector and stops it, by
+// panicking with itself, once it exceeds its budget or deadline.
+type queryGuard struct {
+	visits   int
+	budget   int
+	deadline time.Time
+	err      guardError
+}
+
+func (g *queryGuard) visit() {
+	if g == nil {
+		return
+	}
+	g.visits++
+	if g.budget > 0 && g.visits > g.budget {
+		g.err = guardError(fmt.Sprintf("budget of %d node visits exhausted", g.budget))
+		panic(g)
+	}
+	if !g.deadline.IsZero() && g.visits%deadlineCheckInterval == 0 && time.Now().After(g.deadline) {
+		g.err = guardError(fmt.Sprintf("deadline exceeded after %d node visits", g.visits))
+		panic(g)
+	}
+}
+
+// queryNavigator is an htmlquery navigator counting its moves with a
+// queryGuard, if any, and resolving namespace prefixes if ns is set, see
+// WithNamespaces. Its copies share the guard.
+type queryNavigator struct {
+	*htmlquery.NodeNavigator
+	g  *queryGuard
+	ns bool
+}
+
+func (n *queryNavigator) Copy() xpath.NodeNavigator {
+	return &queryNavigator{n.NodeNavigator.Copy().(*htmlquery.NodeNavigator), n.g, n.ns}
+}
+
+func (n *queryNavigator) MoveToParent() bool {
+	n.g.visit()
+	return n.NodeNavigator.MoveToParent()
+}
+
+func (n *queryNavigator) MoveToNextAttribute() bool {
+	n.g.visit()
+	return n.NodeNavigator.MoveToNextAttribute()
+}
+
+func (n *queryNavigator) MoveToChild() bool {
+	n.g.visit()
+	return n.NodeNavigator.MoveToChild()
+}
+
+func (n *queryNavigator) MoveToFirst() bool {
+	n.g.visit()
+	return n.NodeNavigator.MoveToFirst()
+}
+
+func (n *queryNavigator) MoveToNext() bool {
+	n.g.visit()
+	return n.NodeNavigator.MoveToNext()
+}
+
+func (n *queryNavigator) MoveToPrevious() bool {
+	n.g.visit()
+	return n.NodeNavigator.MoveToPrevious()
+}
+
+func (n *queryNavigator) MoveTo(other xpath.NodeNavigator) bool {
+	if o, ok := other.(*queryNavigator); ok {
+		other = o.NodeNavigator
+	}
+	return n.NodeNavigator.MoveTo(other)
+}
+
+// node returns the node the navigator is on, with attributes returned as
+// detached elements like htmlquery does.
+func (n *queryNavigator) node() *html.Node {
+	if n.NodeType() != xpath.AttributeNode {
+		return n.Current()
+	}
+	text := &html.Node{Type: html.TextNode, Data: n.Value()}
+	return &html.Node{
+		Type:       html.ElementNode,
+		Data:       n.NodeNavigator.LocalName(),
+		FirstChild: text,
+		LastChild:  text,
+	}
+}
+
+// customQuery reports whether XPath selectors are evaluated by query rather
+// than by htmlquery.
+func (c config) customQuery() bool {
+	return c.guardsSelectors() || len(c.namespaces) > 0 || len(c.vars) > 0 || len(c.params) > 0
+}
+
+// compile returns the compiled selector of tag, with the variables and
+// namespaces of d.
+func (d *decodeState) compile(tag xpathTag) (*xpath.Expr, error) {
+	// Selectors with variables are never compiled in advance
+	if tag.expr != nil && len(d.namespaces) == 0 {
+		return tag.expr, nil
+	}
+	if d.exprs != nil {
+		if expr, ok := d.exprs.Load(tag.tag); ok {
+			return expr.(*xpath.Expr), nil
+		}
+	}
+
+	selector := tag.tag
+	if hasVars(selector) {
+		// A nil map would only check the selector, binding every variable
+		vars := d.vars
+		if vars == nil {
+			vars = map[string]string{}
+		}
+		var err error
+		if selector, err = bindVars(selector, vars); err != nil {
+			return nil, err
+		}
+	}
+	var expr *xpath.Expr
+	var err error
+	if len(d.namespaces) > 0 {
+		expr, err = xpath.CompileWithNS(selector, d.namespaces)
+	} else {
+		expr, err = xpath.Compile(selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.exprs != nil {
+		d.exprs.Store(tag.tag, expr)
+	}
+	return expr, nil
+}
+
+// query is findByTag, or findOneByTag if one is set, for an XPath selector
+// evaluated under the timeout and budget of d, with its namespaces and
+// variables.
+func (d *decodeState) query(doc *Document, tag xpathTag, one bool) (sel *Document, err error) {
+	expr, err := d.compile(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var g *queryGuard
+	if d.guardsSelectors() {
+		g = &queryGuard{budget: d.selectorBudget}
+		if d.selectorTimeout > 0 {
+			g.deadline = time.Now().Add(d.selectorTimeout)
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				if r != g {
+					panic(r)
+				}
+				sel, err = nil, g.err
+			}
+		}()
+	}
+
+	query := func(n *html.Node) []*html.Node {
+		var nodes []*html.Node
+		it := expr.Select(&queryNavigator{htmlquery.CreateXPathNavigator(n), g, len(d.namespaces) > 0})
+		for it.MoveNext() {
+			nav := it.Current().(*queryNavigator)
+			found := nav.node()
+			// Drop the duplicates htmlquery.QuerySelectorAll drops
+			if len(nodes) > 0 && (nodes[0] == found || nav.NodeType() == xpath.AttributeNode &&
+				found.Data == nodes[0].Data && nav.Value() == htmlquery.InnerText(nodes[0])) {
+				continue
+			}
+			nodes = append(nodes, found)
+			if one {
+				break
+			}
+		}
+		return nodes
+	}
+	if one {
+		return doc.findFirst(func(n *html.Node) *html.Node {
+			if nodes := query(n); len(nodes) > 0 {
+				return nodes[0]
+			}
+			return nil
+		}), nil
+	}
+	return doc.findAll(query), nil
+}
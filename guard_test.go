@@ -0,0 +1,41 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectorGuard(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<ul>` + strings.Repeat(`<li class="x"><a href="/a">a</a><a href="/b">b</a></li>`, 2000) + `</ul>`)
+
+	type links struct {
+		Links []string `xpath:"//li/a/@href"`
+		Texts []string `xpath:"//li/a"`
+		First string   `xpath:"(//li)[1]/a[2]"`
+		Class string   `xpath:"//li[2]/@class"`
+	}
+	var a, want links
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithSelectorBudget(100000), WithSelectorTimeout(time.Minute)))
+	asrt.NoError(Unmarshal(page, &want))
+	asrt.Equal(want, a)
+	asrt.Len(a.Texts, 4000)
+	asrt.Equal("b", a.First)
+	asrt.Equal("x", a.Class)
+
+	var b struct {
+		Middle []string `xpath:"//li[count(preceding::li) = count(following::li)]"`
+	}
+	err := UnmarshalWithOptions(page, &b, WithSelectorBudget(100000))
+	asrt.True(errors.Is(err, ErrSelectorTimeout))
+	asrt.Contains(err.Error(), ".Middle")
+	asrt.Contains(err.Error(), "budget of 100000 node visits exhausted")
+
+	err = UnmarshalWithOptions(page, &b, WithSelectorTimeout(time.Millisecond))
+	asrt.True(errors.Is(err, ErrSelectorTimeout))
+	asrt.Contains(err.Error(), "deadline exceeded")
+}
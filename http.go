@@ -0,0 +1,106 @@
+package goxtag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// StatusError is returned by Get for responses with a non-2xx status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.URL, e.Status)
+}
+
+// UnmarshalResponse decodes the body of resp into v and closes it. The
+// Content-Type header of the response and the URL it was fetched from are
+// used as if given with WithContentType and WithBaseURL, before opts. The
+// status code is not checked. Decoding stops once the context of the request
+// is done, see UnmarshalContext.
+func UnmarshalResponse(resp *http.Response, v interface{}, opts ...Option) error {
+	d, doc, err := parseResponse(resp, opts)
+	if err != nil {
+		return err
+	}
+	return d.unmarshal(doc, v)
+}
+
+// parseResponse parses the body of resp, closing it, and returns the state
+// to decode it with as UnmarshalResponse describes.
+func parseResponse(resp *http.Response, opts []Option) (*decodeState, *Document, error) {
+	defer resp.Body.Close()
+
+	ctx := context.Background()
+	respOpts := []Option{WithContentType(resp.Header.Get("Content-Type"))}
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+		respOpts = append(respOpts, WithBaseURL(resp.Request.URL))
+	}
+	c := newConfig(append(respOpts, opts...))
+
+	root, src, err := c.parse(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &decodeState{ctx: ctx, config: c, source: src, root: root}, NewDocumentWithNode(root), nil
+}
+
+// Bind returns a response handler decoding every response into a new T with
+// UnmarshalResponse and passing it to fn, so that crawlers calling handlers
+// with *http.Response values can use tagged structs directly. fn isn't
+// called for responses that fail to decode, their error is returned instead.
+func Bind[T any](fn func(resp *http.Response, v T) error, opts ...Option) func(resp *http.Response) error {
+	return func(resp *http.Response) error {
+		var v T
+		if err := UnmarshalResponse(resp, &v, opts...); err != nil {
+			return err
+		}
+		return fn(resp, v)
+	}
+}
+
+// Get fetches rawURL with http.DefaultClient and decodes the page into v with
+// UnmarshalResponse. Responses with a non-2xx status are not decoded and
+// fail with a *StatusError.
+func Get(ctx context.Context, rawURL string, v interface{}, opts ...Option) error {
+	resp, err := fetch(ctx, defaultFetch, rawURL)
+	if err != nil {
+		return err
+	}
+	return UnmarshalResponse(resp, v, opts...)
+}
+
+// FetchFunc fetches the page at url, e.g. with a client setting headers or
+// rate limiting requests. See Paginator.
+type FetchFunc func(ctx context.Context, url string) (*http.Response, error)
+
+func defaultFetch(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// fetch fetches rawURL with f and turns non-2xx responses into a StatusError.
+func fetch(ctx context.Context, f FetchFunc, rawURL string) (*http.Response, error) {
+	resp, err := f(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, &StatusError{
+			URL:        rawURL,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
+	}
+	return resp, nil
+}
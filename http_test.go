@@ -0,0 +1,106 @@
+package goxtag
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := encode(t, charmap.Windows1251, `<h1>Привет</h1><a href="/next?p=2">next</a>`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list":
+			w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+			_, _ = w.Write(page)
+		case "/old":
+			http.Redirect(w, r, "/list", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var a struct {
+		Title string `xpath:"//h1"`
+		Next  string `xpath:"//a/@href" xpath_mode:"url"`
+	}
+	asrt.NoError(Get(context.Background(), srv.URL+"/old", &a))
+	asrt.Equal("Привет", a.Title)
+	asrt.Equal(srv.URL+"/next?p=2", a.Next)
+
+	err := Get(context.Background(), srv.URL+"/missing", &a)
+	var se *StatusError
+	asrt.True(errors.As(err, &se))
+	asrt.Equal(http.StatusNotFound, se.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	asrt.True(errors.Is(Get(ctx, srv.URL+"/list", &a), context.Canceled))
+}
+
+func TestUnmarshalResponse(t *testing.T) {
+	asrt := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		_, _ = w.Write(encode(t, charmap.Windows1251, `<h1>Привет</h1>`))
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	asrt.NoError(err)
+
+	var a struct {
+		Title string `xpath:"//h1"`
+	}
+	asrt.NoError(UnmarshalResponse(resp, &a))
+	asrt.Equal("Привет", a.Title)
+
+	// The body is closed
+	_, err = resp.Body.Read(make([]byte, 1))
+	asrt.Error(err)
+}
+
+func TestBind(t *testing.T) {
+	asrt := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<h1>` + r.URL.Path + `</h1>`))
+	}))
+	defer srv.Close()
+
+	type page struct {
+		Title string `xpath:"//h1"`
+		Sub   string `xpath:"//h2"`
+	}
+	var titles []string
+	handle := Bind(func(resp *http.Response, p page) error {
+		titles = append(titles, p.Title)
+		return nil
+	}, WithCollectErrors())
+
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := http.Get(srv.URL + path)
+		asrt.NoError(err)
+		asrt.True(errors.Is(handle(resp), ErrNodeNotFound))
+	}
+	asrt.Empty(titles)
+
+	handle = Bind(func(resp *http.Response, p *struct {
+		Title string `xpath:"//h1"`
+	}) error {
+		titles = append(titles, p.Title)
+		return nil
+	})
+	resp, err := http.Get(srv.URL + "/c")
+	asrt.NoError(err)
+	asrt.NoError(handle(resp))
+	asrt.Equal([]string{"/c"}, titles)
+}
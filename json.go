@@ -0,0 +1,34 @@
+package goxtag
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// unmarshalJSON decodes the value of the matched nodes, e.g. the text of a
+// <script> or a data attribute, with encoding/json as set by xpath_json.
+// Empty values are an error in required fields and leave the others as they
+// are.
+func (d *decodeState) unmarshalJSON(doc *Document, v reflect.Value, tag xpathTag) error {
+	str, err := d.value(doc, v, tag)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(str) == "" && !tag.required {
+		d.note(tag.tag, "empty JSON value left as zero")
+		d.unset = true
+		return nil
+	}
+	if err := json.Unmarshal([]byte(str), v.Addr().Interface()); err != nil {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTypeConversion,
+			XPath:  tag.tag,
+			Err:    err,
+			Val:    str,
+			Pos:    d.position(doc),
+		}
+	}
+	return nil
+}
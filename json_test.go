@@ -0,0 +1,50 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testJSON = `<div id="app" data-props='{"id": 7, "tags": ["a", "b"]}'></div>
+<script id="state">window.__STATE__ = {"user": {"name": "Ann", "age": 31}};</script>
+<script id="data" type="application/json">
+	{"user": {"name": "Bob", "age": 42}, "items": [1, 2, 3]}
+</script>
+<script id="empty" type="application/json"></script>`
+
+func TestJSON(t *testing.T) {
+	asrt := assert.New(t)
+
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	type props struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+
+	var a struct {
+		Props   props                  `xpath:"//div[@id='app']/@data-props" xpath_json:"true"`
+		Data    map[string]interface{} `xpath:"//script[@id='data']" xpath_json:"true"`
+		Items   []int                  `xpath:"//script[@id='data']" xpath_json:"true" xpath_regex:"\"items\":\\s*(\\[[^\\]]*\\])"`
+		State   *user                  `xpath:"//script[@id='state']" xpath_json:"true" xpath_regex:"\"user\":\\s*(\\{[^}]*\\})"`
+		Empty   *user                  `xpath:"//script[@id='empty']" xpath_json:"true" xpath_required:"false"`
+		Literal string                 `xpath:"//script[@id='state']" xpath_json:"true" xpath_regex:"\"name\":\\s*(\"[^\"]*\")"`
+	}
+	asrt.NoError(Unmarshal([]byte(testJSON), &a))
+	asrt.Equal(props{ID: 7, Tags: []string{"a", "b"}}, a.Props)
+	asrt.Equal("Bob", a.Data["user"].(map[string]interface{})["name"])
+	asrt.Equal([]int{1, 2, 3}, a.Items)
+	asrt.Equal(&user{Name: "Ann", Age: 31}, a.State)
+	asrt.Nil(a.Empty)
+	asrt.Equal("Ann", a.Literal)
+
+	var b struct {
+		State user `xpath:"//script[@id='state']" xpath_json:"true"`
+	}
+	err := Unmarshal([]byte(testJSON), &b)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Contains(err.Error(), "invalid character")
+}
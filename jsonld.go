@@ -0,0 +1,141 @@
+package goxtag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"reflect"
+	"strings"
+)
+
+const (
+	jsonLDTagName = "jsonld"
+	jsonLDMIME    = "application/ld+json"
+
+	// jsonLDAnyType selects the JSON-LD items whatever their @type
+	jsonLDAnyType = "*"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// jsonLDItem holds the keys of a JSON-LD object used to select it.
+type jsonLDItem struct {
+	Type  json.RawMessage   `json:"@type"`
+	Graph []json.RawMessage `json:"@graph"`
+}
+
+// findJSONLD collects the JSON-LD items of the given @type from the
+// <script type="application/ld+json"> blocks in doc. Top-level arrays and
+// @graph lists are searched, nested objects are not. Blocks that are not
+// valid JSON are skipped.
+func (d *decodeState) findJSONLD(doc *Document, tag xpathTag) []json.RawMessage {
+	var items []json.RawMessage
+	for _, script := range jsonLDScripts(doc) {
+		var block json.RawMessage
+		if err := json.Unmarshal(script, &block); err != nil {
+			d.note(tag.tag, fmt.Sprintf("invalid JSON-LD block: %v", err))
+			continue
+		}
+		items = appendJSONLDItems(items, block, tag.jsonLD)
+	}
+	return items
+}
+
+// jsonLDScripts returns the contents of the JSON-LD scripts in doc.
+func jsonLDScripts(doc *Document) [][]byte {
+	var scripts [][]byte
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Script {
+			typ, _ := getAttributeValue("type", n)
+			if strings.EqualFold(strings.TrimSpace(typ), jsonLDMIME) {
+				var buf bytes.Buffer
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					buf.WriteString(c.Data)
+				}
+				scripts = append(scripts, buf.Bytes())
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range doc.Nodes {
+		walk(n)
+	}
+	return scripts
+}
+
+func appendJSONLDItems(items []json.RawMessage, block json.RawMessage, typ string) []json.RawMessage {
+	block = bytes.TrimSpace(block)
+	if len(block) == 0 {
+		return items
+	}
+	switch block[0] {
+	case '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(block, &list); err == nil {
+			for _, item := range list {
+				items = appendJSONLDItems(items, item, typ)
+			}
+		}
+	case '{':
+		var item jsonLDItem
+		if err := json.Unmarshal(block, &item); err != nil {
+			return items
+		}
+		if hasJSONLDType(item.Type, typ) {
+			items = append(items, block)
+		}
+		for _, g := range item.Graph {
+			items = appendJSONLDItems(items, g, typ)
+		}
+	}
+	return items
+}
+
+// hasJSONLDType reports whether the @type of an item, a string or a list of
+// them, matches typ. Types are matched as written, or by their last segment,
+// so "Product" matches "https://schema.org/Product" and "schema:Product".
+func hasJSONLDType(raw json.RawMessage, typ string) bool {
+	if typ == jsonLDAnyType {
+		return true
+	}
+	var types []string
+	if err := json.Unmarshal(raw, &types); err != nil {
+		var t string
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return false
+		}
+		types = []string{t}
+	}
+	for _, t := range types {
+		if t == typ || strings.HasSuffix(t, "/"+typ) || strings.HasSuffix(t, ":"+typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalJSONLD decodes the JSON-LD items into v with encoding/json: slices
+// other than json.RawMessage get every item, other types the first one. It
+// reports whether an item was found.
+func (d *decodeState) unmarshalJSONLD(doc *Document, v reflect.Value, tag xpathTag) (bool, error) {
+	items := d.findJSONLD(doc, tag)
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	data := items[0]
+	if v.Kind() == reflect.Slice && v.Type() != rawMessageType {
+		var err error
+		data, err = json.Marshal(items)
+		if err != nil {
+			return true, err
+		}
+	}
+	return true, json.Unmarshal(data, v.Addr().Interface())
+}
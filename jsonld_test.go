@@ -0,0 +1,67 @@
+package goxtag
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testJSONLD = `<html><head>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "Product", "name": "Widget",
+ "offers": {"@type": "Offer", "price": "9.99", "priceCurrency": "USD"}}
+</script>
+<script type="application/ld+json">{broken</script>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+	{"@type": "BreadcrumbList", "itemListElement": []},
+	{"@type": ["Thing", "schema:Product"], "name": "Gadget"}
+]}
+</script>
+<script type="text/javascript">var x = {"@type": "Product"};</script>
+</head><body></body></html>`
+
+func TestJSONLD(t *testing.T) {
+	asrt := assert.New(t)
+
+	type product struct {
+		Name   string `json:"name"`
+		Offers struct {
+			Price string `json:"price"`
+		} `json:"offers"`
+	}
+
+	var a struct {
+		First    product                  `jsonld:"Product"`
+		Products []product                `jsonld:"Product"`
+		Raw      json.RawMessage          `jsonld:"BreadcrumbList"`
+		Any      []map[string]interface{} `jsonld:"*"`
+		Missing  *product                 `jsonld:"Recipe" xpath_required:"false"`
+		Ptr      *product                 `jsonld:"Thing"`
+	}
+	asrt.NoError(Unmarshal([]byte(testJSONLD), &a))
+	asrt.Equal("Widget", a.First.Name)
+	asrt.Equal("9.99", a.First.Offers.Price)
+	asrt.Len(a.Products, 2)
+	asrt.Equal("Gadget", a.Products[1].Name)
+	asrt.JSONEq(`{"@type": "BreadcrumbList", "itemListElement": []}`, string(a.Raw))
+	asrt.Len(a.Any, 4)
+	asrt.Nil(a.Missing)
+	asrt.Equal("Gadget", a.Ptr.Name)
+
+	var b struct {
+		Recipe struct{} `jsonld:"Recipe"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(testJSONLD), &b), ErrNodeNotFound))
+
+	var c struct {
+		Name int `jsonld:"Product"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(testJSONLD), &c), ErrTypeConversion))
+
+	var d struct {
+		Product product `jsonld:"Product" xpath:"//script"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(testJSONLD), &d), ErrInvalidTag))
+}
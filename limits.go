@@ -0,0 +1,109 @@
+package goxtag
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+)
+
+// LimitError is returned when a document exceeds one of the limits set with
+// WithMaxInputBytes, WithMaxDepth or WithMaxNodes. It matches
+// ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	// Limit is "input bytes", "depth" or "nodes"
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("document exceeds the %s limit of %d", e.Limit, e.Max)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// WithMaxInputBytes rejects documents longer than n bytes, as read before
+// they are transcoded to UTF-8, without reading more than n+1 bytes of them.
+// It protects services decoding untrusted pages from memory exhaustion.
+func WithMaxInputBytes(n int64) Option {
+	return func(c *config) {
+		c.maxInputBytes = n
+	}
+}
+
+// WithMaxDepth rejects documents whose tree is deeper than n nodes, the
+// document node excluded.
+func WithMaxDepth(n int) Option {
+	return func(c *config) {
+		c.maxDepth = n
+	}
+}
+
+// WithMaxNodes rejects documents of more than n nodes (elements, text,
+// comments…), the document node excluded.
+func WithMaxNodes(n int) Option {
+	return func(c *config) {
+		c.maxNodes = n
+	}
+}
+
+// limitReader returns r failing with a LimitError once more than
+// maxInputBytes are read, if that limit is set.
+func (c config) limitReader(r io.Reader) io.Reader {
+	if c.maxInputBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, n: c.maxInputBytes, max: c.maxInputBytes}
+}
+
+type limitedReader struct {
+	r io.Reader
+	// n is the number of bytes left
+	n   int64
+	max int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, &LimitError{Limit: "input bytes", Max: l.max}
+	}
+	// Read one byte more than allowed to tell a document of exactly max
+	// bytes from a longer one
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n - 1, &LimitError{Limit: "input bytes", Max: l.max}
+	}
+	return n, err
+}
+
+// checkLimits checks the depth and the number of nodes of the tree of root
+// against the limits of c.
+func (c config) checkLimits(root *html.Node) error {
+	if c.maxDepth <= 0 && c.maxNodes <= 0 {
+		return nil
+	}
+
+	nodes := 0
+	var walk func(n *html.Node, depth int) error
+	walk = func(n *html.Node, depth int) error {
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			nodes++
+			if c.maxNodes > 0 && nodes > c.maxNodes {
+				return &LimitError{Limit: "nodes", Max: int64(c.maxNodes)}
+			}
+			if c.maxDepth > 0 && depth+1 > c.maxDepth {
+				return &LimitError{Limit: "depth", Max: int64(c.maxDepth)}
+			}
+			if err := walk(ch, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root, 0)
+}
@@ -0,0 +1,53 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestResourceLimits(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<html><head></head><body><div><p><b>deep</b></p></div></body></html>`
+	var a struct {
+		Text string `xpath:"//b"`
+	}
+
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &a, WithMaxInputBytes(int64(len(page)))))
+	asrt.Equal("deep", a.Text)
+
+	err := UnmarshalWithOptions([]byte(page), &a, WithMaxInputBytes(int64(len(page)-1)))
+	asrt.True(errors.Is(err, ErrLimitExceeded))
+	asrt.Equal(&LimitError{Limit: "input bytes", Max: int64(len(page) - 1)}, err)
+
+	// html, body, div, p, b and the text
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &a, WithMaxDepth(6)))
+	err = NewDecoder(strings.NewReader(page), WithMaxDepth(5)).Decode(&a)
+	asrt.True(errors.Is(err, ErrLimitExceeded))
+	asrt.EqualError(err, "document exceeds the depth limit of 5")
+
+	// the same plus head
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &a, WithMaxNodes(7)))
+	err = UnmarshalWithOptions([]byte(page), &a, WithMaxNodes(6))
+	asrt.Equal(&LimitError{Limit: "nodes", Max: 6}, err)
+}
+
+func TestStreamDecoderInputLimit(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<ul><li>a</li><li>b</li>` + strings.Repeat(`<li>x</li>`, 1000) + `</ul>`
+	s, err := NewStreamDecoder(strings.NewReader(page), "li", WithMaxInputBytes(2048))
+	asrt.NoError(err)
+
+	var rec struct {
+		Text string `xpath:"."`
+	}
+	for err == nil {
+		err = s.DecodeNext(&rec)
+	}
+	asrt.NotEqual(io.EOF, err)
+	asrt.True(errors.Is(err, ErrLimitExceeded))
+}
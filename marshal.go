@@ -0,0 +1,524 @@
+package goxtag
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"golang.org/x/net/html"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshaler is the counterpart of Unmarshaler: MarshalHTML returns the nodes
+// to put into the element the field selector points at.
+type Marshaler interface {
+	MarshalHTML() ([]*html.Node, error)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	indexPredRegEx    = regexp.MustCompile(`^\d+$`)
+	attrPredRegEx     = regexp.MustCompile(`^@([\w:.-]+)\s*(?:=\s*(?:'([^']*)'|"([^"]*)"))?$`)
+	containsPredRegEx = regexp.MustCompile(`^contains\(\s*@([\w:.-]+)\s*,\s*(?:'([^']*)'|"([^"]*)")\s*\)$`)
+	classPredRegEx    = regexp.MustCompile(`^contains\(\s*concat\(\s*' '\s*,\s*normalize-space\(\s*@([\w:.-]+)\s*\)\s*,\s*' '\s*\)\s*,\s*' ([^' ]+) '\s*\)$`)
+	stepNameRegEx     = regexp.MustCompile(`^[A-Za-z][\w:.-]*$`)
+)
+
+// Marshal renders v, a struct or a pointer to one, into an HTML fragment that
+// Unmarshal decodes back into an equal value. Every selector is turned into
+// the elements it matches, so only simple paths are supported: element names
+// separated by "/" or "//", optionally ending with "@attr" or "text()", with
+// position, "@attr='value'" and "contains(@attr, 'value')" predicates.
+// Fields whose selector can't be turned into elements make Marshal fail.
+func Marshal(v interface{}) ([]byte, error) {
+	return MarshalWithOptions(v)
+}
+
+// MarshalWithOptions is like Marshal but reads the tags as configured by opts.
+func MarshalWithOptions(v interface{}, opts ...Option) ([]byte, error) {
+	e := &encodeState{
+		decodeState: decodeState{config: newConfig(opts)},
+		root:        &html.Node{Type: html.DocumentNode},
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot marshal nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %s: only structs can be marshaled", rv.Type())
+	}
+
+	if err := e.marshalStruct(e.root, rv); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for n := e.root.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeState holds the state of a single marshaling run.
+type encodeState struct {
+	// decodeState reads the struct tags the same way Unmarshal does
+	decodeState
+	// root is the node absolute selectors start from
+	root *html.Node
+}
+
+// marshalStep is a location step of a selector turned into elements.
+type marshalStep struct {
+	// name is an element name, "@attr" or "text()"
+	name  string
+	attrs []html.Attribute
+	// index is the 1-based position predicate of the step, 0 if there is none
+	index int
+}
+
+func (s marshalStep) isAttr() bool {
+	return strings.HasPrefix(s.name, "@")
+}
+
+func (s marshalStep) isText() bool {
+	return s.name == "text()"
+}
+
+// parseMarshalPath splits a selector into the steps Marshal creates elements
+// for and reports whether it starts from the root.
+func parseMarshalPath(sel string) (bool, []marshalStep, error) {
+	sel = strings.TrimSpace(sel)
+
+	index := 0
+	if strings.HasPrefix(sel, "(") {
+		end := strings.LastIndex(sel, ")")
+		pred := strings.TrimSpace(sel[end+1:])
+		if end < 0 || !strings.HasPrefix(pred, "[") || !strings.HasSuffix(pred, "]") ||
+			!indexPredRegEx.MatchString(pred[1:len(pred)-1]) {
+			return false, nil, fmt.Errorf("unsupported selector %q", sel)
+		}
+		index, _ = strconv.Atoi(pred[1 : len(pred)-1])
+		sel = sel[1:end]
+	}
+
+	abs := strings.HasPrefix(sel, "/")
+
+	var steps []marshalStep
+	for _, part := range splitSteps(sel) {
+		if part == "" || part == "." {
+			continue
+		}
+		step, err := parseMarshalStep(part)
+		if err != nil {
+			return false, nil, err
+		}
+		steps = append(steps, step)
+	}
+
+	if index > 0 {
+		last := len(steps) - 1
+		if last < 0 || steps[last].isAttr() || steps[last].isText() {
+			return false, nil, fmt.Errorf("unsupported selector %q", sel)
+		}
+		steps[last].index = index
+	}
+	return abs, steps, nil
+}
+
+// splitSteps splits a selector on the slashes outside of predicates.
+func splitSteps(sel string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(sel); i++ {
+		c := sel[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '/' && depth == 0:
+			parts = append(parts, strings.TrimSpace(sel[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(sel[start:]))
+}
+
+func parseMarshalStep(part string) (marshalStep, error) {
+	var step marshalStep
+
+	name := part
+	if i := strings.Index(part, "["); i >= 0 {
+		name = part[:i]
+		preds := part[i:]
+		for preds != "" {
+			end := predicateEnd(preds)
+			if !strings.HasPrefix(preds, "[") || end < 0 {
+				return step, fmt.Errorf("unsupported step %q", part)
+			}
+			if err := step.addPredicate(strings.TrimSpace(preds[1:end])); err != nil {
+				return step, err
+			}
+			preds = strings.TrimSpace(preds[end+1:])
+		}
+	}
+
+	switch {
+	case strings.Contains(name, "::"):
+		return step, fmt.Errorf("unsupported axis in step %q", part)
+	case name == "text()":
+	case strings.HasPrefix(name, "@") && stepNameRegEx.MatchString(name[1:]):
+	case stepNameRegEx.MatchString(name):
+	default:
+		return step, fmt.Errorf("unsupported step %q", part)
+	}
+	if (name == "text()" || strings.HasPrefix(name, "@")) && (len(step.attrs) > 0 || step.index > 0) {
+		return step, fmt.Errorf("unsupported step %q", part)
+	}
+	step.name = name
+	return step, nil
+}
+
+// predicateEnd returns the index of the bracket closing the predicate s starts
+// with, or -1.
+func predicateEnd(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (s *marshalStep) addPredicate(pred string) error {
+	if indexPredRegEx.MatchString(pred) {
+		s.index, _ = strconv.Atoi(pred)
+		return nil
+	}
+	if m := attrPredRegEx.FindStringSubmatch(pred); m != nil {
+		s.setAttr(m[1], m[2]+m[3], false)
+		return nil
+	}
+	if m := containsPredRegEx.FindStringSubmatch(pred); m != nil {
+		s.setAttr(m[1], m[2]+m[3], true)
+		return nil
+	}
+	if m := classPredRegEx.FindStringSubmatch(pred); m != nil {
+		s.setAttr(m[1], m[2], true)
+		return nil
+	}
+	return fmt.Errorf("unsupported predicate [%s]", pred)
+}
+
+// setAttr sets an attribute the element of the step must have. With token
+// set, the value is added to the space separated tokens of the attribute.
+func (s *marshalStep) setAttr(key, val string, token bool) {
+	for i := range s.attrs {
+		if s.attrs[i].Key == key {
+			if token && s.attrs[i].Val != "" {
+				s.attrs[i].Val += " " + val
+			} else {
+				s.attrs[i].Val = val
+			}
+			return
+		}
+	}
+	s.attrs = append(s.attrs, html.Attribute{Key: key, Val: val})
+}
+
+// matches reports whether n is an element of the step.
+func (s marshalStep) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Data != s.name {
+		return false
+	}
+	for _, attr := range s.attrs {
+		if val, ok := getAttributeValue(attr.Key, n); !ok || val != attr.Val {
+			return false
+		}
+	}
+	return true
+}
+
+// newElement appends a new element of the step to parent.
+func (s marshalStep) newElement(parent *html.Node) *html.Node {
+	n := &html.Node{
+		Type: html.ElementNode,
+		Data: s.name,
+		Attr: append([]html.Attribute(nil), s.attrs...),
+	}
+	parent.AppendChild(n)
+	return n
+}
+
+// element returns the child of parent the step points at, creating it (and
+// the siblings its position predicate needs) if there is none.
+func (s marshalStep) element(parent *html.Node) *html.Node {
+	index := s.index
+	if index == 0 {
+		index = 1
+	}
+	seen := 0
+	for n := parent.FirstChild; n != nil; n = n.NextSibling {
+		if s.matches(n) {
+			seen++
+			if seen == index {
+				return n
+			}
+		}
+	}
+	var n *html.Node
+	for ; seen < index; seen++ {
+		n = s.newElement(parent)
+	}
+	return n
+}
+
+func (e *encodeState) marshalStruct(parent *html.Node, v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type == reportType {
+			continue
+		}
+
+		tag, err := e.newXpathTag(field)
+		if err != nil {
+			return e.fieldError(t, field.Name, tag, err)
+		}
+		if tag.tag == "" || tag.tag == ignoreTag {
+			continue
+		}
+		if err := e.marshalField(parent, v.Field(i), tag); err != nil {
+			return e.fieldError(t, field.Name, tag, err)
+		}
+	}
+	return nil
+}
+
+func (e *encodeState) fieldError(t reflect.Type, name string, tag xpathTag, err error) error {
+	return &marshalError{path: t.String() + "." + name, xpath: tag.tag, err: err}
+}
+
+// marshalError tells which field could not be marshaled.
+type marshalError struct {
+	path  string
+	xpath string
+	err   error
+}
+
+func (e *marshalError) Error() string {
+	return fmt.Sprintf("could not marshal '%s' tag: '%s': %v", e.path, e.xpath, e.err)
+}
+
+func (e *marshalError) Unwrap() error {
+	return e.err
+}
+
+func (e *encodeState) marshalField(parent *html.Node, v reflect.Value, tag xpathTag) error {
+	switch {
+	case tag.css:
+		return fmt.Errorf("css selectors can't be marshaled")
+	case tag.isMicrodata(), tag.jsonLD != "":
+		return fmt.Errorf("microdata and JSON-LD fields can't be marshaled")
+	case tag.table, tag.srcset, tag.json, tag.linkMap != "", tag.key != "", tag.regex != nil:
+		return fmt.Errorf("the options of the field can't be marshaled")
+	case v.Kind() == reflect.Chan:
+		return fmt.Errorf("channel fields can't be marshaled")
+	}
+
+	abs, steps, err := parseMarshalPath(tag.tag)
+	if err != nil {
+		return err
+	}
+	if abs {
+		parent = e.root
+	}
+
+	// The value goes into the element of the last element step, or into the
+	// attribute or text the selector ends with
+	last := len(steps)
+	var leaf *marshalStep
+	if last > 0 && (steps[last-1].isAttr() || steps[last-1].isText()) {
+		leaf = &steps[last-1]
+		last--
+	}
+	if last == 0 {
+		return fmt.Errorf("selector doesn't point at an element")
+	}
+	for _, step := range steps[:last-1] {
+		parent = step.element(parent)
+	}
+	repeat := steps[last-1]
+
+	if !tag.required && isZero(v) {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !e.isScalar(v) && !tag.joined {
+		for i := 0; i < v.Len(); i++ {
+			if err := e.marshalInto(repeat.newElement(parent), v.Index(i), leaf, tag); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return nil
+	}
+	return e.marshalInto(repeat.element(parent), v, leaf, tag)
+}
+
+// marshalInto puts v into n, into its attribute or its text as leaf says.
+func (e *encodeState) marshalInto(n *html.Node, v reflect.Value, leaf *marshalStep, tag xpathTag) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if leaf == nil && tag.attr == "" {
+		if m, ok := marshaler(v); ok {
+			nodes, err := m.MarshalHTML()
+			if err != nil {
+				return err
+			}
+			for _, node := range nodes {
+				n.AppendChild(node)
+			}
+			return nil
+		}
+		if v.Kind() == reflect.Struct && !e.isScalar(v) {
+			return e.marshalStruct(n, v)
+		}
+	}
+
+	str, err := marshalLiteral(v, tag)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case leaf != nil && leaf.isAttr():
+		setAttr(n, leaf.name[1:], str)
+	case leaf == nil && tag.attr != "":
+		setAttr(n, tag.attr, str)
+	default:
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: str})
+	}
+	return nil
+}
+
+func (e *encodeState) isScalar(v reflect.Value) bool {
+	t := v.Type()
+	return t == timeType || reflect.PtrTo(t).Implements(textMarshalerType) || t.Implements(textMarshalerType)
+}
+
+func marshaler(v reflect.Value) (Marshaler, bool) {
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(marshalerType) {
+		return v.Addr().Interface().(Marshaler), true
+	}
+	if v.Type().Implements(marshalerType) {
+		return v.Interface().(Marshaler), true
+	}
+	return nil, false
+}
+
+// marshalLiteral formats v the way unmarshalLiteral and the time and
+// encoding.TextUnmarshaler support parse it.
+func marshalLiteral(v reflect.Value, tag xpathTag) (string, error) {
+	t := v.Type()
+
+	switch t {
+	case timeType:
+		layout := tag.timeLayout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Interface().(time.Time).Format(layout), nil
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	}
+
+	if v.CanAddr() && reflect.PtrTo(t).Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if t.Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("%s values can't be marshaled", t)
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// isZero reports whether v is the zero value of its type.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,136 @@
+package goxtag
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"testing"
+	"time"
+)
+
+type marshalItem struct {
+	Name  string   `xpath:"./span[@class='name']"`
+	Price float64  `xpath:"./span[@class='price']"`
+	Tags  []string `xpath:"./ul/li"`
+	Link  string   `xpath:"./a/@href"`
+}
+
+type marshalPage struct {
+	Title     string        `xpath:"//title"`
+	Items     []marshalItem `xpath:"//div[@id='items']/div[contains(concat(' ',normalize-space(@class),' '),' item ')]"`
+	Count     int           `xpath:"//div[@id='items']" xpath_attr:"data-count"`
+	Published time.Time     `xpath:"//time,attr=datetime" xpath_time_layout:"2006-01-02"`
+	Second    string        `xpath:"//ol/li[2]"`
+	Note      *string       `xpath:"//p[@class='note']" xpath_required:"false"`
+	Missing   string        `xpath:"//p[@class='missing']" xpath_required:"false"`
+	Level     Level         `xpath:"//span[@class='level']"`
+	Ignored   string        `xpath:"-"`
+	Untagged  string
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	switch l {
+	case 1:
+		return []byte("low"), nil
+	case 2:
+		return []byte("high"), nil
+	}
+	return nil, fmt.Errorf("unknown level %d", l)
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	asrt := assert.New(t)
+
+	in := marshalPage{
+		Title: "Shop",
+		Items: []marshalItem{
+			{Name: "Apple", Price: 1.5, Tags: []string{"fruit", "red"}, Link: "/apple"},
+			{Name: "Bread", Price: 2, Tags: []string{"bakery"}, Link: "/bread"},
+		},
+		Count:     2,
+		Published: time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC),
+		Second:    "two",
+		Level:     2,
+		Ignored:   "ignored",
+		Untagged:  "untagged",
+	}
+
+	bs, err := Marshal(&in)
+	asrt.NoError(err)
+	asrt.Contains(string(bs), `<title>Shop</title>`)
+	asrt.Contains(string(bs), `<div id="items" data-count="2"><div class="item">`)
+	asrt.Contains(string(bs), `<ol><li></li><li>two</li></ol>`)
+	asrt.NotContains(string(bs), "ignored")
+	asrt.NotContains(string(bs), "missing")
+
+	var out marshalPage
+	asrt.NoError(Unmarshal(bs, &out))
+	in.Ignored, in.Untagged = "", ""
+	asrt.Equal(in, out)
+}
+
+type marshalBold string
+
+func (b marshalBold) MarshalHTML() ([]*html.Node, error) {
+	n := &html.Node{Type: html.ElementNode, Data: "b"}
+	n.AppendChild(&html.Node{Type: html.TextNode, Data: string(b)})
+	return []*html.Node{n}, nil
+}
+
+func TestMarshaler(t *testing.T) {
+	asrt := assert.New(t)
+
+	bs, err := Marshal(struct {
+		Bold marshalBold `xpath:"//p"`
+	}{"hi"})
+	asrt.NoError(err)
+	asrt.Equal(`<p><b>hi</b></p>`, string(bs))
+}
+
+func TestMarshalErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	_, err := Marshal("text")
+	asrt.Error(err)
+
+	_, err = Marshal((*marshalPage)(nil))
+	asrt.Error(err)
+
+	for _, v := range []interface{}{
+		struct {
+			A string `css:"p"`
+		}{},
+		struct {
+			A string `xpath:"//p/following-sibling::a"`
+		}{},
+		struct {
+			A string `xpath:"//*[@id='a']"`
+		}{},
+		struct {
+			A string `xpath:"//p[last()]"`
+		}{},
+		struct {
+			A string `xpath:"meta:description"`
+		}{},
+		struct {
+			A map[string]string `xpath:"//a"`
+		}{A: map[string]string{"a": "b"}},
+		struct {
+			A Level `xpath:"//p"`
+		}{A: 3},
+	} {
+		_, err := Marshal(v)
+		asrt.Error(err, "%#v", v)
+	}
+
+	_, err = Marshal(struct {
+		Items []struct {
+			A string `xpath:"./a[text()='x']"`
+		} `xpath:"//li"`
+	}{Items: make([]struct {
+		A string `xpath:"./a[text()='x']"`
+	}, 1)})
+	asrt.Error(err)
+	asrt.Contains(err.Error(), "element 0")
+	asrt.Contains(err.Error(), "unsupported predicate [text()='x']")
+}
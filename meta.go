@@ -0,0 +1,112 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+const (
+	openGraphPrefix = "og:"
+	twitterPrefix   = "twitter:"
+)
+
+// MetaTags holds the content of the <meta> tags of a document, keyed by
+// their lowercased name, property or itemprop. When a key is repeated (e.g.
+// several og:image), the first tag wins.
+//
+// A field of this type without a tag is filled from the selection of its
+// struct, so it can be added to any page struct as is.
+type MetaTags struct {
+	// OpenGraph holds the og:* tags without the prefix, e.g. "title" or
+	// "image:width"
+	OpenGraph map[string]string
+	// Twitter holds the twitter:* tags without the prefix, e.g. "card"
+	Twitter map[string]string
+	// Names holds the other tags, e.g. "description" or "keywords"
+	Names map[string]string
+}
+
+// MetaTags collects the <meta> tags found in the selection in one pass.
+func (doc *Document) MetaTags() MetaTags {
+	m := MetaTags{
+		OpenGraph: map[string]string{},
+		Twitter:   map[string]string{},
+		Names:     map[string]string{},
+	}
+	for _, n := range doc.Nodes {
+		m.collect(n)
+	}
+	return m
+}
+
+// UnmarshalHTML implements Unmarshaler.
+func (m *MetaTags) UnmarshalHTML(nodes []*html.Node) error {
+	*m = NewDocumentWithNodes(nodes).MetaTags()
+	return nil
+}
+
+func (m *MetaTags) collect(n *html.Node) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Meta {
+		m.add(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		m.collect(c)
+	}
+}
+
+func (m *MetaTags) add(n *html.Node) {
+	content, ok := getAttributeValue("content", n)
+	if !ok {
+		return
+	}
+
+	var key string
+	for _, attr := range []string{"property", "name", "itemprop"} {
+		if val, ok := getAttributeValue(attr, n); ok && strings.TrimSpace(val) != "" {
+			key = strings.ToLower(strings.TrimSpace(val))
+			break
+		}
+	}
+	if key == "" {
+		return
+	}
+
+	tags := m.Names
+	switch {
+	case strings.HasPrefix(key, openGraphPrefix):
+		tags, key = m.OpenGraph, strings.TrimPrefix(key, openGraphPrefix)
+	case strings.HasPrefix(key, twitterPrefix):
+		tags, key = m.Twitter, strings.TrimPrefix(key, twitterPrefix)
+	}
+	if _, ok := tags[key]; !ok {
+		tags[key] = strings.TrimSpace(content)
+	}
+}
+
+// lookup returns the first non-empty value of key among the OpenGraph,
+// Twitter and other tags.
+func (m MetaTags) lookup(key string) string {
+	for _, tags := range []map[string]string{m.OpenGraph, m.Twitter, m.Names} {
+		if val := tags[key]; val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// Title returns og:title, twitter:title or the title meta tag.
+func (m MetaTags) Title() string {
+	return m.lookup("title")
+}
+
+// Description returns og:description, twitter:description or the description
+// meta tag.
+func (m MetaTags) Description() string {
+	return m.lookup("description")
+}
+
+// Image returns og:image or twitter:image.
+func (m MetaTags) Image() string {
+	return m.lookup("image")
+}
@@ -0,0 +1,53 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testMetaPage = `<html><head>
+<meta charset="utf-8">
+<meta property="og:title" content="OG title">
+<meta property="og:image" content="https://example.com/1.png">
+<meta property="og:image" content="https://example.com/2.png">
+<meta property="og:image:width" content="800">
+<meta name="twitter:card" content="summary">
+<meta name="Twitter:Description" content=" Card description ">
+<meta name="Description" content="Plain description">
+<meta name="keywords" content="a, b">
+</head><body></body></html>`
+
+func TestMetaTags(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Meta  MetaTags
+		Title string `xpath:"meta:title" xpath_required:"false"`
+	}
+	asrt.NoError(Unmarshal([]byte(testMetaPage), &a))
+
+	m := a.Meta
+	asrt.Equal(map[string]string{
+		"title":       "OG title",
+		"image":       "https://example.com/1.png",
+		"image:width": "800",
+	}, m.OpenGraph)
+	asrt.Equal(map[string]string{
+		"card":        "summary",
+		"description": "Card description",
+	}, m.Twitter)
+	asrt.Equal(map[string]string{
+		"description": "Plain description",
+		"keywords":    "a, b",
+	}, m.Names)
+
+	asrt.Equal("OG title", m.Title())
+	asrt.Equal("Card description", m.Description())
+	asrt.Equal("https://example.com/1.png", m.Image())
+
+	var b struct {
+		Meta MetaTags `xpath:"//body"`
+	}
+	asrt.NoError(Unmarshal([]byte(testMetaPage), &b))
+	asrt.Empty(b.Meta.OpenGraph)
+}
@@ -0,0 +1,114 @@
+package goxtag
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+const (
+	itemPropTagName = "itemprop"
+	itemTypeTagName = "itemtype"
+)
+
+// pseudoSelector returns the pseudo selector shown in errors for the tags
+// that select nodes without a selector, such as itemprop, itemtype or jsonld.
+func pseudoSelector(key, val string) string {
+	return fmt.Sprintf("%s=%s", key, val)
+}
+
+func (tag *xpathTag) isMicrodata() bool {
+	return tag.itemProp != "" || tag.itemType != ""
+}
+
+// findMicrodata finds the schema.org microdata items of the itemtype tag or
+// the properties of the itemprop tag in doc.
+func findMicrodata(doc *Document, tag xpathTag) *Document {
+	var nodes []*html.Node
+	for _, n := range doc.Nodes {
+		if tag.itemType != "" {
+			nodes = appendItems(nodes, n, tag.itemType)
+		} else {
+			nodes = appendItemProps(nodes, n, tag.itemProp)
+		}
+	}
+	return NewDocumentWithNodes(nodes)
+}
+
+// appendItems appends n and its descendants that are items of itemType.
+// Types are matched as written, or by their last path segment when itemType
+// has no scheme, so "Product" matches "https://schema.org/Product".
+func appendItems(nodes []*html.Node, n *html.Node, itemType string) []*html.Node {
+	if n.Type == html.ElementNode && hasAttr(n, "itemscope") {
+		types, _ := getAttributeValue("itemtype", n)
+		for _, t := range strings.Fields(types) {
+			if t == itemType || !strings.Contains(itemType, "://") && strings.HasSuffix(t, "/"+itemType) {
+				nodes = append(nodes, n)
+				break
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = appendItems(nodes, c, itemType)
+	}
+	return nodes
+}
+
+// appendItemProps appends the descendants of the item n that are its
+// properties named name. The properties of nested items are not looked at.
+func appendItemProps(nodes []*html.Node, n *html.Node, name string) []*html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		props, _ := getAttributeValue("itemprop", c)
+		for _, p := range strings.Fields(props) {
+			if p == name {
+				nodes = append(nodes, c)
+				break
+			}
+		}
+		if !hasAttr(c, "itemscope") {
+			nodes = appendItemProps(nodes, c, name)
+		}
+	}
+	return nodes
+}
+
+func hasAttr(n *html.Node, name string) bool {
+	_, ok := getAttributeValue(name, n)
+	return ok
+}
+
+// itemPropVal reads the value of the first property node the way microdata
+// defines it: the content of <meta>, the URL of links and media, the
+// datetime of <time>, the value of <data> and <meter>, or the text.
+func itemPropVal(doc *Document) (string, error) {
+	if doc.IsEmpty() {
+		return "", nil
+	}
+	n := doc.Nodes[0]
+
+	var attr string
+	switch n.DataAtom {
+	case atom.Meta:
+		attr = "content"
+	case atom.A, atom.Area, atom.Link:
+		attr = "href"
+	case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
+		attr = "src"
+	case atom.Object:
+		attr = "data"
+	case atom.Data, atom.Meter:
+		attr = "value"
+	case atom.Time:
+		attr = "datetime"
+	}
+	if attr != "" {
+		if val, ok := getAttributeValue(attr, n); ok {
+			return val, nil
+		}
+	}
+	return NewDocumentWithNode(n).Text(), nil
+}
@@ -0,0 +1,74 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const testMicrodata = `<div itemscope itemtype="https://schema.org/Product">
+	<h1 itemprop="name">Widget</h1>
+	<img itemprop="image" src="/widget.png">
+	<div itemprop="brand" itemscope itemtype="https://schema.org/Brand">
+		<span itemprop="name">Acme</span>
+	</div>
+	<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+		<meta itemprop="priceCurrency" content="USD">
+		<span itemprop="price">9.99</span>
+		<link itemprop="availability" href="https://schema.org/InStock">In stock
+	</div>
+	<div itemprop="review" itemscope itemtype="https://schema.org/Review">
+		<span itemprop="author">Ann</span>
+		<time itemprop="datePublished" datetime="2021-01-02T00:00:00Z">Jan 2</time>
+	</div>
+	<div itemprop="review" itemscope itemtype="https://schema.org/Review">
+		<span itemprop="author">Bob</span>
+	</div>
+</div>
+<div itemscope itemtype="http://schema.org/Product"><span itemprop="name">Gadget</span></div>`
+
+func TestMicrodata(t *testing.T) {
+	asrt := assert.New(t)
+
+	type product struct {
+		Name  string `itemprop:"name"`
+		Image string `itemprop:"image" xpath_required:"false"`
+		Brand struct {
+			Name string `itemprop:"name"`
+		} `itemprop:"brand" xpath_required:"false"`
+		Offer struct {
+			Currency     string  `itemprop:"priceCurrency"`
+			Price        float64 `itemprop:"price"`
+			Availability string  `itemprop:"availability"`
+		} `itemprop:"offers" xpath_required:"false"`
+		Reviews []struct {
+			Author    string    `itemprop:"author"`
+			Published time.Time `itemprop:"datePublished" xpath_required:"false"`
+		} `itemprop:"review" xpath_required:"false"`
+	}
+
+	var a struct {
+		Products []product `itemtype:"Product"`
+		Full     []product `itemtype:"https://schema.org/Product"`
+	}
+	asrt.NoError(Unmarshal([]byte(testMicrodata), &a))
+	asrt.Len(a.Products, 2)
+	asrt.Len(a.Full, 1)
+
+	p := a.Products[0]
+	asrt.Equal("Widget", p.Name)
+	asrt.Equal("/widget.png", p.Image)
+	asrt.Equal("Acme", p.Brand.Name)
+	asrt.Equal("USD", p.Offer.Currency)
+	asrt.Equal(9.99, p.Offer.Price)
+	asrt.Equal("https://schema.org/InStock", p.Offer.Availability)
+	asrt.Len(p.Reviews, 2)
+	asrt.Equal("Ann", p.Reviews[0].Author)
+	asrt.Equal(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), p.Reviews[0].Published)
+	asrt.Equal("Gadget", a.Products[1].Name)
+
+	var b struct {
+		Name string `itemprop:"name" xpath:"//h1"`
+	}
+	asrt.Error(Unmarshal([]byte(testMicrodata), &b))
+}
@@ -0,0 +1,118 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// Remove detaches the nodes of the selection from the document, e.g. to strip
+// ads, navigation or scripts before decoding it. The selection keeps the
+// detached nodes and is returned.
+func (doc *Document) Remove() *Document {
+	for _, n := range doc.Nodes {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+	return doc
+}
+
+// ReplaceWith replaces every node of the selection with the nodes of other,
+// like goquery does: they are moved in place of the last node and cloned in
+// place of the others. It returns the selection of the replaced nodes.
+func (doc *Document) ReplaceWith(other *Document) *Document {
+	for i, n := range doc.Nodes {
+		if n.Parent == nil {
+			continue
+		}
+		last := i == len(doc.Nodes)-1
+		for _, r := range other.Nodes {
+			if last {
+				if r.Parent != nil {
+					r.Parent.RemoveChild(r)
+				}
+			} else {
+				r = cloneNode(r)
+			}
+			n.Parent.InsertBefore(r, n)
+		}
+		n.Parent.RemoveChild(n)
+	}
+	return doc
+}
+
+// ReplaceWithHtml replaces every node of the selection with the nodes of the
+// HTML fragment s, parsed in the context of the parent of the node, see
+// ReplaceWith.
+func (doc *Document) ReplaceWithHtml(s string) (*Document, error) {
+	for _, n := range doc.Nodes {
+		if n.Parent == nil {
+			continue
+		}
+		context := n.Parent
+		if context.Type != html.ElementNode {
+			context = nil
+		}
+		root, err := parseFragment(strings.NewReader(s), context)
+		if err != nil {
+			return nil, err
+		}
+		for c := root.FirstChild; c != nil; c = root.FirstChild {
+			root.RemoveChild(c)
+			n.Parent.InsertBefore(c, n)
+		}
+		n.Parent.RemoveChild(n)
+	}
+	return doc, nil
+}
+
+// SetAttr sets the named attribute of every element of the selection to val,
+// adding it where it is missing.
+func (doc *Document) SetAttr(name, val string) *Document {
+	for _, n := range doc.Nodes {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		found := false
+		for i := range n.Attr {
+			if n.Attr[i].Namespace == "" && n.Attr[i].Key == name {
+				n.Attr[i].Val = val
+				found = true
+				break
+			}
+		}
+		if !found {
+			n.Attr = append(n.Attr, html.Attribute{Key: name, Val: val})
+		}
+	}
+	return doc
+}
+
+// RemoveAttr removes the named attribute from every element of the selection.
+func (doc *Document) RemoveAttr(name string) *Document {
+	for _, n := range doc.Nodes {
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			if a.Namespace != "" || a.Key != name {
+				attrs = append(attrs, a)
+			}
+		}
+		n.Attr = attrs
+	}
+	return doc
+}
+
+// cloneNode returns a deep copy of n, detached from any tree.
+func cloneNode(n *html.Node) *html.Node {
+	c := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      append([]html.Attribute(nil), n.Attr...),
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.AppendChild(cloneNode(child))
+	}
+	return c
+}
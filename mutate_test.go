@@ -0,0 +1,41 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDocument_Mutations(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<div id="main"><script>track()</script><p class="ad">Buy</p>
+		<p>Text <a href="/a" onclick="x()">a</a> <a href="/b">b</a></p><p class="ad">Now</p></div>`)
+	body := doc.Find("//div[@id='main']")
+
+	removed := doc.Find("//script | //p[@class='ad']").Remove()
+	asrt.Equal(3, removed.Length())
+	asrt.Equal("Text a b", strings.TrimSpace(body.Text()))
+
+	doc.Find("//a").SetAttr("rel", "nofollow").SetAttr("href", "/x").RemoveAttr("onclick")
+	out, err := doc.Find("//p").OuterHtml()
+	asrt.NoError(err)
+	asrt.Equal(`<p>Text <a href="/x" rel="nofollow">a</a> <a href="/x" rel="nofollow">b</a></p>`, out)
+
+	doc.Find("//a").ReplaceWith(removed.Eq(1))
+	out, err = doc.Find("//div/p").Html()
+	asrt.NoError(err)
+	asrt.Equal(`<p>Text <p class="ad">Buy</p> <p class="ad">Buy</p></p>`, out)
+
+	_, err = doc.Find("//p[@class='ad']").ReplaceWithHtml(`<i>x</i><i>y</i>`)
+	asrt.NoError(err)
+	out, err = body.OutputHtml(false)
+	asrt.NoError(err)
+	asrt.Equal("\n\t\t<p>Text <i>x</i><i>y</i> <i>x</i><i>y</i></p>", out)
+
+	var a struct {
+		Items []string `xpath:"//i"`
+	}
+	asrt.NoError(UnmarshalSelection(doc, &a))
+	asrt.Equal([]string{"x", "y", "x", "y"}, a.Items)
+}
@@ -0,0 +1,106 @@
+package goxtag
+
+import (
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+	"strings"
+	"sync"
+)
+
+// xmlNamespace is the namespace bound to the xml prefix by definition
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// WithNamespaces registers namespace prefixes for selectors, mapping them to
+// namespace URIs: with WithNamespaces(map[string]string{"media":
+// "http://search.yahoo.com/mrss/"}), ".//media:thumbnail/@url" matches the
+// thumbnail elements of that namespace whatever the prefix the document
+// binds it to with xmlns. Unprefixed names still only match unprefixed
+// elements and attributes. Selectors using an unregistered prefix fail.
+func WithNamespaces(namespaces map[string]string) Option {
+	return func(c *config) {
+		for prefix, uri := range namespaces {
+			c.setNamespace(prefix, uri)
+		}
+	}
+}
+
+// RegisterNamespace registers a namespace prefix for the selectors of this
+// decoder, see WithNamespaces.
+func (d *Decoder) RegisterNamespace(prefix, uri string) {
+	d.config.setNamespace(prefix, uri)
+}
+
+// setNamespace maps prefix to uri, copying the map first since configs are
+// copied by value, and drops the selectors compiled with the previous map.
+func (c *config) setNamespace(prefix, uri string) {
+	m := make(map[string]string, len(c.namespaces)+1)
+	for k, v := range c.namespaces {
+		m[k] = v
+	}
+	m[prefix] = uri
+	c.namespaces = m
+	c.exprs = &sync.Map{}
+}
+
+// splitName splits a qualified name into its prefix and local name.
+func splitName(name string) (prefix, local string) {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func (n *queryNavigator) LocalName() string {
+	name := n.NodeNavigator.LocalName()
+	if !n.ns {
+		return name
+	}
+	_, local := splitName(name)
+	return local
+}
+
+func (n *queryNavigator) Prefix() string {
+	if !n.ns {
+		return ""
+	}
+	prefix, _ := splitName(n.NodeNavigator.LocalName())
+	return prefix
+}
+
+// NamespaceURL returns the namespace URI of the node the navigator is on,
+// found in the xmlns attributes of its element and of the ancestors of the
+// element.
+func (n *queryNavigator) NamespaceURL() string {
+	if !n.ns {
+		return ""
+	}
+	prefix := n.Prefix()
+	if prefix == "" && n.NodeType() == xpath.AttributeNode {
+		// Unprefixed attributes are in no namespace
+		return ""
+	}
+	return lookupNamespace(n.Current(), prefix)
+}
+
+// lookupNamespace returns the URI prefix is bound to in the scope of n, or
+// the default namespace for an empty prefix.
+func lookupNamespace(n *html.Node, prefix string) string {
+	if prefix == "xml" {
+		return xmlNamespace
+	}
+	key := "xmlns"
+	if prefix != "" {
+		key += ":" + prefix
+	}
+	for ; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		for _, attr := range n.Attr {
+			if attr.Key == key && attr.Namespace == "" {
+				return attr.Val
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package goxtag
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testMediaRSS = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:m="http://search.yahoo.com/mrss/" xmlns:yt="http://www.youtube.com/xml/schemas/2015">
+  <channel>
+    <item>
+      <title>First</title>
+      <m:thumbnail url="https://example.com/1.jpg"/>
+      <thumbnail url="https://example.com/plain.jpg"/>
+    </item>
+    <item xmlns:media="http://www.youtube.com/xml/schemas/2015">
+      <title>Second</title>
+      <m:thumbnail url="https://example.com/2.jpg"/>
+      <media:thumbnail url="https://example.com/other.jpg"/>
+    </item>
+  </channel>
+</rss>`
+
+type testMediaFeed struct {
+	Items []struct {
+		Title string `xpath:"./title"`
+		Thumb string `xpath:".//media:thumbnail/@url" xpath_required:"false"`
+	} `xpath:"//item"`
+}
+
+func TestWithNamespaces(t *testing.T) {
+	asrt := assert.New(t)
+
+	ns := map[string]string{"media": "http://search.yahoo.com/mrss/"}
+	var a testMediaFeed
+	asrt.NoError(UnmarshalXML([]byte(testMediaRSS), &a, WithNamespaces(ns)))
+	asrt.Len(a.Items, 2)
+	asrt.Equal("https://example.com/1.jpg", a.Items[0].Thumb)
+	asrt.Equal("https://example.com/2.jpg", a.Items[1].Thumb)
+
+	var b testMediaFeed
+	dec := NewDecoder(bytes.NewReader([]byte(testMediaRSS)), WithSyntax(SyntaxXML), WithSelectorBudget(10000))
+	dec.RegisterNamespace("media", "http://www.youtube.com/xml/schemas/2015")
+	asrt.NoError(dec.Decode(&b))
+	asrt.Empty(b.Items[0].Thumb)
+	asrt.Equal("https://example.com/other.jpg", b.Items[1].Thumb)
+
+	// The meta: shorthands give way to a registered meta prefix
+	var m struct {
+		Feed struct {
+			Generator string `xpath:"meta:generator"`
+		} `xpath:"/feed"`
+	}
+	feed := []byte(`<feed xmlns:m="https://example.com/meta"><m:generator>gen</m:generator></feed>`)
+	asrt.NoError(UnmarshalXML(feed, &m, WithNamespaces(map[string]string{"meta": "https://example.com/meta"})))
+	asrt.Equal("gen", m.Feed.Generator)
+
+	// Without namespaces, prefixed names match nothing
+	var c testMediaFeed
+	asrt.NoError(UnmarshalXML([]byte(testMediaRSS), &c))
+	asrt.Empty(c.Items[0].Thumb)
+
+	// Namespaces declared in HTML documents are resolved as well
+	var d struct {
+		Image string `xpath:"//og:image/@content"`
+		Title string `xpath:"//title"`
+	}
+	asrt.NoError(UnmarshalWithOptions([]byte(`<html xmlns:x="https://ogp.me/ns#"><head><title>T</title></head>
+		<body><x:image content="a.png"></x:image></body></html>`), &d, WithNamespaces(map[string]string{"og": "https://ogp.me/ns#"})))
+	asrt.Equal("a.png", d.Image)
+	asrt.Equal("T", d.Title)
+
+	var e struct {
+		Thumb string `xpath:"//unknown:thumbnail"`
+	}
+	asrt.Error(UnmarshalXML([]byte(testMediaRSS), &e, WithNamespaces(ns)))
+}
@@ -0,0 +1,46 @@
+package goxtag
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// nullValueTypes maps the database/sql Null types to the type of the value
+// they hold.
+var nullValueTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullTime{}):    reflect.TypeOf(time.Time{}),
+}
+
+func isNullType(t reflect.Type) bool {
+	_, ok := nullValueTypes[TypeDeref(t)]
+	return ok
+}
+
+// unmarshalNull decodes the value of the matched nodes like a field of the
+// valT type and stores it into the Null value v, which becomes Valid. Empty
+// values of other types than strings leave v as NULL, as do fields whose
+// selector matches nothing.
+func (d *decodeState) unmarshalNull(doc *Document, v reflect.Value, valT reflect.Type, tag xpathTag) error {
+	if valT.Kind() != reflect.String {
+		str, err := d.value(doc, v, tag)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(str) == "" {
+			return nil
+		}
+	}
+
+	val := reflect.New(valT).Elem()
+	if err := d.unmarshalByType(doc, val, tag); err != nil {
+		return err
+	}
+	return v.Addr().Interface().(sql.Scanner).Scan(val.Interface())
+}
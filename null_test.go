@@ -0,0 +1,44 @@
+package goxtag
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNullTypes(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<div>
+<span class="name">Widget</span>
+<span class="price">9.5</span>
+<span class="stock"></span>
+<span class="active">true</span>
+<time datetime="2021-01-02T03:04:05Z"></time>
+<span class="qty">3</span><span class="qty"></span>
+</div>`)
+
+	var a struct {
+		Name     sql.NullString  `xpath:"//span[@class='name']"`
+		Note     sql.NullString  `xpath:"//span[@class='note']"`
+		Price    sql.NullFloat64 `xpath:"//span[@class='price']"`
+		Stock    sql.NullInt64   `xpath:"//span[@class='stock']"`
+		Active   *sql.NullBool   `xpath:"//span[@class='active']"`
+		Added    sql.NullTime    `xpath:"//time/@datetime"`
+		Quantity []sql.NullInt32 `xpath:"//span[@class='qty']"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal(sql.NullString{String: "Widget", Valid: true}, a.Name)
+	asrt.False(a.Note.Valid)
+	asrt.Equal(sql.NullFloat64{Float64: 9.5, Valid: true}, a.Price)
+	asrt.False(a.Stock.Valid)
+	asrt.Equal(&sql.NullBool{Bool: true, Valid: true}, a.Active)
+	asrt.Equal(sql.NullTime{Time: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}, a.Added)
+	asrt.Equal([]sql.NullInt32{{Int32: 3, Valid: true}, {}}, a.Quantity)
+
+	var b struct {
+		Price sql.NullInt64 `xpath:"//span[@class='name']"`
+	}
+	asrt.Error(Unmarshal(page, &b))
+}
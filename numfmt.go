@@ -0,0 +1,59 @@
+package goxtag
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// numberFormat holds the separators numbers are written with, see
+// xpath_numfmt.
+type numberFormat struct {
+	thousands rune
+	decimal   rune
+}
+
+// numberFormats are the named formats of xpath_numfmt.
+var numberFormats = map[string]numberFormat{
+	"en": {',', '.'},
+	"de": {'.', ','},
+	"es": {'.', ','},
+	"it": {'.', ','},
+	"nl": {'.', ','},
+	"pt": {'.', ','},
+	"fr": {' ', ','},
+	"ru": {' ', ','},
+	"pl": {' ', ','},
+	"ch": {'\'', '.'},
+}
+
+// parseNumberFormat reads a number format: either a name of numberFormats or
+// the thousands and decimal separators, e.g. ".," for "1.234,56".
+func parseNumberFormat(spec string) (*numberFormat, error) {
+	if f, ok := numberFormats[strings.ToLower(spec)]; ok {
+		return &f, nil
+	}
+	if utf8.RuneCountInString(spec) == 2 {
+		seps := []rune(spec)
+		if seps[0] != seps[1] {
+			return &numberFormat{thousands: seps[0], decimal: seps[1]}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be a locale name or a thousands and a decimal separator, got %q", numFmtTag, spec)
+}
+
+// normalize rewrites s in Go syntax: thousands separators are dropped and the
+// decimal separator becomes a dot. Any space is taken for a thousands
+// separator when the format uses one, e.g. a no-break space.
+func (f *numberFormat) normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == f.thousands, unicode.IsSpace(f.thousands) && unicode.IsSpace(r):
+			return -1
+		case r == f.decimal:
+			return '.'
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
@@ -0,0 +1,48 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNumberFormat(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte("<p class=\"de\">1.234,56</p><p class=\"fr\">1 234,5</p>" +
+		"<p class=\"ch\">12'000</p><p class=\"en\">1,234.5</p>")
+
+	var a struct {
+		DE       float64 `xpath:"//p[@class='de']" xpath_numfmt:"de"`
+		FR       float64 `xpath:"//p[@class='fr']" xpath_numfmt:"fr"`
+		CH       int     `xpath:"//p[@class='ch']" xpath_numfmt:"ch"`
+		Explicit float32 `xpath:"//p[@class='de']" xpath_numfmt:".,"`
+		EN       float64 `xpath:"//p[@class='en']" xpath_numfmt:"en"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal(1234.56, a.DE)
+	asrt.Equal(1234.5, a.FR)
+	asrt.Equal(12000, a.CH)
+	asrt.Equal(float32(1234.56), a.Explicit)
+	asrt.Equal(1234.5, a.EN)
+
+	var b struct {
+		DE float64 `xpath:"//p[@class='de']"`
+		EN float64 `xpath:"//p[@class='en']" xpath_numfmt:"en"`
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &b, WithNumberFormat("de")))
+	asrt.Equal(1234.56, b.DE)
+	asrt.Equal(1234.5, b.EN)
+
+	var c struct {
+		N int `xpath:"//p[@class='de']" xpath_numfmt:"de"`
+	}
+	err := Unmarshal(page, &c)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Contains(err.Error(), `value "1.234,56"`)
+
+	var d struct {
+		N int `xpath:"//p[@class='de']" xpath_numfmt:"klingon"`
+	}
+	asrt.True(errors.Is(Unmarshal(page, &d), ErrInvalidTag))
+}
@@ -0,0 +1,175 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Option configures how a document is unmarshaled, see UnmarshalWithOptions.
+type Option func(*config)
+
+// config holds the settings an unmarshaling run is configured with.
+type config struct {
+	// tagName replaces "xpath" as the name of the selector tag and as the
+	// prefix of its companion tags
+	tagName string
+	// attrGetter overrides how attribute values are read, see AttrGetter
+	attrGetter AttrGetter
+	// contentType is the charset hint for the document, see WithContentType
+	contentType string
+	// fragment makes documents be parsed as fragments in fragmentContext,
+	// see WithFragment
+	fragment        bool
+	fragmentContext *html.Node
+	// collectErrors keeps decoding after a field fails, see WithCollectErrors
+	collectErrors bool
+	// syntax selects the parser of documents, see WithSyntax
+	syntax SyntaxMode
+	// strict is the default of the xpath_strict tag, see WithStrict
+	strict bool
+	// numFmt is the default of the xpath_numfmt tag, see WithNumberFormat
+	numFmt string
+	// baseURL is the URL the document was fetched from, see WithBaseURL
+	baseURL *url.URL
+	// trace is called for every field looked up, see WithTraceFunc
+	trace func(TraceEvent)
+	// coverage records the matched nodes, see WithCoverage
+	coverage *Coverage
+	// parallelism is the number of workers decoding slice elements, see
+	// WithParallelism
+	parallelism int
+	// converters decode the fields of their types, see WithConverter
+	converters map[reflect.Type]ConverterFunc
+	// maxInputBytes, maxDepth and maxNodes limit the documents parsed, see
+	// WithMaxInputBytes
+	maxInputBytes int64
+	maxDepth      int
+	maxNodes      int
+	// selectorTimeout and selectorBudget bound the evaluation of the
+	// selectors of fields, see WithSelectorTimeout
+	selectorTimeout time.Duration
+	selectorBudget  int
+	// namespaces maps the prefixes of selectors to namespace URIs, see
+	// WithNamespaces
+	namespaces map[string]string
+	// vars are the values of the variables of selectors, see WithVars
+	vars map[string]string
+	// params fill the placeholders of selectors, see WithParams
+	params map[string]string
+	// exprs caches the selectors compiled with namespaces, vars and params
+	exprs *sync.Map
+	// sanitizers are run on documents before decoding, see WithSanitizer
+	sanitizers []func(*html.Node)
+	// rawText reads text as written in the source, see WithRawText
+	rawText bool
+	// keepSource keeps the source of parsed documents, see WithSource
+	keepSource bool
+}
+
+func newConfig(opts []Option) config {
+	c := config{tagName: tagName}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithTagName reads selectors from the named struct tag instead of "xpath".
+// Companion tags are renamed accordingly, e.g. with WithTagName("html")
+// xpath_required becomes html_required and th becomes html_th. The css tag is
+// not affected.
+func WithTagName(name string) Option {
+	return func(c *config) {
+		if name != "" {
+			c.tagName = name
+		}
+	}
+}
+
+// WithAttrGetter overrides how attribute values are read, see AttrGetter.
+// Passing nil restores the default getter.
+func WithAttrGetter(getter AttrGetter) Option {
+	return func(c *config) {
+		c.attrGetter = getter
+	}
+}
+
+// WithCollectErrors keeps decoding the remaining fields and slice elements
+// after one fails instead of stopping at the first error. The failures are
+// returned together as UnmarshalErrors.
+func WithCollectErrors() Option {
+	return func(c *config) {
+		c.collectErrors = true
+	}
+}
+
+// WithStrict makes every field strict, as if tagged with xpath_strict:"true":
+// values of optional numeric and time fields that fail to parse are reported
+// instead of being left as zero, and so are empty values of numeric fields.
+// Fields tagged with xpath_strict:"false" are left lenient.
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithNumberFormat sets the format numbers are written with for every field
+// without an xpath_numfmt tag: a locale name like "de" or the thousands and
+// decimal separators like ".,". An invalid format makes unmarshaling fail.
+func WithNumberFormat(format string) Option {
+	return func(c *config) {
+		c.numFmt = format
+	}
+}
+
+// UnmarshalWithOptions is like Unmarshal but configured with opts.
+func UnmarshalWithOptions(bs []byte, v interface{}, opts ...Option) error {
+	c := newConfig(opts)
+	root, src, err := c.parseBytes(bs)
+
+	if err != nil {
+		return err
+	}
+
+	d := &decodeState{config: c, source: src}
+	return d.unmarshal(NewDocumentWithNode(root), v)
+}
+
+// UnmarshalSelectionWithOptions is like UnmarshalSelection but configured
+// with opts.
+func UnmarshalSelectionWithOptions(doc *Document, v interface{}, opts ...Option) error {
+	d := &decodeState{config: newConfig(opts)}
+	return d.unmarshal(doc, v)
+}
+
+// fieldTags reads the tags of a struct field, looking the "xpath" tag and its
+// companions up under the configured tag name.
+type fieldTags struct {
+	tag  reflect.StructTag
+	name string
+}
+
+func (ft fieldTags) Lookup(key string) (string, bool) {
+	if ft.name != tagName && strings.HasPrefix(key, tagName) {
+		key = ft.name + strings.TrimPrefix(key, tagName)
+	}
+	return ft.tag.Lookup(key)
+}
+
+func (ft fieldTags) Get(key string) string {
+	val, _ := ft.Lookup(key)
+	return val
+}
+
+// thTagKey returns the name of the th tag under the tag name name: th for
+// "xpath", and e.g. html_th with WithTagName("html").
+func thTagKey(name string) string {
+	if name == tagName {
+		return thTagName
+	}
+	return name + "_" + thTagName
+}
@@ -0,0 +1,139 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestWithTagName(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<h1>Title</h1><span class="count">3</span>`
+
+	var a struct {
+		Title   string `html:"//h1"`
+		Count   int    `html:"//span[@class='count']"`
+		Missing string `html:"//p" html_required:"false"`
+		Default string `html:"//p" html_default:"none"`
+		Ignored string `xpath:"//h1"`
+	}
+
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &a, WithTagName("html")))
+	asrt.Equal("Title", a.Title)
+	asrt.Equal(3, a.Count)
+	asrt.Equal("", a.Missing)
+	asrt.Equal("none", a.Default)
+	asrt.Equal("", a.Ignored)
+
+	// The xpath companion tags don't apply to the renamed tag
+	var b struct {
+		Missing string `html:"//p" xpath_required:"false"`
+	}
+	e := checkErr(asrt, UnmarshalWithOptions([]byte(page), &b, WithTagName("html")))
+	asrt.Equal(ErrNodeNotFound, e.Reason)
+}
+
+func TestWithAttrGetter(t *testing.T) {
+	asrt := assert.New(t)
+
+	upper := func(node *html.Node, name string) (string, bool) {
+		val, ok := getAttributeValue(name, node)
+		return strings.ToUpper(val), ok
+	}
+
+	var a struct {
+		Href string `xpath:"//a" xpath_attr:"href"`
+	}
+	asrt.NoError(UnmarshalWithOptions([]byte(`<a href="/x">x</a>`), &a, WithAttrGetter(upper)))
+	asrt.Equal("/X", a.Href)
+
+	doc := parseTestDocument(t, `<a href="/y">y</a>`)
+	asrt.NoError(UnmarshalSelectionWithOptions(doc, &a, WithAttrGetter(upper)))
+	asrt.Equal("/Y", a.Href)
+
+	asrt.NoError(NewDecoder(strings.NewReader(`<a href="/z">z</a>`), WithAttrGetter(upper)).Decode(&a))
+	asrt.Equal("/Z", a.Href)
+
+	// innertext reads the hidden attribute through the getter, which can
+	// reveal hidden elements
+	visible := func(node *html.Node, name string) (string, bool) {
+		if name == "hidden" {
+			return "", false
+		}
+		return getAttributeValue(name, node)
+	}
+	var b struct {
+		Text string `xpath:"//div" xpath_mode:"innertext"`
+	}
+	page := []byte(`<div><p>shown</p><p hidden>revealed</p></div>`)
+	asrt.NoError(Unmarshal(page, &b))
+	asrt.Equal("shown", b.Text)
+	asrt.NoError(UnmarshalWithOptions(page, &b, WithAttrGetter(visible)))
+	asrt.Equal("shown\n\nrevealed", b.Text)
+}
+
+func TestWithCollectErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<span class="count">many</span><span class="price">cheap</span>
+		<ul><li><b>1</b></li><li><b>two</b></li><li><b>3</b></li></ul>`
+
+	type item struct {
+		N int `xpath:"./b"`
+	}
+	type target struct {
+		Count   int     `xpath:"//span[@class='count']"`
+		Price   float64 `xpath:"//span[@class='price']"`
+		Missing string  `xpath:"//p"`
+		Items   []item  `xpath:"//li"`
+		OK      string  `xpath:"//span[@class='count']"`
+	}
+
+	var a target
+	err := UnmarshalWithOptions([]byte(page), &a, WithCollectErrors())
+	asrt.IsType(UnmarshalErrors{}, err)
+	errs := err.(UnmarshalErrors)
+	asrt.Len(errs, 4)
+
+	var vals []string
+	for _, err := range errs {
+		e := checkErr(asrt, err).unwind()
+		vals = append(vals, e.val)
+	}
+	asrt.Equal([]string{"many", "cheap", "", "two"}, vals)
+	asrt.Contains(errs[0].Error(), `value "many"`)
+	asrt.Contains(errs[0].Error(), `.Count'`)
+	asrt.Contains(errs[2].Error(), "tag: '//p'")
+	asrt.Contains(errs[3].Error(), ".Items[1].N'")
+	asrt.Contains(err.Error(), "4 errors occurred")
+
+	// The other fields and elements are decoded anyway
+	asrt.Equal("many", a.OK)
+	asrt.Equal([]item{{1}, {0}, {3}}, a.Items)
+
+	// Without the option the first error is returned
+	var b target
+	e := checkErr(asrt, Unmarshal([]byte(page), &b)).unwind()
+	asrt.Equal("many", e.val)
+	asrt.Equal("", b.OK)
+}
+
+func TestWithStrict(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<p class="price">n/a</p><p class="stock"></p>`)
+
+	var a struct {
+		Price float64 `xpath:"//p[@class='price']" xpath_required:"false"`
+	}
+	asrt.True(errors.Is(UnmarshalWithOptions(page, &a, WithStrict()), ErrTypeConversion))
+
+	var b struct {
+		Price float64 `xpath:"//p[@class='price']" xpath_required:"false" xpath_strict:"false"`
+		Stock int     `xpath:"//p[@class='stock']" xpath_strict:"false"`
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &b, WithStrict()))
+}
@@ -0,0 +1,185 @@
+package goxtag
+
+import (
+	"context"
+	"fmt"
+	"github.com/antchfx/xpath"
+	"reflect"
+	"strings"
+)
+
+// Paginator decodes multi-page listings: it fetches a page, decodes it, then
+// follows the link matched by the next page selector until there is none,
+// the link points at a page already visited or MaxPages pages were fetched.
+type Paginator struct {
+	// MaxPages stops after that many pages, 0 for no limit
+	MaxPages int
+	// Key, if set, identifies records: those with a key seen on a previous
+	// record are dropped, e.g. items repeated on consecutive pages
+	Key func(rec interface{}) string
+
+	next  string
+	fetch FetchFunc
+	opts  []Option
+}
+
+// NewPaginator returns a Paginator following the link matched by the
+// nextSelector XPath selector: the href of the element or the value of the
+// attribute it matches, resolved against the URL of the page. Pages are
+// fetched with fetch, or with GET requests of http.DefaultClient if it is
+// nil, and decoded with opts like UnmarshalResponse does.
+func NewPaginator(nextSelector string, fetch FetchFunc, opts ...Option) (*Paginator, error) {
+	if _, err := xpath.Compile(nextSelector); err != nil {
+		return nil, err
+	}
+	if fetch == nil {
+		fetch = defaultFetch
+	}
+	return &Paginator{next: nextSelector, fetch: fetch, opts: opts}, nil
+}
+
+// Collect decodes the first page at startURL into v, a pointer to a struct,
+// and appends the records of the slice field named field of every next page
+// to the one of v. The other fields keep the values of the first page.
+func (p *Paginator) Collect(ctx context.Context, startURL string, v interface{}, field string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &CannotUnmarshalError{
+			V:      rv,
+			Reason: ErrNonPointer,
+		}
+	}
+	st := rv.Elem().Type()
+	if f, ok := st.FieldByName(field); !ok || f.Type.Kind() != reflect.Slice {
+		return fmt.Errorf("%s has no slice field %s", st, field)
+	}
+
+	records := rv.Elem().FieldByName(field)
+	seen := map[string]bool{}
+	first := true
+	return p.each(ctx, startURL, func(d *decodeState, doc *Document) error {
+		page := rv
+		if !first {
+			page = reflect.New(st)
+		}
+		first = false
+		if err := d.unmarshal(doc, page.Interface()); err != nil {
+			return err
+		}
+
+		recs := page.Elem().FieldByName(field)
+		kept := reflect.MakeSlice(recs.Type(), 0, recs.Len())
+		for i := 0; i < recs.Len(); i++ {
+			if p.keep(recs.Index(i).Interface(), seen) {
+				kept = reflect.Append(kept, recs.Index(i))
+			}
+		}
+		if page == rv {
+			records.Set(kept)
+		} else {
+			records.Set(reflect.AppendSlice(records, kept))
+		}
+		return nil
+	})
+}
+
+// Stream is like Decoder.Stream over every page: the nodes matching
+// recordSelector are decoded into values of the element type of ch and sent
+// as soon as they are decoded. The channel is closed when Stream returns.
+func (p *Paginator) Stream(ctx context.Context, startURL, recordSelector string, ch interface{}) error {
+	chv, err := sendChan(ch)
+	if err != nil {
+		return err
+	}
+	defer chv.Close()
+
+	seen := map[string]bool{}
+	keep := func(rec interface{}) bool {
+		return p.keep(rec, seen)
+	}
+	return p.each(ctx, startURL, func(d *decodeState, doc *Document) error {
+		sel, err := d.findAll(doc, recordSelector)
+		if err != nil {
+			return err
+		}
+		return sendRecords(ctx, chv, sel, recordSelector, func(rec *Document, v interface{}) error {
+			rd := *d
+			return rd.unmarshal(rec, v)
+		}, keep)
+	})
+}
+
+// keep reports whether rec has not been seen before according to Key.
+func (p *Paginator) keep(rec interface{}, seen map[string]bool) bool {
+	if p.Key == nil {
+		return true
+	}
+	key := p.Key(rec)
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	return true
+}
+
+// each fetches and parses the pages from startURL on and calls decode with
+// each of them.
+func (p *Paginator) each(ctx context.Context, startURL string, decode func(d *decodeState, doc *Document) error) error {
+	visited := map[string]bool{}
+	for page, pageURL := 1, startURL; pageURL != ""; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		visited[strings.SplitN(pageURL, "#", 2)[0]] = true
+
+		resp, err := fetch(ctx, p.fetch, pageURL)
+		if err != nil {
+			return err
+		}
+		d, doc, err := parseResponse(resp, p.opts)
+		if err != nil {
+			return err
+		}
+		d.ctx = ctx
+		if err := decode(d, doc); err != nil {
+			return err
+		}
+
+		if p.MaxPages > 0 && page >= p.MaxPages {
+			return nil
+		}
+		pageURL, err = p.nextURL(d, doc)
+		if err != nil {
+			return err
+		}
+		if visited[strings.SplitN(pageURL, "#", 2)[0]] {
+			return nil
+		}
+	}
+	return nil
+}
+
+// nextURL returns the absolute URL of the next page of doc, or an empty
+// string if there is none.
+func (p *Paginator) nextURL(d *decodeState, doc *Document) (string, error) {
+	link, err := d.findOne(doc, p.next)
+	if err != nil || link.IsEmpty() {
+		return "", err
+	}
+
+	href, ok := d.attr(link.Nodes[0], "href")
+	if !ok {
+		// Attributes are returned as elements holding their value
+		href = link.Text()
+	}
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return "", nil
+	}
+
+	u, err := d.resolveURL(href)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
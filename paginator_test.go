@@ -0,0 +1,94 @@
+package goxtag
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var testPages = map[string]string{
+	"1": `<h1>Shop</h1><ul><li>a</li><li>b</li></ul><a rel="next" href="?p=2">next</a>`,
+	"2": `<h1>Shop, page 2</h1><ul><li>b</li><li>c</li></ul><a rel="next" href="/list?p=3">next</a>`,
+	"3": `<h1>Shop, page 3</h1><ul><li>d</li></ul><a rel="next" href="/list?p=1#top">first</a>`,
+}
+
+func testPaginatorServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := testPages[r.URL.Query().Get("p")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+}
+
+type testListingPage struct {
+	Title string   `xpath:"//h1"`
+	Items []string `xpath:"//li"`
+}
+
+func TestPaginatorCollect(t *testing.T) {
+	asrt := assert.New(t)
+
+	srv := testPaginatorServer(t)
+	defer srv.Close()
+
+	p, err := NewPaginator("//a[@rel='next']", nil)
+	asrt.NoError(err)
+
+	var a testListingPage
+	asrt.NoError(p.Collect(context.Background(), srv.URL+"/list?p=1", &a, "Items"))
+	asrt.Equal("Shop", a.Title)
+	asrt.Equal([]string{"a", "b", "b", "c", "d"}, a.Items)
+
+	p.Key = func(rec interface{}) string {
+		return rec.(string)
+	}
+	p.MaxPages = 2
+	a = testListingPage{}
+	asrt.NoError(p.Collect(context.Background(), srv.URL+"/list?p=1", &a, "Items"))
+	asrt.Equal([]string{"a", "b", "c"}, a.Items)
+
+	asrt.Error(p.Collect(context.Background(), srv.URL+"/list?p=1", &a, "Title"))
+	asrt.True(errors.Is(p.Collect(context.Background(), srv.URL+"/list?p=1", a, "Items"), ErrNonPointer))
+
+	var se *StatusError
+	asrt.True(errors.As(p.Collect(context.Background(), srv.URL+"/list?p=9", &a, "Items"), &se))
+
+	_, err = NewPaginator("//a[", nil)
+	asrt.Error(err)
+}
+
+func TestPaginatorStream(t *testing.T) {
+	asrt := assert.New(t)
+
+	srv := testPaginatorServer(t)
+	defer srv.Close()
+
+	var fetched []string
+	p, err := NewPaginator("//a[@rel='next']/@href", func(ctx context.Context, url string) (*http.Response, error) {
+		fetched = append(fetched, url)
+		return defaultFetch(ctx, url)
+	})
+	asrt.NoError(err)
+	p.Key = func(rec interface{}) string {
+		return rec.(string)
+	}
+
+	ch := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Stream(context.Background(), srv.URL+"/list?p=1", "//li", ch)
+	}()
+	var items []string
+	for it := range ch {
+		items = append(items, it)
+	}
+	asrt.NoError(<-errc)
+	asrt.Equal([]string{"a", "b", "c", "d"}, items)
+	asrt.Equal([]string{srv.URL + "/list?p=1", srv.URL + "/list?p=2", srv.URL + "/list?p=3"}, fetched)
+}
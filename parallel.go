@@ -0,0 +1,74 @@
+package goxtag
+
+import (
+	"reflect"
+	"sync"
+)
+
+// minParallelElements is the least number of slice elements worth decoding
+// concurrently.
+const minParallelElements = 8
+
+// WithParallelism decodes the elements of slices with at least a few matched
+// nodes concurrently with up to workers goroutines, keeping their order.
+// Elements are decoded as usual otherwise, so it only pays off for elements
+// that take some work, like the items of large listings. Custom Unmarshalers
+// of the elements must then be safe for concurrent use.
+//
+// Slices are decoded serially when tracing, recording coverage or collecting
+// notes into a DecodeReport, which all depend on the order of the fields.
+// Nested slices are decoded serially by the workers.
+func WithParallelism(workers int) Option {
+	return func(c *config) {
+		c.parallelism = workers
+	}
+}
+
+// elementResult is an element decoded ahead by a worker.
+type elementResult struct {
+	v   reflect.Value
+	err error
+}
+
+// decodeElementsParallel decodes every node of doc into a new element of type
+// eleT if the run decodes slices concurrently, and returns nil otherwise.
+func (d *decodeState) decodeElementsParallel(doc *Document, eleT reflect.Type, tag xpathTag) []elementResult {
+	n := doc.Length()
+	if d.parallelism < 2 || n < minParallelElements || d.trace != nil || d.coverage != nil || d.report != nil {
+		return nil
+	}
+
+	workers := d.parallelism
+	if workers > n {
+		workers = n
+	}
+
+	results := make([]elementResult, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Workers don't share the state of the run
+			wd := *d
+			wd.parallelism = 0
+			for i := range indexes {
+				if err := wd.canceled(); err != nil {
+					results[i].err = err
+					continue
+				}
+				newV := reflect.New(TypeDeref(eleT))
+				elem := doc.pooledEq(i)
+				results[i] = elementResult{newV, wd.unmarshalByType(elem, newV, tag)}
+				putDocument(elem)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
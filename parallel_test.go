@@ -0,0 +1,57 @@
+package goxtag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func testListing(n int) []byte {
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `<li><b>item %d</b><i>%d</i><span>a,b</span></li>`, i, i)
+	}
+	b.WriteString("</ul>")
+	return []byte(b.String())
+}
+
+type parallelItem struct {
+	Name  string   `xpath:"./b"`
+	Price int      `xpath:"./i"`
+	Tags  []string `xpath:"./span" xpath_split:","`
+}
+
+func TestParallelism(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := testListing(100)
+
+	var serial, parallel struct {
+		Items []*parallelItem `xpath:"//li"`
+	}
+	asrt.NoError(Unmarshal(page, &serial))
+	asrt.NoError(UnmarshalWithOptions(page, &parallel, WithParallelism(4)))
+	asrt.Len(parallel.Items, 100)
+	asrt.Equal(serial, parallel)
+	asrt.Equal(&parallelItem{Name: "item 42", Price: 42, Tags: []string{"a", "b"}}, parallel.Items[42])
+
+	// Errors are reported for the first failing element, in order
+	bad := strings.Replace(string(page), "<i>7</i>", "<i>x</i>", 1)
+	bad = strings.Replace(bad, "<i>60</i>", "<i>y</i>", 1)
+	err := UnmarshalWithOptions([]byte(bad), &parallel, WithParallelism(4))
+	asrt.Error(err)
+	asrt.Contains(err.Error(), "Items[7]")
+
+	err = UnmarshalWithOptions([]byte(bad), &parallel, WithParallelism(4), WithCollectErrors())
+	var errs UnmarshalErrors
+	asrt.True(errors.As(err, &errs))
+	asrt.Len(errs, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	asrt.Equal(context.Canceled, UnmarshalContext(ctx, page, &parallel, WithParallelism(4)))
+}
@@ -0,0 +1,196 @@
+package goxtag
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// WithParams fills the {name} placeholders of the selectors of fields before
+// they are compiled, e.g. with WithParams(map[string]string{"sku": "A-1"}),
+// `xpath:".//div[@data-sku='{sku}']"` selects the div of that product. Values
+// are escaped for where they appear: inside a string literal they become part
+// of it, elsewhere they are inserted as a string literal, or as an
+// identifier in css selectors. Without params, selectors are used as
+// written; with params, placeholders without a value fail.
+func WithParams(params map[string]string) Option {
+	return func(c *config) {
+		m := make(map[string]string, len(c.params)+len(params))
+		for k, v := range c.params {
+			m[k] = v
+		}
+		for k, v := range params {
+			m[k] = v
+		}
+		c.params = m
+		c.exprs = &sync.Map{}
+	}
+}
+
+// expandParams returns tag with the placeholders of its selector filled with
+// the params of d.
+func (d *decodeState) expandParams(tag xpathTag) (xpathTag, error) {
+	if !hasPlaceholders(tag.tag) {
+		return tag, nil
+	}
+	selector, err := expandPlaceholders(tag.tag, tag.css, func(name string) (string, bool) {
+		val, ok := d.params[name]
+		return val, ok
+	})
+	if err != nil {
+		return tag, err
+	}
+	tag.tag = selector
+	tag.expr = nil
+	tag.cssSel = nil
+	return tag, nil
+}
+
+// hasPlaceholders reports whether selector holds placeholders.
+func hasPlaceholders(selector string) bool {
+	for s := selector; ; {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			return false
+		}
+		s = s[start+1:]
+		if end := strings.IndexByte(s, '}'); end > 0 && isPlaceholderName(s[:end]) {
+			return true
+		}
+	}
+}
+
+// expandPlaceholders replaces the {name} placeholders of selector with the
+// values lookup returns, escaped for XPath or, if css is set, for CSS.
+func expandPlaceholders(selector string, css bool, lookup func(name string) (string, bool)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(selector); {
+		c := selector[i]
+		if c == '\'' || c == '"' {
+			end := strings.IndexByte(selector[i+1:], c)
+			if end < 0 {
+				// Left for the compiler to report
+				b.WriteString(selector[i:])
+				break
+			}
+			lit := selector[i+1 : i+1+end]
+			content, err := fillPlaceholders(lit, lookup, func(s string) string {
+				if css {
+					return cssEscapeString(s, c)
+				}
+				return s
+			})
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case css || content == lit:
+				b.WriteByte(c)
+				b.WriteString(content)
+				b.WriteByte(c)
+			default:
+				b.WriteString(xpathLiteral(content))
+			}
+			i += end + 2
+			continue
+		}
+
+		next := strings.IndexAny(selector[i:], `'"`)
+		if next < 0 {
+			next = len(selector) - i
+		}
+		part, err := fillPlaceholders(selector[i:i+next], lookup, func(s string) string {
+			if css {
+				return cssEscapeIdent(s)
+			}
+			return xpathLiteral(s)
+		})
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(part)
+		i += next
+	}
+	return b.String(), nil
+}
+
+// fillPlaceholders replaces the placeholders of s with the values lookup
+// returns, escaped with escape.
+func fillPlaceholders(s string, lookup func(name string) (string, bool), escape func(string) string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		name := ""
+		if end > 0 {
+			name = s[start+1 : start+end]
+		}
+		if !isPlaceholderName(name) {
+			b.WriteString(s[:start+1])
+			s = s[start+1:]
+			continue
+		}
+		val, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("no value for placeholder {%s}", name)
+		}
+		b.WriteString(s[:start])
+		b.WriteString(escape(val))
+		s = s[start+end+1:]
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
+
+func isPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVarNameByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// cssEscapeString escapes s for a CSS string delimited by quote.
+func cssEscapeString(s string, quote byte) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == quote || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\%x ", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// cssEscapeIdent escapes s as a CSS identifier.
+func cssEscapeIdent(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9' && (i == 0 || i == 1 && s[0] == '-'):
+			fmt.Fprintf(&b, "\\%x ", c)
+		case c == '_' || c == '-' || c >= 0x80 ||
+			c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9':
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\%x ", c)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testSKUPage struct {
+	Name   string `xpath:".//div[@data-sku='{sku}']/h2"`
+	Price  string `css:"#{id} .price"`
+	Script string `xpath:"//script[contains(., '{')]"`
+}
+
+func TestWithParams(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<div data-sku="A-1"><h2>Phone</h2></div>
+		<div data-sku="it's &quot;x&quot;"><h2>Case</h2></div>
+		<div id="1st"><span class="price">10</span></div>
+		<div id="a.b"><span class="price">20</span></div>
+		<script>var a = {};</script>`)
+
+	var a testSKUPage
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithParams(map[string]string{"sku": "A-1", "id": "1st"})))
+	asrt.Equal(testSKUPage{Name: "Phone", Price: "10", Script: "var a = {};"}, a)
+
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithParams(map[string]string{"sku": `it's "x"`, "id": "a.b"})))
+	asrt.Equal("Case", a.Name)
+	asrt.Equal("20", a.Price)
+
+	asrt.Error(UnmarshalWithOptions(page, &a, WithParams(map[string]string{"sku": "A-1"})))
+
+	_, err := NewTypeDecoder(reflect.TypeOf(testSKUPage{}))
+	asrt.NoError(err)
+
+	sel, err := expandPlaceholders(`//a[@href={url}]`, false, func(string) (string, bool) {
+		return "/x'y", true
+	})
+	asrt.NoError(err)
+	asrt.Equal(`//a[@href="/x'y"]`, sel)
+	sel, err = expandPlaceholders(`a[title="{t}"]`, true, func(string) (string, bool) {
+		return `say "hi"\`, true
+	})
+	asrt.NoError(err)
+	asrt.Equal(`a[title="say \"hi\"\\"]`, sel)
+}
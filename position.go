@@ -0,0 +1,194 @@
+package goxtag
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is the location of an element in the source of the document.
+type Position struct {
+	// Offset is the byte offset of the start tag in the source, transcoded
+	// to UTF-8 for HTML documents, starting at 0
+	Offset int
+	// Line and Column start at 1, columns are counted in runes
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// WithSource keeps the UTF-8 source of the documents read by a Decoder or
+// from an http.Response in memory, to report the Pos of value errors and to
+// read the text of xpath_mode:"raw" fields as written. Without it, these
+// documents are parsed as they are read. Documents passed as bytes, e.g. to
+// Unmarshal, always keep it. WithRawText implies it.
+func WithSource() Option {
+	return func(c *config) {
+		c.keepSource = true
+	}
+}
+
+// sourceIndex locates the elements of a parsed document in its source. The
+// parser does not keep positions, so the n-th element of a given name in the
+// tree is matched with the n-th start tag of that name in the source. Names
+// whose counts differ between the two, such as the <html>, <body> or <tbody>
+// elements added by the parser, are not located.
+type sourceIndex struct {
+	src  []byte
+	root *html.Node
+	// starts holds the offsets of the start tags per tag name
+	starts map[string][]int
+	// ordinals holds the rank of each element among those of its name
+	ordinals map[*html.Node]int
+	counts   map[string]int
+}
+
+func newSourceIndex(src []byte, root *html.Node) *sourceIndex {
+	idx := &sourceIndex{
+		src:      src,
+		root:     root,
+		starts:   map[string][]int{},
+		ordinals: map[*html.Node]int{},
+		counts:   map[string]int{},
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(src))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			name, _ := z.TagName()
+			idx.starts[string(name)] = append(idx.starts[string(name)], offset)
+		}
+		offset += len(z.Raw())
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			name := strings.ToLower(n.Data)
+			idx.ordinals[n] = idx.counts[name]
+			idx.counts[name]++
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	return idx
+}
+
+// position returns the position of the start tag of n, or of the element
+// holding it for other nodes.
+func (idx *sourceIndex) position(n *html.Node) Position {
+	for n != nil && n.Type != html.ElementNode {
+		n = n.Parent
+	}
+	offset, ok := idx.offset(n)
+	if !ok {
+		return Position{}
+	}
+
+	lineStart := bytes.LastIndexByte(idx.src[:offset], '\n') + 1
+	return Position{
+		Offset: offset,
+		Line:   bytes.Count(idx.src[:offset], []byte{'\n'}) + 1,
+		Column: utf8.RuneCount(idx.src[lineStart:offset]) + 1,
+	}
+}
+
+// offset returns the offset of the start tag of the element n in the source.
+func (idx *sourceIndex) offset(n *html.Node) (int, bool) {
+	if n == nil {
+		return 0, false
+	}
+	ord, ok := idx.ordinals[n]
+	if !ok {
+		return 0, false
+	}
+	name := strings.ToLower(n.Data)
+	starts := idx.starts[name]
+	if len(starts) != idx.counts[name] {
+		return 0, false
+	}
+	return starts[ord], true
+}
+
+// rawText returns the source of the text of the element n, entities and
+// character references intact, given text, its parsed text. The text tokens
+// following the start tag are collected until they make up text, so it is
+// not found when the parser moved or dropped text, e.g. in misnested tables.
+func (idx *sourceIndex) rawText(n *html.Node, text string) (string, bool) {
+	offset, ok := idx.offset(n)
+	if !ok {
+		return "", false
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(idx.src[offset:]))
+	if z.Next() != html.StartTagToken {
+		return "", text == ""
+	}
+	var raw, parsed strings.Builder
+	for parsed.Len() < len(text) {
+		switch z.Next() {
+		case html.ErrorToken:
+			return "", false
+		case html.TextToken:
+			raw.Write(z.Raw())
+			parsed.Write(z.Text())
+		}
+	}
+	if parsed.String() != text {
+		return "", false
+	}
+	return raw.String(), true
+}
+
+// index returns the index of the source of the document of n, built on the
+// first call, or nil if the document wasn't parsed by the run itself or its
+// source wasn't kept, see WithSource.
+func (d *decodeState) index(n *html.Node) *sourceIndex {
+	if d.source == nil {
+		return nil
+	}
+	if d.sourceIndex == nil {
+		root := n
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		if root.Type != html.DocumentNode {
+			// e.g. the detached node of an attribute selected with /@name
+			return nil
+		}
+		d.sourceIndex = newSourceIndex(d.source, root)
+	}
+	return d.sourceIndex
+}
+
+// position returns the position in the source of the first node of doc. It
+// is only known when the document was parsed by the run itself; the index is
+// built on the first call, i.e. once something went wrong.
+func (d *decodeState) position(doc *Document) Position {
+	if doc == nil || len(doc.Nodes) == 0 {
+		return Position{}
+	}
+	idx := d.index(doc.Nodes[0])
+	if idx == nil {
+		return Position{}
+	}
+	return idx.position(doc.Nodes[0])
+}
@@ -0,0 +1,51 @@
+package goxtag
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+var errNoSource = errors.New("the source of the document is unknown, see WithSource")
+
+// WithRawText makes the text of fields be read as written in the source of
+// the document, entities and character references intact (e.g. "Q&amp;A"
+// rather than "Q&A") and whitespace preserved, for pipelines hashing or
+// diffing extracted values byte for byte. It applies to the string fields in
+// the default text mode, see xpath_mode:"raw" for single fields. Attributes
+// and the text of other fields are still read parsed.
+func WithRawText() Option {
+	return func(c *config) {
+		c.rawText = true
+		c.keepSource = true
+	}
+}
+
+// rawTextVal returns a valFunc reading the text of the matched elements as
+// written in the source. It fails for documents not parsed by the run or
+// whose source wasn't kept. The parsed text is used for other nodes and
+// where the text can't be found in the source, with a note for the field
+// selected by xpath.
+func (d *decodeState) rawTextVal(xpath string) valFunc {
+	return func(doc *Document) (string, error) {
+		if d.source == nil {
+			return doc.Text(), errNoSource
+		}
+		var b strings.Builder
+		for i, n := range doc.Nodes {
+			text := doc.Eq(i).Text()
+			raw, ok := "", false
+			if n.Type == html.ElementNode {
+				if idx := d.index(n); idx != nil {
+					raw, ok = idx.rawText(n, text)
+				}
+			}
+			if !ok {
+				d.note(xpath, "source text not found, using the parsed text")
+				raw = text
+			}
+			b.WriteString(raw)
+		}
+		return b.String(), nil
+	}
+}
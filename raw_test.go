@@ -0,0 +1,62 @@
+package goxtag
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRawText(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<h1 title="Q&amp;A"> Q&amp;A &#8212; <b>&lt;tips&gt;</b> </h1>
+		<p>1&#160;000</p><script>if (a &amp;&amp; b) {}</script><div>&copy;</div>`)
+
+	var a struct {
+		Title  string `xpath:"//h1"`
+		Raw    string `xpath:"//h1" xpath_mode:"raw"`
+		Attr   string `xpath:"//h1" xpath_attr:"title"`
+		Script string `xpath:"//script" xpath_mode:"raw"`
+		Count  string `xpath:"//p" xpath_mode:"raw" xpath_space:"trim"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal("Q&A — <tips>", a.Title)
+	asrt.Equal(" Q&amp;A &#8212; &lt;tips&gt; ", a.Raw)
+	asrt.Equal("Q&A", a.Attr)
+	asrt.Equal("if (a &amp;&amp; b) {}", a.Script)
+	asrt.Equal("1&#160;000", a.Count)
+
+	var b struct {
+		Title  string   `xpath:"//h1"`
+		Texts  []string `xpath:"//p | //div"`
+		Attr   string   `xpath:"//h1" xpath_attr:"title"`
+		Price  float64  `xpath:"//p" xpath_numfmt:"fr"`
+		Report DecodeReport
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &b, WithRawText()))
+	asrt.Equal(" Q&amp;A &#8212; &lt;tips&gt; ", b.Title)
+	asrt.Equal([]string{"1&#160;000", "&copy;"}, b.Texts)
+	asrt.Equal("Q&A", b.Attr)
+	asrt.Equal(float64(1000), b.Price)
+	asrt.Empty(b.Report.Notes)
+
+	// Streamed documents need WithSource
+	var c struct {
+		Title string `xpath:"//h1" xpath_mode:"raw" xpath_space:"trim"`
+	}
+	asrt.NoError(NewDecoder(bytes.NewReader(page), WithSource()).Decode(&c))
+	asrt.Equal("Q&amp;A &#8212; &lt;tips&gt;", c.Title)
+
+	// Raw text fails rather than falling back when the source is unknown
+	e := checkErr(asrt, NewDecoder(bytes.NewReader(page)).Decode(&c))
+	asrt.Equal(ErrTypeConversion, e.Reason)
+	asrt.True(errors.Is(e, errNoSource))
+	e = checkErr(asrt, UnmarshalSelection(parseTestDocument(t, string(page)), &c))
+	asrt.True(errors.Is(e, errNoSource))
+
+	var d struct {
+		Title string `xpath:"//h1" xpath_mode:"raw" xpath_attr:"title"`
+	}
+	asrt.Error(Unmarshal(page, &d))
+}
@@ -0,0 +1,35 @@
+package goxtag
+
+import "reflect"
+
+var reportType = reflect.TypeOf(DecodeReport{})
+
+// DecodeNote describes a non-fatal issue met while decoding a struct field.
+type DecodeNote struct {
+	Field   string
+	XPath   string
+	Message string
+}
+
+// DecodeReport collects non-fatal issues met while decoding a struct, such as
+// optional fields whose selector matched nothing or optional numbers that
+// could not be parsed and were left as zero. A struct field of this type needs
+// no tag; it is filled with the notes about the other fields of the same
+// struct, including the fields of nested structs that have no report of their
+// own.
+type DecodeReport struct {
+	Notes []DecodeNote
+}
+
+// note records a non-fatal issue for the field being decoded. Notes are
+// dropped if no enclosing struct has a DecodeReport.
+func (d *decodeState) note(xpath, msg string) {
+	if d.report == nil {
+		return
+	}
+	d.report.Notes = append(d.report.Notes, DecodeNote{
+		Field:   d.field,
+		XPath:   xpath,
+		Message: msg,
+	})
+}
@@ -0,0 +1,53 @@
+package goxtag
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// requiredIfField returns the index of the sibling field named by the
+// xpath_required_if option of the i-th field of struct type t.
+func requiredIfField(t reflect.Type, i int, tag xpathTag) (int, error) {
+	other, ok := t.FieldByName(tag.requiredIf)
+	if !ok || len(other.Index) != 1 {
+		return 0, fmt.Errorf("%s: %s has no field %s", requiredIfTag, t, tag.requiredIf)
+	}
+	if other.Index[0] == i {
+		return 0, fmt.Errorf("%s: field %s can't depend on itself", requiredIfTag, tag.requiredIf)
+	}
+	return other.Index[0], nil
+}
+
+// siblingMatched reports whether the sibling field the i-th field of struct v
+// depends on with xpath_required_if matches nodes in doc, the selection of v,
+// which makes the i-th field required. Siblings are looked up whatever their
+// order, without being decoded.
+func (d *decodeState) siblingMatched(doc *Document, v reflect.Value, i int, tag xpathTag) (bool, error) {
+	t := v.Type()
+	j, err := requiredIfField(t, i, tag)
+	var otherTag xpathTag
+	if err == nil {
+		otherTag, err = d.fieldTag(t, j)
+	}
+	if err == nil && (otherTag.tag == "" || otherTag.tag == ignoreTag) {
+		err = fmt.Errorf("%s: field %s has no selector", requiredIfTag, tag.requiredIf)
+	}
+	if err != nil {
+		return false, &CannotUnmarshalError{
+			V:        v,
+			Reason:   ErrInvalidTag,
+			XPath:    tag.tag,
+			Err:      err,
+			FldOrIdx: t.Field(i).Name,
+		}
+	}
+
+	if otherTag.jsonLD != "" {
+		return len(d.findJSONLD(doc, otherTag)) > 0, nil
+	}
+	sel, err := d.findForTypeByTag(doc, v.Field(j), otherTag)
+	if err != nil {
+		return false, err
+	}
+	return !sel.IsEmpty(), nil
+}
@@ -0,0 +1,44 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testOffer struct {
+	Price   float64 `xpath:".//span[@class='price']" xpath_required_if:"InStock"`
+	InStock bool    `xpath:".//span[@class='stock']" xpath_required:"false" xpath_true:"in stock"`
+	Name    string  `xpath:".//h2"`
+}
+
+func TestRequiredIf(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Offers []testOffer `xpath:"//div"`
+	}
+	page := `<div><h2>A</h2><span class="stock">in stock</span><span class="price">9.5</span></div>
+		<div><h2>B</h2></div>`
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal([]testOffer{{Price: 9.5, InStock: true, Name: "A"}, {Name: "B"}}, a.Offers)
+
+	page = `<div><h2>A</h2><span class="stock">in stock</span></div>`
+	asrt.Equal(ErrNodeNotFound, checkErr(asrt, Unmarshal([]byte(page), &a)).unwind().chain[2].Reason)
+
+	td, err := NewTypeDecoder(reflect.TypeOf(a))
+	asrt.NoError(err)
+	asrt.Error(td.Unmarshal([]byte(page), &a))
+
+	var b struct {
+		Price float64 `xpath:"//span" xpath_required_if:"Stock"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &b)).Reason)
+	asrt.Error(ValidateType(reflect.TypeOf(b)))
+
+	var c struct {
+		Price float64 `xpath:"//span" xpath_required_if:"Name" xpath_required:"true"`
+		Name  string  `xpath:"//h2"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &c)).Reason)
+}
@@ -0,0 +1,60 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// WithSanitizer runs fn on the root of every document once it is parsed and
+// before it is decoded, so that scripts, comments, tracking pixels and other
+// junk can be stripped or attributes normalized in one place, see
+// RemoveElements and RemoveComments. Several sanitizers run in the order
+// they are given. A StreamDecoder runs them on the tree of each record.
+func WithSanitizer(fn func(root *html.Node)) Option {
+	return func(c *config) {
+		// Configs are copied by value, don't append to a shared array
+		c.sanitizers = append(c.sanitizers[:len(c.sanitizers):len(c.sanitizers)], fn)
+	}
+}
+
+// sanitize runs the sanitizers of c on root.
+func (c config) sanitize(root *html.Node) {
+	for _, fn := range c.sanitizers {
+		fn(root)
+	}
+}
+
+// RemoveElements returns a sanitizer removing the elements with the given
+// names, e.g. RemoveElements("script", "style", "noscript").
+func RemoveElements(names ...string) func(root *html.Node) {
+	remove := make(map[string]bool, len(names))
+	for _, name := range names {
+		remove[strings.ToLower(name)] = true
+	}
+	return func(root *html.Node) {
+		removeNodes(root, func(n *html.Node) bool {
+			return n.Type == html.ElementNode && remove[strings.ToLower(n.Data)]
+		})
+	}
+}
+
+// RemoveComments is a sanitizer removing the comments of the document.
+func RemoveComments(root *html.Node) {
+	removeNodes(root, func(n *html.Node) bool {
+		return n.Type == html.CommentNode
+	})
+}
+
+// removeNodes removes the descendants of n for which remove returns true,
+// along with their subtrees.
+func removeNodes(n *html.Node, remove func(*html.Node) bool) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if remove(c) {
+			n.RemoveChild(c)
+		} else {
+			removeNodes(c, remove)
+		}
+		c = next
+	}
+}
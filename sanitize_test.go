@@ -0,0 +1,45 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestWithSanitizer(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<div id="post"><!-- ad slot --><p>Hello <script>track()</script>world</p>
+		<img src="/pixel.gif" width="1"><STYLE>p{}</STYLE><a href="/a" data-track="1">more</a></div>`)
+
+	stripTracking := func(root *html.Node) {
+		doc := NewDocumentWithNode(root)
+		doc.Find("//img[@width='1']").Remove()
+		doc.Find("//*[@data-track]").RemoveAttr("data-track")
+	}
+
+	var a struct {
+		Body  string   `xpath:"//div[@id='post']" xpath_mode:"html"`
+		Texts []string `xpath:"//div[@id='post']//text()[normalize-space(.)]"`
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &a,
+		WithSanitizer(RemoveElements("script", "style")), WithSanitizer(RemoveComments), WithSanitizer(stripTracking)))
+	asrt.Equal(`<p>Hello world</p>`+"\n\t\t"+`<a href="/a">more</a>`, a.Body)
+	asrt.Equal([]string{"Hello", "world", "more"}, a.Texts)
+
+	dec, err := NewStreamDecoder(strings.NewReader(`<ul><li>a<script>x</script></li><li>b<!-- c --></li></ul>`), "li",
+		WithSanitizer(RemoveElements("SCRIPT")), WithSanitizer(RemoveComments))
+	asrt.NoError(err)
+	var items []string
+	for {
+		var item struct {
+			HTML string `xpath:"." xpath_mode:"html"`
+		}
+		if dec.DecodeNext(&item) != nil {
+			break
+		}
+		items = append(items, item.HTML)
+	}
+	asrt.Equal([]string{"a", "b"}, items)
+}
@@ -0,0 +1,74 @@
+package goxtag
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"time"
+)
+
+// Sitemap is a sitemap, see ParseSitemap. Sitemap indexes have Sitemaps,
+// url sets have URLs.
+type Sitemap struct {
+	URLs     []SitemapURL   `xpath:"/urlset/url" xpath_required:"false"`
+	Sitemaps []SitemapEntry `xpath:"/sitemapindex/sitemap" xpath_required:"false"`
+}
+
+// SitemapURL is a page listed by a sitemap.
+type SitemapURL struct {
+	Loc        string    `xpath:"./loc"`
+	LastMod    time.Time `xpath:"./lastmod" xpath_required:"false"`
+	ChangeFreq string    `xpath:"./changefreq" xpath_required:"false"`
+	// Priority is 0.5, the default of the protocol, when it isn't given
+	Priority float64 `xpath:"./priority" xpath_default:"0.5"`
+}
+
+// SitemapEntry is a sitemap listed by a sitemap index.
+type SitemapEntry struct {
+	Loc     string    `xpath:"./loc"`
+	LastMod time.Time `xpath:"./lastmod" xpath_required:"false"`
+}
+
+// sitemapTimeLayouts are the W3C Datetime formats allowed for lastmod.
+var sitemapTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// parseSitemapTime parses s with the first of sitemapTimeLayouts that fits.
+// Dates that fit none are left as zero.
+func parseSitemapTime(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range sitemapTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// ParseSitemap decodes a sitemap (a urlset) or a sitemap index with the XML
+// syntax, see WithSyntax. Gzipped sitemaps (sitemap.xml.gz) are decompressed
+// first; the WithMaxInputBytes limit applies to the decompressed document.
+func ParseSitemap(bs []byte, opts ...Option) (*Sitemap, error) {
+	var r io.Reader = bytes.NewReader(bs)
+	if bytes.HasPrefix(bs, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var sitemap Sitemap
+	opts = append([]Option{WithConverter(timeType, parseSitemapTime)}, opts...)
+	if err := NewDecoder(r, append(opts, WithSyntax(SyntaxXML))...).Decode(&sitemap); err != nil {
+		return nil, err
+	}
+	return &sitemap, nil
+}
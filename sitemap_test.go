@@ -0,0 +1,72 @@
+package goxtag
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseSitemap(t *testing.T) {
+	asrt := assert.New(t)
+
+	urlset := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2021-01-02</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>https://example.com/about</loc>
+    <lastmod>2021-01-02T03:04+01:00</lastmod>
+  </url>
+</urlset>`)
+	want := []SitemapURL{
+		{
+			Loc:        "https://example.com/",
+			LastMod:    time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+			ChangeFreq: "daily",
+			Priority:   0.8,
+		},
+		{
+			Loc:      "https://example.com/about",
+			Priority: 0.5,
+		},
+	}
+
+	sm, err := ParseSitemap(urlset)
+	asrt.NoError(err)
+	asrt.Len(sm.URLs, 2)
+	asrt.True(time.Date(2021, 1, 2, 2, 4, 0, 0, time.UTC).Equal(sm.URLs[1].LastMod))
+	want[1].LastMod = sm.URLs[1].LastMod
+	asrt.Equal(want, sm.URLs)
+	asrt.Empty(sm.Sitemaps)
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write(urlset)
+	asrt.NoError(w.Close())
+	sm, err = ParseSitemap(gz.Bytes())
+	asrt.NoError(err)
+	asrt.Equal(want, sm.URLs)
+
+	_, err = ParseSitemap(gz.Bytes(), WithMaxInputBytes(100))
+	asrt.True(errors.Is(err, ErrLimitExceeded))
+
+	sm, err = ParseSitemap([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/a.xml.gz</loc><lastmod>2021-03</lastmod></sitemap>
+  <sitemap><loc>https://example.com/b.xml.gz</loc></sitemap>
+</sitemapindex>`))
+	asrt.NoError(err)
+	asrt.Equal([]SitemapEntry{
+		{Loc: "https://example.com/a.xml.gz", LastMod: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Loc: "https://example.com/b.xml.gz"},
+	}, sm.Sitemaps)
+
+	_, err = ParseSitemap([]byte(`<urlset><url><lastmod>2021</lastmod></url></urlset>`))
+	asrt.Error(err)
+}
@@ -0,0 +1,134 @@
+package goxtag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+// DevTools Protocol node types, see DOM.Node
+const (
+	devToolsElement  = 1
+	devToolsText     = 3
+	devToolsCDATA    = 4
+	devToolsComment  = 8
+	devToolsDocument = 9
+	devToolsDoctype  = 10
+	devToolsFragment = 11
+)
+
+// devToolsNode is a DOM.Node of the Chrome DevTools Protocol.
+type devToolsNode struct {
+	NodeType   int             `json:"nodeType"`
+	NodeName   string          `json:"nodeName"`
+	LocalName  string          `json:"localName"`
+	NodeValue  string          `json:"nodeValue"`
+	Attributes []string        `json:"attributes"`
+	Children   []*devToolsNode `json:"children"`
+	IsSVG      bool            `json:"isSVG"`
+}
+
+// ParseSnapshot parses a DOM serialized by a headless browser, so that pages
+// rendered by JavaScript can be decoded like any other: either their HTML,
+// e.g. the result of DOM.getOuterHTML with chromedp, parsed like Unmarshal
+// does with opts, or the JSON of a DevTools Protocol DOM.Node tree, e.g. the
+// result of DOM.getDocument with a depth of -1 or its root node. Children
+// that were not fetched, shadow roots, iframe documents and pseudo elements
+// are left out of the tree.
+func ParseSnapshot(data []byte, opts ...Option) (*Document, error) {
+	c := newConfig(opts)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		root, _, err := c.parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return NewDocumentWithNode(root), nil
+	}
+
+	var snapshot struct {
+		Root *devToolsNode `json:"root"`
+		devToolsNode
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	node := snapshot.Root
+	if node == nil {
+		node = &snapshot.devToolsNode
+	}
+
+	root, err := node.htmlNode()
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("unsupported DevTools node type %d", node.NodeType)
+	}
+	if err := c.checkLimits(root); err != nil {
+		return nil, err
+	}
+	c.sanitize(root)
+	return NewDocumentWithNode(root), nil
+}
+
+// UnmarshalSnapshot decodes a DOM serialized by a headless browser into v,
+// see ParseSnapshot.
+func UnmarshalSnapshot(data []byte, v interface{}, opts ...Option) error {
+	doc, err := ParseSnapshot(data, opts...)
+	if err != nil {
+		return err
+	}
+	return UnmarshalSelectionWithOptions(doc, v, opts...)
+}
+
+// htmlNode converts the DevTools node n and its children into an html.Node.
+func (n *devToolsNode) htmlNode() (*html.Node, error) {
+	node := &html.Node{}
+	switch n.NodeType {
+	case devToolsDocument, devToolsFragment:
+		node.Type = html.DocumentNode
+	case devToolsElement:
+		if len(n.Attributes)%2 != 0 {
+			return nil, fmt.Errorf("odd number of attribute names and values in %s", n.NodeName)
+		}
+		name := n.LocalName
+		if name == "" {
+			name = strings.ToLower(n.NodeName)
+		}
+		node.Type = html.ElementNode
+		node.Data = name
+		node.DataAtom = atom.Lookup([]byte(name))
+		if n.IsSVG {
+			node.Namespace = "svg"
+		}
+		for i := 0; i < len(n.Attributes); i += 2 {
+			node.Attr = append(node.Attr, html.Attribute{Key: n.Attributes[i], Val: n.Attributes[i+1]})
+		}
+	case devToolsText, devToolsCDATA:
+		node.Type = html.TextNode
+		node.Data = n.NodeValue
+	case devToolsComment:
+		node.Type = html.CommentNode
+		node.Data = n.NodeValue
+	case devToolsDoctype:
+		node.Type = html.DoctypeNode
+		node.Data = strings.ToLower(n.NodeName)
+	default:
+		// e.g. processing instructions, which HTML documents don't have
+		return nil, nil
+	}
+
+	for _, child := range n.Children {
+		c, err := child.htmlNode()
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			node.AppendChild(c)
+		}
+	}
+	return node, nil
+}
@@ -0,0 +1,71 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testDevToolsDOM = `{"root": {"nodeId": 1, "nodeType": 9, "nodeName": "#document", "localName": "", "nodeValue": "",
+	"children": [
+		{"nodeType": 10, "nodeName": "html", "nodeValue": ""},
+		{"nodeType": 1, "nodeName": "HTML", "localName": "html", "nodeValue": "", "attributes": ["lang", "en"], "children": [
+			{"nodeType": 1, "nodeName": "HEAD", "localName": "head", "nodeValue": "", "children": [
+				{"nodeType": 1, "nodeName": "TITLE", "localName": "title", "nodeValue": "", "children": [
+					{"nodeType": 3, "nodeName": "#text", "localName": "", "nodeValue": "Rendered"}
+				]}
+			]},
+			{"nodeType": 1, "nodeName": "BODY", "localName": "body", "nodeValue": "", "children": [
+				{"nodeType": 8, "nodeName": "#comment", "localName": "", "nodeValue": " app "},
+				{"nodeType": 1, "nodeName": "UL", "localName": "ul", "nodeValue": "", "attributes": ["id", "items"], "children": [
+					{"nodeType": 1, "nodeName": "LI", "localName": "li", "nodeValue": "", "attributes": ["data-price", "1.5"], "children": [
+						{"nodeType": 3, "nodeName": "#text", "localName": "", "nodeValue": "a"}
+					]},
+					{"nodeType": 1, "nodeName": "LI", "localName": "li", "nodeValue": "", "attributes": ["data-price", "2"], "children": [
+						{"nodeType": 3, "nodeName": "#text", "localName": "", "nodeValue": "b"}
+					]}
+				]},
+				{"nodeType": 1, "nodeName": "svg", "localName": "svg", "nodeValue": "", "isSVG": true, "childNodeCount": 3}
+			]}
+		]}
+	]
+}}`
+
+type testSnapshotPage struct {
+	Title  string    `xpath:"meta:title"`
+	Lang   string    `xpath:"//html/@lang"`
+	Items  []string  `xpath:"//ul[@id='items']/li"`
+	Prices []float64 `xpath:"//li/@data-price"`
+}
+
+func TestUnmarshalSnapshot(t *testing.T) {
+	asrt := assert.New(t)
+
+	want := testSnapshotPage{
+		Title:  "Rendered",
+		Lang:   "en",
+		Items:  []string{"a", "b"},
+		Prices: []float64{1.5, 2},
+	}
+
+	var a testSnapshotPage
+	asrt.NoError(UnmarshalSnapshot([]byte(testDevToolsDOM), &a))
+	asrt.Equal(want, a)
+
+	var b testSnapshotPage
+	asrt.NoError(UnmarshalSnapshot([]byte(`<html lang="en"><head><title>Rendered</title></head>
+		<body><ul id="items"><li data-price="1.5">a</li><li data-price="2">b</li></ul></body></html>`), &b))
+	asrt.Equal(want, b)
+
+	doc, err := ParseSnapshot([]byte(testDevToolsDOM))
+	asrt.NoError(err)
+	asrt.Equal("svg", doc.Find("//svg").Nodes[0].Namespace)
+	asrt.Equal(" app ", doc.Find("//comment()").Nodes[0].Data)
+
+	_, err = ParseSnapshot([]byte(`{"nodeType": 1, "nodeName": "P", "attributes": ["id"]}`))
+	asrt.Error(err)
+	_, err = ParseSnapshot([]byte(`{"root": [`))
+	asrt.Error(err)
+	_, err = ParseSnapshot([]byte(testDevToolsDOM), WithMaxNodes(5))
+	asrt.True(errors.Is(err, ErrLimitExceeded))
+}
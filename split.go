@@ -0,0 +1,67 @@
+package goxtag
+
+import (
+	"reflect"
+	"strings"
+)
+
+// unmarshalSplit fills a slice by splitting the value of each matched node on
+// the xpath_split separator. Parts are trimmed and empty ones are dropped, the
+// others are converted like the value of a single node.
+func (d *decodeState) unmarshalSplit(doc *Document, v reflect.Value, tag xpathTag) error {
+	slice := v
+	eleT := v.Type().Elem()
+	val := d.valFunc(tag)
+
+	v.SetLen(0)
+	for i := range doc.Nodes {
+		str, err := val(doc.Eq(i))
+		if err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				XPath:    tag.tag,
+				Err:      err,
+				Val:      str,
+				FldOrIdx: v.Len(),
+				Pos:      d.position(doc.Eq(i)),
+			}
+		}
+		for _, part := range strings.Split(str, tag.split) {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			newV := reflect.New(TypeDeref(eleT))
+			if err := unmarshalText(part, newV.Elem()); err != nil {
+				return &CannotUnmarshalError{
+					V:        v,
+					Reason:   ErrTypeConversion,
+					XPath:    tag.tag,
+					Err:      err,
+					Val:      part,
+					FldOrIdx: v.Len(),
+					Pos:      d.position(doc.Eq(i)),
+				}
+			}
+
+			if eleT.Kind() != reflect.Ptr {
+				newV = newV.Elem()
+			}
+			v = reflect.Append(v, newV)
+		}
+	}
+
+	slice.Set(v)
+	return nil
+}
+
+// unmarshalText converts s into v with its UnmarshalText method if it has
+// one, or as a literal otherwise.
+func unmarshalText(s string, v reflect.Value) error {
+	if tu, ok := textUnmarshaler(v); ok {
+		return tu.UnmarshalText([]byte(s))
+	}
+	return unmarshalLiteral(s, v)
+}
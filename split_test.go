@@ -0,0 +1,39 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<meta name="keywords" content="go, html , ,xpath">
+<p class="crumbs">Home &gt; Blog &gt; Post</p>
+<p class="ids">1;2</p><p class="ids">3</p>`)
+
+	var a struct {
+		Keywords []string `xpath:"//meta[@name='keywords']/@content" xpath_split:","`
+		Crumbs   []string `xpath:"//p[@class='crumbs']" xpath_split:">"`
+		IDs      []*int   `xpath:"//p[@class='ids']" xpath_split:";"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal([]string{"go", "html", "xpath"}, a.Keywords)
+	asrt.Equal([]string{"Home", "Blog", "Post"}, a.Crumbs)
+	asrt.Len(a.IDs, 3)
+	asrt.Equal(3, *a.IDs[2])
+
+	var b struct {
+		Levels []Level `xpath:"//p" xpath_split:","`
+	}
+	asrt.NoError(Unmarshal([]byte(`<p>high, low</p>`), &b))
+	asrt.Equal([]Level{2, 1}, b.Levels)
+
+	var c struct {
+		IDs []int `xpath:"//p" xpath_split:","`
+	}
+	err := Unmarshal([]byte(`<p>1, x</p>`), &c)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Contains(err.Error(), `value "x"`)
+}
@@ -0,0 +1,135 @@
+package goxtag
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const srcsetAttr = "srcset"
+
+var srcsetType = reflect.TypeOf(Srcset{})
+
+// Srcset holds the image candidates of the srcset attribute of an <img> or
+// <source> element. Fields of this type are filled from the srcset attribute
+// of the matched nodes, or from their text when the selector ends in
+// /@srcset, without further tags.
+type Srcset []SrcsetCandidate
+
+// SrcsetCandidate is a single image candidate of a srcset attribute. Width is
+// set for "480w" descriptors and Density for "2x" ones, the other stays zero.
+type SrcsetCandidate struct {
+	URL     string
+	Width   int
+	Density float64
+}
+
+// parseSrcset splits a srcset attribute into its image candidates following
+// the shape of the HTML algorithm: URLs are separated from their descriptors
+// by whitespace and candidates are separated by commas. Width ("480w") and
+// pixel density ("2x") descriptors are kept, other descriptors are ignored.
+func parseSrcset(s string) Srcset {
+	var candidates Srcset
+
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		if s == "" {
+			return candidates
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		c := SrcsetCandidate{URL: s[:end]}
+		s = s[end:]
+
+		// A URL glued to the next comma has no descriptors
+		if strings.HasSuffix(c.URL, ",") {
+			c.URL = strings.TrimRight(c.URL, ",")
+			candidates = append(candidates, c)
+			continue
+		}
+
+		end = strings.IndexByte(s, ',')
+		if end < 0 {
+			end = len(s)
+		}
+		for _, desc := range strings.Fields(s[:end]) {
+			value, unit := desc[:len(desc)-1], desc[len(desc)-1]
+			switch unit {
+			case 'w':
+				c.Width, _ = strconv.Atoi(value)
+			case 'x':
+				c.Density, _ = strconv.ParseFloat(value, 64)
+			}
+		}
+		s = s[end:]
+
+		candidates = append(candidates, c)
+	}
+}
+
+// unmarshalSrcset fills a slice from the srcset attribute of the matched
+// nodes (or from their text when the selector ends in /@srcset). Struct
+// elements get their URL, Width and Density fields set when present; string
+// elements get the URL only.
+func (d *decodeState) unmarshalSrcset(doc *Document, v reflect.Value) error {
+	slice := v
+	eleT := v.Type().Elem()
+
+	v.SetLen(0)
+	for _, node := range doc.Nodes {
+		val, ok := d.attr(node, srcsetAttr)
+		if !ok {
+			val = NewDocumentWithNode(node).Text()
+		}
+
+		for _, c := range parseSrcset(val) {
+			newV := reflect.New(TypeDeref(eleT))
+			setSrcsetCandidate(newV.Elem(), c)
+
+			if eleT.Kind() != reflect.Ptr {
+				newV = newV.Elem()
+			}
+			v = reflect.Append(v, newV)
+		}
+	}
+
+	slice.Set(v)
+	return nil
+}
+
+func setSrcsetCandidate(v reflect.Value, c SrcsetCandidate) {
+	if v.Kind() == reflect.String {
+		v.SetString(c.URL)
+		return
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	if f := v.FieldByName("URL"); f.IsValid() && f.Kind() == reflect.String {
+		f.SetString(c.URL)
+	}
+	if f := v.FieldByName("Width"); f.IsValid() && isNumberKind(f.Kind()) {
+		setNumber(f, float64(c.Width))
+	}
+	if f := v.FieldByName("Density"); f.IsValid() && isNumberKind(f.Kind()) {
+		setNumber(f, c.Density)
+	}
+}
+
+func setNumber(v reflect.Value, n float64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(n)
+	}
+}
@@ -0,0 +1,40 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	asrt := assert.New(t)
+
+	asrt.Equal(Srcset{
+		{URL: "a.jpg"},
+		{URL: "b.jpg", Density: 1.5},
+		{URL: "c.jpg", Density: 2},
+		{URL: "d.jpg", Width: 100},
+	}, parseSrcset(" a.jpg, b.jpg 1.5x,c.jpg 2x , d.jpg 100w 50h,,"))
+	asrt.Nil(parseSrcset(" , "))
+}
+
+func TestSrcsetType(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Image  Srcset  `xpath:"//img[@id='hero']"`
+		Attr   Srcset  `xpath:"//img[@id='hero']/@srcset"`
+		Source *Srcset `xpath:"//source"`
+		None   Srcset  `xpath:"//img[@id='plain']"`
+	}
+
+	page := `<picture><source srcset="hero.webp 1x, hero@2x.webp 2x">
+		<img id="hero" src="hero.jpg" srcset="hero-480.jpg 480w, hero-960.jpg 960w"></picture>
+		<img id="plain" src="plain.jpg">`
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	want := Srcset{{URL: "hero-480.jpg", Width: 480}, {URL: "hero-960.jpg", Width: 960}}
+	asrt.Equal(want, a.Image)
+	asrt.Equal(want, a.Attr)
+	asrt.Equal(&Srcset{{URL: "hero.webp", Density: 1}, {URL: "hero@2x.webp", Density: 2}}, a.Source)
+	asrt.Empty(a.None)
+}
@@ -0,0 +1,210 @@
+package goxtag
+
+import (
+	"context"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+)
+
+// StreamDecoder decodes the records of a document one at a time without
+// building the tree of the whole document, which keeps memory flat on huge
+// pages (exports, long listings). Records are the elements matched by a CSS
+// selector; only the tree of the current record is built and handed to
+// Unmarshal-like decoding, with the record element as the root of its own
+// document.
+//
+// Since the document is never built, the record selector can only look at
+// the element itself and its ancestors (e.g. "tr.row", "#list > li"), not at
+// siblings or descendants. For the same reason only WithMaxInputBytes of the
+// resource limits applies.
+type StreamDecoder struct {
+	z      *html.Tokenizer
+	sel    cascadia.SelectorGroup
+	config config
+
+	// open holds the elements open around the current token
+	open []*html.Node
+	// pending is a start tag that implicitly closed the previous record
+	pending *html.Token
+	err     error
+}
+
+// NewStreamDecoder returns a StreamDecoder reading the records matched by the
+// recordSelector CSS selector from r.
+func NewStreamDecoder(r io.Reader, recordSelector string, opts ...Option) (*StreamDecoder, error) {
+	sel, err := cascadia.ParseGroup(recordSelector)
+	if err != nil {
+		return nil, err
+	}
+	c := newConfig(opts)
+	r, err = c.utf8Reader(c.limitReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return &StreamDecoder{
+		z:      html.NewTokenizer(r),
+		sel:    sel,
+		config: c,
+	}, nil
+}
+
+// DecodeNext decodes the next record into v following the rules of
+// Unmarshal. It returns io.EOF once there are no more records.
+func (s *StreamDecoder) DecodeNext(v interface{}) error {
+	return s.DecodeNextContext(context.Background(), v)
+}
+
+// DecodeNextContext is like DecodeNext but stops with ctx.Err() as soon as
+// ctx is done, see UnmarshalContext.
+func (s *StreamDecoder) DecodeNextContext(ctx context.Context, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rec, err := s.next()
+	if err != nil {
+		return err
+	}
+
+	s.config.sanitize(rec)
+	d := &decodeState{ctx: ctx, config: s.config}
+	return d.unmarshal(NewDocumentWithNode(rec), v)
+}
+
+// next reads tokens up to the next record and returns its tree.
+func (s *StreamDecoder) next() (*html.Node, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	for {
+		var tok html.Token
+		if s.pending != nil {
+			tok, s.pending = *s.pending, nil
+		} else {
+			if s.z.Next() == html.ErrorToken {
+				s.err = s.z.Err()
+				return nil, s.err
+			}
+			tok = s.z.Token()
+		}
+
+		switch tok.Type {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			n := s.elementNode(tok)
+			if s.sel.Match(n) {
+				return s.readRecord(n, tok.Type == html.SelfClosingTagToken), nil
+			}
+			if tok.Type == html.StartTagToken && !isVoidElement(tok.DataAtom) {
+				s.open = append(s.open, n)
+			}
+		case html.EndTagToken:
+			s.close(tok.Data)
+		}
+	}
+}
+
+// elementNode returns a detached element for a start tag, parented by the
+// open elements so that selectors can match on ancestors.
+func (s *StreamDecoder) elementNode(tok html.Token) *html.Node {
+	n := &html.Node{
+		Type:     html.ElementNode,
+		Data:     tok.Data,
+		DataAtom: tok.DataAtom,
+		Attr:     tok.Attr,
+	}
+	if len(s.open) > 0 {
+		n.Parent = s.open[len(s.open)-1]
+	}
+	return n
+}
+
+// close pops the open elements up to the one named name, if it is open.
+func (s *StreamDecoder) close(name string) bool {
+	for i := len(s.open) - 1; i >= 0; i-- {
+		if s.open[i].Data == name {
+			s.open = s.open[:i]
+			return true
+		}
+	}
+	return false
+}
+
+// readRecord builds the tree of the record starting with the rec element.
+func (s *StreamDecoder) readRecord(rec *html.Node, closed bool) *html.Node {
+	parent := rec.Parent
+	rec.Parent = nil
+	root := &html.Node{Type: html.DocumentNode}
+	root.AppendChild(rec)
+	if closed || isVoidElement(rec.DataAtom) {
+		return rec
+	}
+
+	stack := []*html.Node{rec}
+	for len(stack) > 0 {
+		if s.z.Next() == html.ErrorToken {
+			s.err = s.z.Err()
+			return rec
+		}
+		tok := s.z.Token()
+		top := stack[len(stack)-1]
+
+		switch tok.Type {
+		case html.TextToken:
+			top.AppendChild(&html.Node{Type: html.TextNode, Data: tok.Data})
+		case html.CommentToken:
+			top.AppendChild(&html.Node{Type: html.CommentNode, Data: tok.Data})
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if len(stack) == 1 && tok.Data == rec.Data && impliesEnd(rec.DataAtom) {
+				// e.g. <li>one<li>two: the new record closes this one
+				s.pending = &tok
+				return rec
+			}
+			n := &html.Node{
+				Type:     html.ElementNode,
+				Data:     tok.Data,
+				DataAtom: tok.DataAtom,
+				Attr:     tok.Attr,
+			}
+			top.AppendChild(n)
+			if tok.Type == html.StartTagToken && !isVoidElement(tok.DataAtom) {
+				stack = append(stack, n)
+			}
+		case html.EndTagToken:
+			found := false
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].Data == tok.Data {
+					stack = stack[:i]
+					found = true
+					break
+				}
+			}
+			if !found && parent != nil && s.close(tok.Data) {
+				// An ancestor was closed, so the record is too
+				return rec
+			}
+		}
+	}
+	return rec
+}
+
+func isVoidElement(a atom.Atom) bool {
+	switch a {
+	case atom.Area, atom.Base, atom.Br, atom.Col, atom.Embed, atom.Hr, atom.Img,
+		atom.Input, atom.Link, atom.Meta, atom.Param, atom.Source, atom.Track, atom.Wbr:
+		return true
+	}
+	return false
+}
+
+// impliesEnd reports whether a start tag of an element closes a sibling of the
+// same kind that is still open.
+func impliesEnd(a atom.Atom) bool {
+	switch a {
+	case atom.Li, atom.P, atom.Tr, atom.Td, atom.Th, atom.Dt, atom.Dd, atom.Option:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package goxtag
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+type streamRecord struct {
+	ID    int      `xpath:"." xpath_attr:"data-id"`
+	Name  string   `xpath:"./td[1]"`
+	Price float64  `xpath:"./td[2]"`
+	Tags  []string `css:"span.tag" xpath_required:"false"`
+}
+
+func TestStreamDecoder(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<html><body>
+<table id="other"><tr class="row" data-id="0"><td>skip</td><td>0</td></tr></table>
+<table id="items">
+	<tr><th>Name</th><th>Price</th></tr>
+	<tr class="row" data-id="1"><td>Apple <span class="tag">fruit</span></td><td>1.5</td></tr>
+	<!-- comment -->
+	<tr class="row" data-id="2"><td>Bread<br>loaf</td><td>2</td>
+	<tr class="row" data-id="3"><td>Milk</td><td>0.99</td></tr>
+</table>
+<div class="row" data-id="4"></div>
+</body></html>`
+
+	dec, err := NewStreamDecoder(strings.NewReader(page), "#items tr.row")
+	asrt.NoError(err)
+
+	var recs []streamRecord
+	for {
+		var rec streamRecord
+		err := dec.DecodeNext(&rec)
+		if err == io.EOF {
+			break
+		}
+		asrt.NoError(err)
+		recs = append(recs, rec)
+	}
+
+	asrt.Equal([]streamRecord{
+		{ID: 1, Name: "Apple fruit", Price: 1.5, Tags: []string{"fruit"}},
+		{ID: 2, Name: "Breadloaf", Price: 2},
+		{ID: 3, Name: "Milk", Price: 0.99},
+	}, recs)
+	asrt.Equal(io.EOF, dec.DecodeNext(&streamRecord{}))
+}
+
+func TestStreamDecoderImpliedEnd(t *testing.T) {
+	asrt := assert.New(t)
+
+	dec, err := NewStreamDecoder(strings.NewReader(`<ul><li>one<li>two <b>2</b></ul><li>three`), "li")
+	asrt.NoError(err)
+
+	var items []string
+	for {
+		var item struct {
+			Text string `xpath:"."`
+		}
+		if err := dec.DecodeNext(&item); err != nil {
+			asrt.Equal(io.EOF, err)
+			break
+		}
+		items = append(items, item.Text)
+	}
+	asrt.Equal([]string{"one", "two 2", "three"}, items)
+}
+
+func TestStreamDecoderErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	_, err := NewStreamDecoder(strings.NewReader(""), "li[")
+	asrt.Error(err)
+
+	dec, err := NewStreamDecoder(strings.NewReader(`<li>x</li>`), "li")
+	asrt.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var item struct {
+		Text string `xpath:"."`
+	}
+	asrt.Equal(context.Canceled, dec.DecodeNextContext(ctx, &item))
+	asrt.NoError(dec.DecodeNext(&item))
+	asrt.Equal("x", item.Text)
+}
@@ -0,0 +1,171 @@
+package goxtag
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tableHeaderSelector picks the header row: the first row of <thead>, or
+	// else the first row having <th> cells.
+	tableHeaderSelector = "(./thead/tr | ./tbody/tr[th] | ./tr[th])[1]"
+	tableRowsSelector   = "./tbody/tr | ./tr"
+	tableCellsSelector  = "./th | ./td"
+)
+
+// hasHeaderFields reports whether t is a slice of structs having fields
+// tagged with th (renamed after the tag name name, see thTagKey), which are
+// decoded as tables without an xpath_table tag.
+func hasHeaderFields(t reflect.Type, name string) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	structT := TypeDeref(t.Elem())
+	if structT.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < structT.NumField(); i++ {
+		if _, ok := structT.Field(i).Tag.Lookup(thTagKey(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// tableColumns maps the normalized header text of a table to column indexes.
+type tableColumns map[string]int
+
+func normalizeHeader(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func newTableColumns(header *Document) tableColumns {
+	cols := tableColumns{}
+	for i, cell := range header.Find(tableCellsSelector).Nodes {
+		name := normalizeHeader(NewDocumentWithNode(cell).Text())
+		if _, ok := cols[name]; !ok {
+			cols[name] = i
+		}
+	}
+	return cols
+}
+
+// unmarshalTable fills a slice of structs from the body rows of the matched
+// tables. Each struct field is assigned the cell of the column whose header
+// text matches the th (or xpath_col) tag of the field, or its name; the match is
+// case-insensitive and ignores surrounding whitespace. A field with an xpath
+// tag is looked up relative to its cell. Fields without a matching column are
+// left untouched, or set from their defaults. Rows are otherwise decoded like
+// any struct, with its hooks, required checks and defaults.
+func (d *decodeState) unmarshalTable(doc *Document, v reflect.Value, tag xpathTag) error {
+	slice := v
+	eleT := v.Type().Elem()
+	structT := TypeDeref(eleT)
+
+	if structT.Kind() != reflect.Struct {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTableNeedsStructs,
+			XPath:  tag.tag,
+		}
+	}
+
+	v.SetLen(0)
+	for i := range doc.Nodes {
+		table := doc.Eq(i)
+		header := table.Find(tableHeaderSelector)
+		cols := newTableColumns(header)
+		if d.coverage != nil {
+			d.coverage.mark(header.Nodes, true)
+		}
+
+		for _, row := range table.Find(tableRowsSelector).Nodes {
+			if err := d.canceled(); err != nil {
+				return err
+			}
+			if !header.IsEmpty() && row == header.Nodes[0] {
+				continue
+			}
+
+			newV := reflect.New(structT)
+			if d.coverage != nil {
+				d.coverage.mark([]*html.Node{row}, false)
+			}
+			cells := NewDocumentWithNode(row).Find(tableCellsSelector)
+			pop := d.pushPath(fmt.Sprintf("[%d]", v.Len()))
+			err := d.unmarshalStruct(NewDocumentWithNode(row), newV.Elem(), &tableRow{cells, cols})
+			pop()
+			if err != nil {
+				return &CannotUnmarshalError{
+					V:        v,
+					Reason:   ErrTypeConversion,
+					XPath:    tag.tag,
+					Err:      err,
+					FldOrIdx: v.Len(),
+				}
+			}
+
+			if eleT.Kind() != reflect.Ptr {
+				newV = newV.Elem()
+			}
+			v = reflect.Append(v, newV)
+		}
+	}
+
+	slice.Set(v)
+	return nil
+}
+
+// tableRow is the row of a table a struct is decoded from.
+type tableRow struct {
+	cells *Document
+	cols  tableColumns
+}
+
+// unmarshalCell decodes the i-th field of the struct v from the cell of its
+// column in row, or from the nodes its xpath tag selects relative to the
+// cell. Fields without a column in the table only get their defaults.
+func (d *decodeState) unmarshalCell(doc *Document, v reflect.Value, i int, tag xpathTag, row *tableRow) error {
+	field := v.Type().Field(i)
+	if field.PkgPath != "" || field.Type == reportType {
+		return nil
+	}
+
+	name := tag.col
+	if name == "" {
+		name = field.Name
+	}
+	idx, ok := row.cols[normalizeHeader(name)]
+	if !ok || idx >= row.cells.Length() {
+		if !tag.hasDefaults() {
+			return nil
+		}
+		if _, err := d.applyDefault(doc, v.Field(i), tag); err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: field.Name,
+			}
+		}
+		return nil
+	}
+
+	cell := row.cells.Eq(idx)
+	sel := cell
+	selector := "column " + strconv.Quote(name)
+	if tag.tag != "" {
+		var err error
+		if sel, err = d.findForTypeByTag(cell, v.Field(i), tag); err != nil {
+			return err
+		}
+		selector += " " + tag.tag
+	}
+	d.traceField(sel, field.Type, tag, selector)
+	d.cover(cell, sel, field.Type, tag)
+	return d.decodeField(cell, sel, v, i, tag)
+}
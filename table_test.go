@@ -0,0 +1,176 @@
+package goxtag
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testTable = `<table id="prices">
+	<caption>Prices</caption>
+	<thead>
+		<tr><th> Product </th><th>PRICE, $</th><th>In stock</th><th>Link</th><th>Notes</th></tr>
+	</thead>
+	<tbody>
+		<tr><td>Apple</td><td>1.25</td><td>true</td><td><a href="/apple">view</a></td><td>fresh</td></tr>
+		<tr><td>Banana</td><td>0.5</td><td>false</td><td><a href="/banana">view</a></td><td></td></tr>
+		<tr><td>Cherry</td><td></td><td>true</td><td></td><td>seasonal</td></tr>
+	</tbody>
+</table>
+<table id="headless-thead">
+	<tr><th>Name</th><th>Qty</th></tr>
+	<tr><td>Bolt</td><td>10</td></tr>
+	<tr><td>Nut</td><td>20</td></tr>
+</table>`
+
+type priceRow struct {
+	Product string
+	Price   float64 `xpath_col:"price, $"`
+	InStock bool    `xpath_col:"In Stock"`
+	Link    string  `xpath_col:"link" xpath:".//a/@href" xpath_required:"false"`
+	Missing string  `xpath_col:"no such column"`
+	Ignored string  `xpath:"-" xpath_col:"notes"`
+}
+
+func TestTable(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Rows []priceRow `xpath:"//table[@id='prices']" xpath_table:"true"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testTable), &a))
+	asrt.Equal([]priceRow{
+		{Product: "Apple", Price: 1.25, InStock: true, Link: "/apple"},
+		{Product: "Banana", Price: 0.5, InStock: false, Link: "/banana"},
+		{Product: "Cherry", InStock: true},
+	}, a.Rows)
+}
+
+type hookedRow struct {
+	Product string
+	Price   float64 `xpath_col:"price, $" xpath:"./text()" xpath_default:"0.5"`
+	Link    string  `xpath_col:"link" xpath:".//a/@href"`
+	Cells   int     `xpath:"-"`
+	Total   string  `xpath:"-"`
+}
+
+func (r *hookedRow) BeforeUnmarshalHTML(doc *Document) error {
+	r.Cells = doc.Find("./td").Length()
+	return nil
+}
+
+func (r *hookedRow) AfterUnmarshalHTML() error {
+	r.Total = fmt.Sprintf("%s=%.2f", r.Product, r.Price)
+	return nil
+}
+
+func (r *hookedRow) Validate() error {
+	if r.Price > 1 {
+		return errors.New("too expensive")
+	}
+	return nil
+}
+
+func TestTableRowHooks(t *testing.T) {
+	asrt := assert.New(t)
+
+	header := `<table><tr><th>Product</th><th>Price, $</th><th>Link</th></tr>`
+
+	// Rows get the hooks, defaults and required checks of other structs
+	var a struct {
+		Rows []hookedRow `xpath:"//table" xpath_table:"true"`
+	}
+	asrt.NoError(Unmarshal([]byte(header+`
+		<tr><td>Apple</td><td>0.75</td><td><a href="/apple">view</a></td></tr>
+		<tr><td>Banana</td><td></td><td><a href="/banana">view</a></td></tr>
+	</table>`), &a))
+	asrt.Equal([]hookedRow{
+		{Product: "Apple", Price: 0.75, Link: "/apple", Cells: 3, Total: "Apple=0.75"},
+		{Product: "Banana", Price: 0.5, Link: "/banana", Cells: 3, Total: "Banana=0.50"},
+	}, a.Rows)
+
+	err := Unmarshal([]byte(header+`<tr><td>Cherry</td><td>0.5</td><td>-</td></tr></table>`), &a)
+	asrt.True(errors.Is(err, ErrNodeNotFound))
+
+	err = Unmarshal([]byte(header+`<tr><td>Melon</td><td>3</td><td><a href="/melon">view</a></td></tr></table>`), &a)
+	asrt.True(errors.Is(err, ErrValidation))
+	asrt.Contains(err.Error(), "Rows[0]")
+}
+
+func TestTableHeaderInBody(t *testing.T) {
+	asrt := assert.New(t)
+
+	type part struct {
+		Name string
+		Qty  int
+	}
+
+	var a struct {
+		Parts []*part `xpath:"//table[@id='headless-thead']" xpath_table:"true"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testTable), &a))
+	asrt.Equal([]*part{{Name: "Bolt", Qty: 10}, {Name: "Nut", Qty: 20}}, a.Parts)
+}
+
+func TestTableHeaderTags(t *testing.T) {
+	asrt := assert.New(t)
+
+	type row struct {
+		Product string  `th:"product"`
+		Price   float64 `th:"Price, $"`
+		Notes   string  `th:"Notes"`
+		Extra   string  `th:"Discount"`
+	}
+
+	var a struct {
+		Rows []row `xpath:"//table[@id='prices']"`
+	}
+	asrt.NoError(Unmarshal([]byte(testTable), &a))
+	asrt.Equal([]row{
+		{Product: "Apple", Price: 1.25, Notes: "fresh"},
+		{Product: "Banana", Price: 0.5},
+		{Product: "Cherry", Notes: "seasonal"},
+	}, a.Rows)
+
+	var b struct {
+		Rows []struct {
+			Price float64 `th:"Price, $" xpath_col:"Cost"`
+		} `xpath:"//table[@id='prices']"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(testTable), &b), ErrInvalidTag))
+
+	// The th tag follows WithTagName
+	var c struct {
+		Rows []struct {
+			Product string `html_th:"product"`
+			Ignored string `th:"Notes"`
+		} `html:"//table[@id='prices']"`
+	}
+	asrt.NoError(UnmarshalWithOptions([]byte(testTable), &c, WithTagName("html")))
+	asrt.Len(c.Rows, 3)
+	asrt.Equal("Apple", c.Rows[0].Product)
+	asrt.Equal("", c.Rows[0].Ignored)
+}
+
+func TestTableErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Rows []string `xpath:"//table[@id='prices']" xpath_table:"true"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(testTable), &a))
+	asrt.Equal(ErrTableNeedsStructs, checkErr(asrt, e.Err).Reason)
+
+	var b struct {
+		Rows []struct {
+			Product int
+		} `xpath:"//table[@id='prices']" xpath_table:"true"`
+	}
+	err := Unmarshal([]byte(testTable), &b)
+	asrt.Error(err)
+	asrt.Contains(err.Error(), "Rows[0].Product")
+	asrt.Contains(err.Error(), `"Apple"`)
+}
@@ -0,0 +1,192 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+// blockElements are the elements that start and end on their own line in the
+// text returned by InnerText.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Body: true, atom.Caption: true, atom.Dd: true, atom.Details: true,
+	atom.Dialog: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true, atom.Footer: true,
+	atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hgroup: true, atom.Hr: true, atom.Html: true, atom.Legend: true,
+	atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.Option: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Summary: true, atom.Table: true, atom.Tbody: true, atom.Tfoot: true,
+	atom.Thead: true, atom.Tr: true, atom.Ul: true,
+}
+
+// unrenderedElements are the elements whose content is left out of the text
+// returned by InnerText, as browsers don't render it.
+var unrenderedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Noscript: true, atom.Script: true, atom.Style: true,
+	atom.Template: true,
+}
+
+// OwnText returns the text of the selected nodes without the text of their
+// descendants, e.g. the price in <span>9.99 <small>USD</small></span>.
+func (doc *Document) OwnText() string {
+	var b strings.Builder
+	for _, n := range doc.Nodes {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			continue
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				b.WriteString(c.Data)
+			}
+		}
+	}
+	return b.String()
+}
+
+// InnerText returns the text of the selection the way a browser renders it:
+// block elements and <br> start new lines, paragraphs are separated by a
+// blank line, table cells by tabs, and the whitespace of inline content is
+// collapsed except in <pre>. Scripts, styles and elements hidden by an
+// attribute or an inline style are left out, see IsHidden.
+func (doc *Document) InnerText() string {
+	return innerText(doc, defaultAttrGetter)
+}
+
+// innerText is InnerText reading the hidden and style attributes with attr.
+func innerText(doc *Document, attr AttrGetter) string {
+	w := innerTextWriter{attr: attr}
+	for _, n := range doc.Nodes {
+		w.lineBreak(1)
+		w.walk(n)
+	}
+	return w.b.String()
+}
+
+// innerTextWriter builds the text of InnerText. Separators are kept pending
+// until the next word so that none is written at the ends or twice.
+type innerTextWriter struct {
+	attr   AttrGetter
+	b      strings.Builder
+	breaks int
+	tab    bool
+	space  bool
+	pre    int
+}
+
+func (w *innerTextWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.walk(c)
+		}
+		return
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.breaks++
+		return
+	case atom.Td, atom.Th:
+		if prevElementSibling(n) != nil {
+			w.tab = true
+		}
+	case atom.P:
+		w.lineBreak(2)
+	case atom.Pre, atom.Textarea:
+		w.pre++
+		defer func() { w.pre-- }()
+	}
+	if blockElements[n.DataAtom] {
+		w.lineBreak(1)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (unrenderedElements[c.DataAtom] || isHidden(c, w.attr)) {
+			continue
+		}
+		w.walk(c)
+	}
+
+	switch {
+	case n.DataAtom == atom.P:
+		w.lineBreak(2)
+	case blockElements[n.DataAtom]:
+		w.lineBreak(1)
+	}
+}
+
+// lineBreak makes sure at least n newlines come before the next word.
+func (w *innerTextWriter) lineBreak(n int) {
+	if w.breaks < n {
+		w.breaks = n
+	}
+}
+
+func (w *innerTextWriter) text(s string) {
+	if w.pre > 0 {
+		if s != "" {
+			w.flush()
+			w.b.WriteString(s)
+		}
+		return
+	}
+
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		w.space = w.space || s != ""
+		return
+	}
+	if isSpace(s[0]) {
+		w.space = true
+	}
+	for i, word := range words {
+		if i > 0 {
+			w.space = true
+		}
+		w.flush()
+		w.b.WriteString(word)
+	}
+	w.space = isSpace(s[len(s)-1])
+}
+
+// flush writes the pending separator, if any text was written before it.
+func (w *innerTextWriter) flush() {
+	if w.b.Len() > 0 {
+		switch {
+		case w.breaks > 0:
+			w.b.WriteString(strings.Repeat("\n", w.breaks))
+		case w.tab:
+			w.b.WriteByte('\t')
+		case w.space:
+			w.b.WriteByte(' ')
+		}
+	}
+	w.breaks, w.tab, w.space = 0, false, false
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+func prevElementSibling(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOwnText(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<ul><li>9.99 <small>USD</small> each</li><li>5 <small>EUR</small></li></ul>`)
+
+	asrt.Equal("9.99  each", doc.Find("//li[1]").OwnText())
+	asrt.Equal("9.99  each5 ", doc.Find("//li").OwnText())
+	asrt.Equal("", doc.Find("//ul").OwnText())
+	asrt.Equal("USD", doc.Find("(//small)[1]/text()").OwnText())
+	asrt.Equal("", (&Document{}).OwnText())
+}
+
+func TestInnerText(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<html><head><title>Page</title></head><body>
+		<article>
+			<h1>  The   title </h1>
+			<p>First <b>bold</b>
+			paragraph.</p><p>Second<br>line</p>
+			<div>Block<span hidden>hidden</span></div><div style="display: none">None</div>
+			<script>var x = 1;</script>
+			<pre>  keep
+  this</pre>
+			<table><tr><th>Name</th><th>Price</th></tr><tr><td>A</td><td>1</td></tr></table>
+			<ul><li>one</li><li>two <i>and</i> a half</li></ul>
+		</article>
+	</body></html>`)
+
+	asrt.Equal("The title\n\nFirst bold paragraph.\n\nSecond\nline\n\nBlock\n  keep\n  this\n"+
+		"Name\tPrice\nA\t1\none\ntwo and a half", doc.Find("//article").InnerText())
+	asrt.Equal("First bold paragraph.\n\nSecond\nline", doc.Find("//p").InnerText())
+	asrt.Equal("bold", doc.Find("//b").InnerText())
+	asrt.Equal("var x = 1;", doc.Find("//script").InnerText())
+	asrt.NotContains(doc.InnerText(), "Page")
+	asrt.Equal("", (&Document{}).InnerText())
+}
+
+func TestTextModes(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<div class="price">9.99 <small>USD</small></div>
+		<div class="body"><p>One</p><p>Two <b>bold</b></p></div>`)
+
+	var a struct {
+		Price float64 `xpath:"//div[@class='price']" xpath_mode:"owntext"`
+		Body  string  `xpath:"//div[@class='body']" xpath_mode:"innertext"`
+		Text  string  `xpath:"//div[@class='body']" xpath_mode:"text"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal(9.99, a.Price)
+	asrt.Equal("One\n\nTwo bold", a.Body)
+	asrt.Equal("OneTwo bold", a.Text)
+
+	var b struct {
+		Price string `xpath:"//div" xpath_attr:"class" xpath_mode:"owntext"`
+	}
+	asrt.True(errors.Is(Unmarshal(page, &b), ErrInvalidTag))
+}
@@ -0,0 +1,129 @@
+package goxtag
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// unmarshalTime parses the value of the matched nodes with the xpath_time_layout
+// layout (RFC 3339 by default).
+func (d *decodeState) unmarshalTime(doc *Document, v reflect.Value, tag xpathTag) error {
+	layout := tag.timeLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return d.unmarshalParsed(doc, v, tag, func(s string) (interface{}, error) {
+		return time.Parse(layout, s)
+	})
+}
+
+// unmarshalDuration parses the value of the matched nodes with parseDuration.
+func (d *decodeState) unmarshalDuration(doc *Document, v reflect.Value, tag xpathTag) error {
+	return d.unmarshalParsed(doc, v, tag, func(s string) (interface{}, error) {
+		return parseDuration(s)
+	})
+}
+
+// unmarshalParsed sets v to what parse returns for the value of the matched
+// nodes. Empty values leave the field untouched and values of optional fields
+// that fail to parse are left as zero, unless the field is strict.
+func (d *decodeState) unmarshalParsed(doc *Document, v reflect.Value, tag xpathTag, parse func(string) (interface{}, error)) error {
+	str, err := d.value(doc, v, tag)
+	if err != nil {
+		return err
+	}
+	if str == "" {
+		d.unset = true
+		return nil
+	}
+
+	val, err := parse(str)
+	if err != nil {
+		if !tag.required && !tag.strict {
+			d.note(tag.tag, fmt.Sprintf("value %q left as zero: %v", str, err))
+			d.unset = true
+			return nil
+		}
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTypeConversion,
+			XPath:  tag.tag,
+			Err:    err,
+			Val:    str,
+			Pos:    d.position(doc),
+		}
+	}
+	v.Set(reflect.ValueOf(val).Convert(v.Type()))
+	return nil
+}
+
+// parseDuration parses Go durations ("90s", "1h30m") as well as the ISO 8601
+// durations found in microdata and JSON-LD ("PT1H30M", "P1DT2H"). Years and
+// months are rejected since their length is not fixed.
+func parseDuration(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "P") && !strings.HasPrefix(s, "-P") {
+		return time.ParseDuration(s)
+	}
+
+	invalid := fmt.Errorf("invalid ISO 8601 duration %q", s)
+	neg := strings.HasPrefix(s, "-")
+	rest := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "P")
+	if rest == "" {
+		return 0, invalid
+	}
+
+	var total time.Duration
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime || len(rest) == 1 {
+				return 0, invalid
+			}
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+
+		i := strings.IndexFunc(rest, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i <= 0 {
+			return 0, invalid
+		}
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, invalid
+		}
+
+		var unit time.Duration
+		switch {
+		case !inTime && rest[i] == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && rest[i] == 'D':
+			unit = 24 * time.Hour
+		case inTime && rest[i] == 'H':
+			unit = time.Hour
+		case inTime && rest[i] == 'M':
+			unit = time.Minute
+		case inTime && rest[i] == 'S':
+			unit = time.Second
+		default:
+			return 0, invalid
+		}
+		total += time.Duration(n * float64(unit))
+		rest = rest[i+1:]
+	}
+
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
@@ -0,0 +1,87 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const timePage = `<html><body>
+<span class="published">2021-03-14</span>
+<time datetime="2021-03-14T15:09:26Z">yesterday</time>
+<span class="updated">soon</span>
+</body></html>`
+
+func TestTime(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Published time.Time `xpath:"//span[@class='published']" xpath_time_layout:"2006-01-02"`
+		DateTime  time.Time `xpath:"//time" xpath_attr:"datetime"`
+		Missing   time.Time `xpath:"//span[@class='missing']" xpath_required:"false"`
+		Updated   time.Time `xpath:"//span[@class='updated']" xpath_required:"false"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(timePage), &a))
+	asrt.Equal(time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC), a.Published)
+	asrt.Equal(time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC), a.DateTime)
+	asrt.True(a.Missing.IsZero())
+	asrt.True(a.Updated.IsZero())
+
+	var b struct {
+		Updated time.Time `xpath:"//span[@class='updated']"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(timePage), &b)).unwind()
+	asrt.Equal(ErrTypeConversion, e.chain[len(e.chain)-1].Reason)
+	asrt.Equal("soon", e.chain[len(e.chain)-1].Val)
+
+	var c struct {
+		Dates time.Time `xpath:"//span"`
+	}
+	e = checkErr(asrt, Unmarshal([]byte(timePage), &c)).unwind()
+	asrt.Equal(ErrMultipleNodes, e.chain[len(e.chain)-1].Reason)
+}
+
+func TestDuration(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Go      time.Duration `xpath:"//span[@class='go']"`
+		ISO     time.Duration `xpath:"//meta[@itemprop='duration']" xpath_attr:"content"`
+		Invalid time.Duration `xpath:"//span[@class='invalid']" xpath_required:"false"`
+	}
+
+	page := `<html><body>
+<span class="go">1h30m</span>
+<meta itemprop="duration" content="PT1M33S">
+<span class="invalid">ages</span>
+</body></html>`
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal(90*time.Minute, a.Go)
+	asrt.Equal(93*time.Second, a.ISO)
+	asrt.Zero(a.Invalid)
+}
+
+func TestParseDuration(t *testing.T) {
+	asrt := assert.New(t)
+
+	for s, want := range map[string]time.Duration{
+		"90s":       90 * time.Second,
+		"PT1H30M":   90 * time.Minute,
+		"P1DT2H":    26 * time.Hour,
+		"P2W":       14 * 24 * time.Hour,
+		"PT0.5S":    500 * time.Millisecond,
+		"-PT10M":    -10 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"PT1H0M30S": time.Hour + 30*time.Second,
+	} {
+		d, err := parseDuration(s)
+		asrt.NoError(err, s)
+		asrt.Equal(want, d, s)
+	}
+
+	for _, s := range []string{"P", "PT", "P1M", "P1Y", "PT1D", "P1H", "PTH", "P1DT", "ages"} {
+		_, err := parseDuration(s)
+		asrt.Error(err, s)
+	}
+}
@@ -0,0 +1,77 @@
+package goxtag
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// TraceEvent tells how the value of a struct field was looked up, see
+// WithTraceFunc.
+type TraceEvent struct {
+	// Field is the path of the field from the decoded value, e.g.
+	// "Items[2].Price"
+	Field string
+	// Selector is the selector of the field, or the column of table cells
+	Selector string
+	// Matched is the number of nodes the selector matched
+	Matched int
+	// Text is the raw value the field is converted from, for fields holding
+	// a single value
+	Text string
+}
+
+// WithTraceFunc calls f for every struct field looked up while decoding,
+// after its selector is evaluated and before its value is converted, to
+// debug why a field comes back empty or fails to parse.
+func WithTraceFunc(f func(TraceEvent)) Option {
+	return func(c *config) {
+		c.trace = f
+	}
+}
+
+// WithTrace writes a line per struct field looked up while decoding to w,
+// with the path of the field, its selector, the number of nodes it matched
+// and the raw text of the value, see WithTraceFunc.
+func WithTrace(w io.Writer) Option {
+	return WithTraceFunc(func(e TraceEvent) {
+		fmt.Fprintf(w, "%s: %s matched %d", e.Field, e.Selector, e.Matched)
+		if e.Text != "" {
+			fmt.Fprintf(w, ", text %q", e.Text)
+		}
+		fmt.Fprintln(w)
+	})
+}
+
+// pushPath appends elem to the path of the field being decoded when tracing
+// and returns the function restoring it.
+func (d *decodeState) pushPath(elem string) func() {
+	if d.trace == nil {
+		return func() {}
+	}
+	n := len(d.path)
+	d.path = append(d.path, elem)
+	return func() { d.path = d.path[:n] }
+}
+
+// traceField reports the nodes selected for a field of type t, if tracing.
+func (d *decodeState) traceField(sel *Document, t reflect.Type, tag xpathTag, selector string) {
+	if d.trace == nil {
+		return
+	}
+	e := TraceEvent{
+		Field:    strings.TrimPrefix(strings.Join(d.path, ""), "."),
+		Selector: selector,
+		Matched:  sel.Length(),
+	}
+	if !sel.IsEmpty() && (isLiteralType(TypeDeref(t)) || isNullType(t)) {
+		// The value is read again for the trace only, without the notes
+		// reading it adds, which come with its decoding
+		report := d.report
+		d.report = nil
+		e.Text, _ = d.valFunc(tag)(sel)
+		d.report = report
+	}
+	d.trace(e)
+}
@@ -0,0 +1,61 @@
+package goxtag
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	asrt := assert.New(t)
+
+	type item struct {
+		Name  string  `xpath:"./b"`
+		Price float64 `xpath:"./i" xpath_required:"false"`
+	}
+	var a struct {
+		Title string     `xpath:"//h1"`
+		Items []item     `xpath:"//li"`
+		Rows  []priceRow `xpath:"//table[@id='prices']" xpath_table:"true"`
+		Note  *string    `xpath:"//p[@class='note']" xpath_required:"false"`
+	}
+
+	page := `<h1> Shop </h1><ul><li><b>Apple</b><i>1.5</i></li><li><b>Pear</b></li></ul>` + testTable
+
+	var events []TraceEvent
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &a, WithTraceFunc(func(e TraceEvent) {
+		events = append(events, e)
+	})))
+
+	asrt.Equal(TraceEvent{Field: "Title", Selector: "//h1", Matched: 1, Text: "Shop"}, events[0])
+	asrt.Equal(TraceEvent{Field: "Items", Selector: "//li", Matched: 2}, events[1])
+	asrt.Equal(TraceEvent{Field: "Items[0].Name", Selector: "./b", Matched: 1, Text: "Apple"}, events[2])
+	asrt.Equal(TraceEvent{Field: "Items[1].Price", Selector: "./i", Matched: 0}, events[5])
+	asrt.Contains(events, TraceEvent{Field: "Rows[1].Price", Selector: `column "price, $"`, Matched: 1, Text: "0.5"})
+	asrt.Contains(events, TraceEvent{Field: "Rows[0].Link", Selector: `column "link" .//a/@href`, Matched: 1, Text: "/apple"})
+	asrt.Equal(TraceEvent{Field: "Note", Selector: "//p[@class='note']", Matched: 0}, events[len(events)-1])
+
+	var buf bytes.Buffer
+	var b struct {
+		Title string `xpath:"//h1"`
+		Items []item `xpath:"//li"`
+	}
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &b, WithTrace(&buf)))
+	asrt.Equal(`Title: //h1 matched 1, text "Shop"
+Items: //li matched 2
+Items[0].Name: ./b matched 1, text "Apple"
+Items[0].Price: ./i matched 1, text "1.5"
+Items[1].Name: ./b matched 1, text "Pear"
+Items[1].Price: ./i matched 0
+`, buf.String())
+
+	// Tracing doesn't repeat the notes of the values it shows
+	var c struct {
+		Stock  int `xpath:"//h1" xpath_regex:"\\d+" xpath_required:"false"`
+		Report DecodeReport
+	}
+	buf.Reset()
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &c, WithTrace(&buf)))
+	asrt.Len(c.Report.Notes, 1)
+	asrt.Contains(buf.String(), `Stock: //h1 matched 1`)
+}
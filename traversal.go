@@ -0,0 +1,166 @@
+package goxtag
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Parent returns the parent elements of the nodes of the selection.
+func (doc *Document) Parent() *Document {
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		if p := n.Parent; p != nil && p.Type == html.ElementNode {
+			return []*html.Node{p}
+		}
+		return nil
+	})
+}
+
+// Parents returns the ancestor elements of the nodes of the selection.
+func (doc *Document) Parents() *Document {
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		var nodes []*html.Node
+		for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+			nodes = append(nodes, p)
+		}
+		return nodes
+	})
+}
+
+// Children returns the child elements of the nodes of the selection.
+func (doc *Document) Children() *Document {
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		var nodes []*html.Node
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				nodes = append(nodes, c)
+			}
+		}
+		return nodes
+	})
+}
+
+// Next returns the element following each node of the selection.
+func (doc *Document) Next() *Document {
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		for s := n.NextSibling; s != nil; s = s.NextSibling {
+			if s.Type == html.ElementNode {
+				return []*html.Node{s}
+			}
+		}
+		return nil
+	})
+}
+
+// Prev returns the element preceding each node of the selection.
+func (doc *Document) Prev() *Document {
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			if s.Type == html.ElementNode {
+				return []*html.Node{s}
+			}
+		}
+		return nil
+	})
+}
+
+// Siblings returns the sibling elements of the nodes of the selection, not
+// including the nodes themselves.
+func (doc *Document) Siblings() *Document {
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		if n.Parent == nil {
+			return nil
+		}
+		var nodes []*html.Node
+		for s := n.Parent.FirstChild; s != nil; s = s.NextSibling {
+			if s != n && s.Type == html.ElementNode {
+				nodes = append(nodes, s)
+			}
+		}
+		return nodes
+	})
+}
+
+// Closest returns, for each node of the selection, the node itself or its
+// closest ancestor matched by the selector, which is evaluated from the root
+// of the document (e.g. "//li" or "//div[@class='card']"). It panics if the
+// selector is invalid, like Find.
+func (doc *Document) Closest(selector string) *Document {
+	matches := matcher(selector)
+	return doc.mapNodes(func(n *html.Node) []*html.Node {
+		for a := n; a != nil; a = a.Parent {
+			if matches(a) {
+				return []*html.Node{a}
+			}
+		}
+		return nil
+	})
+}
+
+// Filter returns the nodes of the selection matched by the selector, which is
+// evaluated from the root of the document like in Closest.
+func (doc *Document) Filter(selector string) *Document {
+	matches := matcher(selector)
+	var nodes []*html.Node
+	for _, n := range doc.Nodes {
+		if matches(n) {
+			nodes = append(nodes, n)
+		}
+	}
+	return NewDocumentWithNodes(nodes)
+}
+
+// Each calls f with every node of the selection wrapped into a Document.
+func (doc *Document) Each(f func(int, *Document)) *Document {
+	for i := range doc.Nodes {
+		f(i, doc.Eq(i))
+	}
+	return doc
+}
+
+// Map returns what f returns for every node of the selection wrapped into a
+// Document.
+func (doc *Document) Map(f func(int, *Document) string) []string {
+	res := make([]string, 0, len(doc.Nodes))
+	for i := range doc.Nodes {
+		res = append(res, f(i, doc.Eq(i)))
+	}
+	return res
+}
+
+// matcher returns a function reporting whether a node is matched by the
+// selector evaluated from the root of its document. The matches of each root
+// are only looked up once.
+func matcher(selector string) func(*html.Node) bool {
+	matched := map[*html.Node]map[*html.Node]bool{}
+	return func(n *html.Node) bool {
+		root := n
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		set, ok := matched[root]
+		if !ok {
+			set = map[*html.Node]bool{}
+			for _, m := range NewDocumentWithNode(root).Find(selector).Nodes {
+				set[m] = true
+			}
+			matched[root] = set
+		}
+		return set[n]
+	}
+}
+
+// mapNodes returns the nodes f returns for every node of the selection,
+// without duplicates and in document order.
+func (doc *Document) mapNodes(f func(*html.Node) []*html.Node) *Document {
+	var nodes []*html.Node
+	seen := map[*html.Node]bool{}
+	for _, n := range doc.Nodes {
+		for _, m := range f(n) {
+			if !seen[m] {
+				seen[m] = true
+				nodes = append(nodes, m)
+			}
+		}
+	}
+	sortInDocumentOrder(nodes)
+	return NewDocumentWithNodes(nodes)
+}
@@ -0,0 +1,78 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+// ids returns the id attributes of the nodes of the selection.
+func ids(doc *Document) []string {
+	var res []string
+	for i := range doc.Nodes {
+		id, _ := doc.Eq(i).Attr("id")
+		res = append(res, id)
+	}
+	return res
+}
+
+func TestTraversal(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<div id="card" class="card">
+		<ul id="list">
+			<li id="a">a</li>
+			<li id="b">b <span id="s">s</span></li>
+			<li id="c">c</li>
+		</ul>
+		<p id="p">p</p>
+	</div>`)
+
+	b := doc.Find("//li[@id='b']")
+	span := doc.Find("//span")
+	items := doc.Find("//li")
+
+	asrt.Equal([]string{"list"}, ids(b.Parent()))
+	asrt.Equal([]string{"list"}, ids(items.Parent()))
+	asrt.Equal([]string{"", "", "card", "list", "b"}, ids(span.Parents()))
+	asrt.Equal([]string{"a", "b", "c"}, ids(doc.Find("//ul").Children()))
+	asrt.Equal([]string{"c"}, ids(b.Next()))
+	asrt.Equal([]string{"a"}, ids(b.Prev()))
+	asrt.Equal([]string{"b", "c"}, ids(items.Next()))
+	asrt.True(doc.Find("//li[@id='c']").Next().IsEmpty())
+	asrt.Equal([]string{"a", "c"}, ids(b.Siblings()))
+	asrt.Equal([]string{"a", "b", "c"}, ids(doc.Find("//li[@id='a']").Siblings().Siblings()))
+
+	asrt.Equal([]string{"b"}, ids(span.Closest("//li")))
+	asrt.Equal([]string{"b"}, ids(b.Closest("//li")))
+	asrt.Equal([]string{"card"}, ids(items.Closest("//div[@class='card']")))
+	asrt.True(span.Closest("//table").IsEmpty())
+	asrt.Panics(func() { span.Closest("//li[") })
+
+	asrt.True((&Document{}).Parent().IsEmpty())
+	asrt.True((&Document{}).Children().IsEmpty())
+}
+
+func TestEachMapFilter(t *testing.T) {
+	asrt := assert.New(t)
+
+	doc := parseTestDocument(t, `<ul><li id="a" class="x">1</li><li id="b">2</li><li id="c" class="x">3</li></ul>`)
+	items := doc.Find("//li")
+
+	var seen []string
+	asrt.Equal(items, items.Each(func(i int, item *Document) {
+		asrt.Equal(1, item.Length())
+		seen = append(seen, item.Text())
+	}))
+	asrt.Equal([]string{"1", "2", "3"}, seen)
+
+	asrt.Equal([]string{"0:a", "1:b", "2:c"}, items.Map(func(i int, item *Document) string {
+		id, _ := item.Attr("id")
+		return strconv.Itoa(i) + ":" + id
+	}))
+	asrt.Empty((&Document{}).Map(func(int, *Document) string { return "" }))
+
+	asrt.Equal([]string{"a", "c"}, ids(items.Filter("//li[@class='x']")))
+	asrt.True(items.Filter("//p").IsEmpty())
+	asrt.Panics(func() { items.Filter("//li[") })
+}
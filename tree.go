@@ -0,0 +1,114 @@
+package goxtag
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"reflect"
+	"strings"
+)
+
+// Keys of the maps decoded with xpath_mode:"tree"
+const (
+	treeTag      = "tag"
+	treeAttrs    = "attrs"
+	treeChildren = "children"
+	treeText     = "text"
+)
+
+var treeMapType = reflect.TypeOf(map[string]interface{}(nil))
+
+// nodeTree returns the generic representation of the subtree of n decoded
+// with xpath_mode:"tree": elements become maps holding their name under
+// "tag", their attributes (if any) under "attrs", their child elements and
+// trimmed non-blank texts (if any) under "children" and their whole trimmed
+// text under "text". Text nodes become strings, other nodes are dropped.
+func nodeTree(n *html.Node) interface{} {
+	switch n.Type {
+	case html.TextNode:
+		if text := strings.TrimSpace(n.Data); text != "" {
+			return text
+		}
+		return nil
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return nil
+	}
+
+	m := map[string]interface{}{
+		treeText: strings.TrimSpace(NewDocumentWithNode(n).Text()),
+	}
+	if n.Type == html.ElementNode {
+		m[treeTag] = n.Data
+	}
+	if len(n.Attr) > 0 {
+		attrs := make(map[string]interface{}, len(n.Attr))
+		for _, a := range n.Attr {
+			attrs[a.Key] = a.Val
+		}
+		m[treeAttrs] = attrs
+	}
+	var children []interface{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if child := nodeTree(c); child != nil {
+			children = append(children, child)
+		}
+	}
+	if children != nil {
+		m[treeChildren] = children
+	}
+	return m
+}
+
+// unmarshalTree sets v, an interface{} or a map[string]interface{}, to the
+// tree of the matched nodes, see nodeTree. An interface{} gets a slice of
+// trees when several nodes are matched, a map fails like other single values.
+func (d *decodeState) unmarshalTree(doc *Document, v reflect.Value, tag xpathTag) error {
+	t := v.Type()
+	if doc.IsEmpty() {
+		return nil
+	}
+
+	if t.Kind() == reflect.Interface {
+		if doc.Length() == 1 {
+			if tree := nodeTree(doc.Nodes[0]); tree != nil {
+				v.Set(reflect.ValueOf(tree))
+			}
+			return nil
+		}
+		trees := make([]interface{}, 0, doc.Length())
+		for _, n := range doc.Nodes {
+			if tree := nodeTree(n); tree != nil {
+				trees = append(trees, tree)
+			}
+		}
+		v.Set(reflect.ValueOf(trees))
+		return nil
+	}
+
+	if doc.Length() > 1 {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrMultipleNodes,
+			XPath:  tag.tag,
+			Pos:    d.position(doc),
+		}
+	}
+	tree, ok := nodeTree(doc.Nodes[0]).(map[string]interface{})
+	if !ok {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTypeConversion,
+			XPath:  tag.tag,
+			Err:    errors.New("only element and document nodes can be decoded into a map"),
+			Pos:    d.position(doc),
+		}
+	}
+	v.Set(reflect.ValueOf(tree))
+	return nil
+}
+
+// isTreeType reports whether fields of type t can be decoded with
+// xpath_mode:"tree".
+func isTreeType(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0 || t == treeMapType
+}
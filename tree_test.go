@@ -0,0 +1,70 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTreeMode(t *testing.T) {
+	asrt := assert.New(t)
+
+	const page = `<div class="post"><h2 id="t">Title</h2>
+		<p>Some <b>bold</b> text<!-- note --></p></div><ul><li>a</li><li>b</li></ul>`
+
+	var a struct {
+		Post  map[string]interface{}   `xpath:"//div" xpath_mode:"tree"`
+		Title interface{}              `xpath:"//h2" xpath_mode:"tree"`
+		Items interface{}              `xpath:"//li" xpath_mode:"tree"`
+		List  []map[string]interface{} `xpath:"//li" xpath_mode:"tree"`
+		None  interface{}              `xpath:"//table" xpath_mode:"tree" xpath_required:"false"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+
+	title := map[string]interface{}{
+		"tag":      "h2",
+		"attrs":    map[string]interface{}{"id": "t"},
+		"children": []interface{}{"Title"},
+		"text":     "Title",
+	}
+	asrt.Equal(map[string]interface{}{
+		"tag":   "div",
+		"attrs": map[string]interface{}{"class": "post"},
+		"children": []interface{}{
+			title,
+			map[string]interface{}{
+				"tag": "p",
+				"children": []interface{}{
+					"Some",
+					map[string]interface{}{"tag": "b", "children": []interface{}{"bold"}, "text": "bold"},
+					"text",
+				},
+				"text": "Some bold text",
+			},
+		},
+		"text": "Title\n\t\tSome bold text",
+	}, a.Post)
+	asrt.Equal(title, a.Title)
+
+	li := func(text string) map[string]interface{} {
+		return map[string]interface{}{"tag": "li", "children": []interface{}{text}, "text": text}
+	}
+	asrt.Equal([]interface{}{li("a"), li("b")}, a.Items)
+	asrt.Equal([]map[string]interface{}{li("a"), li("b")}, a.List)
+	asrt.Nil(a.None)
+
+	var b struct {
+		Title string `xpath:"//h2" xpath_mode:"tree"`
+	}
+	asrt.Equal(ErrTypeConversion, checkErr(asrt, Unmarshal([]byte(page), &b)).Reason)
+
+	var many struct {
+		Item map[string]interface{} `xpath:"//li" xpath_mode:"tree"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(page), &many), ErrMultipleNodes))
+
+	var c struct {
+		ID interface{} `xpath:"//h2" xpath_attr:"id" xpath_mode:"tree"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &c)).Reason)
+}
@@ -0,0 +1,166 @@
+package goxtag
+
+import (
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
+	"reflect"
+)
+
+// TypeDecoder unmarshals documents into values of a single type. The struct
+// tags of the type and of every struct it nests are parsed, checked and their
+// selectors compiled once by NewTypeDecoder, so none of it is repeated per
+// document. A TypeDecoder is safe for concurrent use.
+type TypeDecoder struct {
+	t      reflect.Type
+	config config
+	plans  map[reflect.Type][]fieldPlan
+}
+
+// fieldPlan is the parsed tag of a struct field.
+type fieldPlan struct {
+	tag xpathTag
+}
+
+// NewTypeDecoder prepares a TypeDecoder for values of type t (pointers are
+// dereferenced). Invalid tags and selectors are reported here rather than
+// while decoding.
+func NewTypeDecoder(t reflect.Type, opts ...Option) (*TypeDecoder, error) {
+	td := &TypeDecoder{
+		t:      TypeDeref(t),
+		config: newConfig(opts),
+		plans:  map[reflect.Type][]fieldPlan{},
+	}
+
+	d := &decodeState{config: td.config}
+	if err := d.planType(td.t, td.plans); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// Type returns the type the decoder was prepared for.
+func (td *TypeDecoder) Type() reflect.Type {
+	return td.t
+}
+
+// Unmarshal is like UnmarshalWithOptions with the options the decoder was
+// created with. Values of other types than the prepared one are decoded as
+// well, just without the precompiled tags.
+func (td *TypeDecoder) Unmarshal(bs []byte, v interface{}) error {
+	root, src, err := td.config.parseBytes(bs)
+
+	if err != nil {
+		return err
+	}
+
+	d := &decodeState{config: td.config, plans: td.plans, source: src}
+	return d.unmarshal(NewDocumentWithNode(root), v)
+}
+
+// UnmarshalSelection is like UnmarshalSelectionWithOptions with the options
+// the decoder was created with.
+func (td *TypeDecoder) UnmarshalSelection(doc *Document, v interface{}) error {
+	d := &decodeState{config: td.config, plans: td.plans}
+	return d.unmarshal(doc, v)
+}
+
+// planType parses the tags of t and of the structs it is made of into plans.
+func (d *decodeState) planType(t reflect.Type, plans map[reflect.Type][]fieldPlan) error {
+	t = TypeDeref(t)
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan, reflect.Map:
+		return d.planType(t.Elem(), plans)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	if _, ok := plans[t]; ok || isScalarType(t) || d.converter(t) != nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	plan := make([]fieldPlan, t.NumField())
+	plans[t] = plan
+
+	for i := range plan {
+		field := t.Field(i)
+
+		tag, err := d.newXpathTag(field)
+		if err == nil {
+			err = tag.compile()
+		}
+		if err == nil && tag.requiredIf != "" {
+			_, err = requiredIfField(t, i, tag)
+		}
+		if err != nil {
+			return &CannotUnmarshalError{
+				V:        reflect.New(t).Elem(),
+				Reason:   ErrInvalidTag,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: field.Name,
+			}
+		}
+		plan[i] = fieldPlan{tag: tag}
+
+		if tag.tag == ignoreTag {
+			continue
+		}
+		if err := d.planType(field.Type, plans); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldTag returns the parsed tag of the i-th field of struct type t.
+func (d *decodeState) fieldTag(t reflect.Type, i int) (xpathTag, error) {
+	if plan, ok := d.plans[t]; ok {
+		return plan[i].tag, nil
+	}
+	return d.newXpathTag(t.Field(i))
+}
+
+// compile compiles the selector of the tag so that findByTag doesn't have to,
+// and checks that the other selectors of the tag are valid.
+func (tag *xpathTag) compile() error {
+	if tag.tag != "" && tag.tag != ignoreTag && !tag.isMicrodata() && tag.jsonLD == "" {
+		var err error
+		switch {
+		case hasPlaceholders(tag.tag):
+			// Only checked, the values of the placeholders come with each run
+			var sel string
+			sel, err = expandPlaceholders(tag.tag, tag.css, func(string) (string, bool) {
+				return "x", true
+			})
+			if err == nil {
+				err = (&xpathTag{tag: sel, css: tag.css}).compile()
+			}
+		case tag.css:
+			tag.cssSel, err = cascadia.ParseGroup(tag.tag)
+		case hasVars(tag.tag):
+			// Only checked, the values of the variables come with each run
+			var sel string
+			if sel, err = bindVars(tag.tag, nil); err == nil {
+				_, err = xpath.Compile(sel)
+			}
+		default:
+			tag.expr, err = xpath.Compile(tag.tag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Evaluated like the main selector, see decodeState.findAll
+	for _, sel := range []string{tag.sortBy, tag.child, tag.defWhen, tag.label, tag.key, tag.value, tag.valueKey} {
+		if sel == "" {
+			continue
+		}
+		if err := (&xpathTag{tag: sel}).compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package goxtag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type typeDecoderItem struct {
+	Name  string `xpath:"./span[@class='name']"`
+	Price int    `css:"span.price"`
+}
+
+type typeDecoderPage struct {
+	Title  string            `xpath:"meta:title"`
+	Items  []typeDecoderItem `xpath:"//li"`
+	First  *typeDecoderItem  `xpath:"(//li)[1]"`
+	Note   string            `xpath:"//p" xpath_default:"none"`
+	Hidden string            `xpath:"-"`
+}
+
+func TestTypeDecoder(t *testing.T) {
+	asrt := assert.New(t)
+
+	td, err := NewTypeDecoder(reflect.TypeOf(&typeDecoderPage{}))
+	asrt.NoError(err)
+	asrt.Equal(reflect.TypeOf(typeDecoderPage{}), td.Type())
+	asrt.Len(td.plans, 2)
+
+	pages := []string{
+		`<title>One</title><ul><li><span class="name">a</span><span class="price">1</span></li></ul>`,
+		`<title>Two</title><ul><li><span class="name">b</span><span class="price">2</span></li>` +
+			`<li><span class="name">c</span><span class="price">3</span></li></ul><p>sale</p>`,
+	}
+
+	var a typeDecoderPage
+	asrt.NoError(td.Unmarshal([]byte(pages[0]), &a))
+	asrt.Equal("One", a.Title)
+	asrt.Equal([]typeDecoderItem{{"a", 1}}, a.Items)
+	asrt.Equal("none", a.Note)
+
+	var wg sync.WaitGroup
+	results := make([]typeDecoderPage, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = td.Unmarshal([]byte(pages[1]), &results[i])
+		}(i)
+	}
+	wg.Wait()
+	for i, b := range results {
+		asrt.NoError(errs[i])
+		asrt.Equal("Two", b.Title)
+		asrt.Equal([]typeDecoderItem{{"b", 2}, {"c", 3}}, b.Items)
+		asrt.Equal(&typeDecoderItem{"b", 2}, b.First)
+		asrt.Equal("sale", b.Note)
+	}
+
+	// Other types are decoded without the precompiled tags
+	var c struct {
+		Title string `xpath:"meta:title"`
+	}
+	asrt.NoError(td.UnmarshalSelection(parseTestDocument(t, pages[0]), &c))
+	asrt.Equal("One", c.Title)
+}
+
+func TestTypeDecoderInvalidTags(t *testing.T) {
+	asrt := assert.New(t)
+
+	type badXPath struct {
+		Name string `xpath:"//li[@class="`
+	}
+	_, err := NewTypeDecoder(reflect.TypeOf(badXPath{}))
+	e := checkErr(asrt, err)
+	asrt.Equal(ErrInvalidTag, e.Reason)
+	asrt.Equal("Name", e.FldOrIdx)
+
+	type badCSS struct {
+		Items []struct {
+			Name string `css:"li["`
+		} `xpath:"//ul"`
+	}
+	_, err = NewTypeDecoder(reflect.TypeOf(badCSS{}))
+	e = checkErr(asrt, err)
+	asrt.Equal(ErrInvalidTag, e.Reason)
+
+	type badOption struct {
+		Name string `xpath:"//li" xpath_required:"maybe"`
+	}
+	_, err = NewTypeDecoder(reflect.TypeOf(badOption{}))
+	asrt.Error(err)
+
+	type badLabel struct {
+		Name      string `xpath:"//li" xpath_label:"preceding::"`
+		NameLabel string
+	}
+	_, err = NewTypeDecoder(reflect.TypeOf(badLabel{}))
+	asrt.Error(err)
+}
@@ -1,21 +1,33 @@
 package goxtag
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
-// All "Reason" fields within CannotUnmarshalError will be constants and part of
-// this list
-const (
-	nonPointer             = "non-pointer value"
-	nodeNotFound           = "node not found in document"
-	nilDestination         = "destination is nil"
-	arrayLengthMismatch    = "array length does not match document elements found"
-	customUnmarshalError   = "a custom Unmarshaler implementation threw an error"
-	typeConversionError    = "a type conversion error occurred"
-	mapIsNotSupportedError = "map type is not currently supported"
-	multipleNodesDetected  = "multiple nodes detected for selector"
+// All "Reason" fields within CannotUnmarshalError will be one of these errors,
+// so the cause of an error can be checked with errors.Is.
+var (
+	ErrNonPointer          = errors.New("non-pointer value")
+	ErrNodeNotFound        = errors.New("node not found in document")
+	ErrNilDestination      = errors.New("destination is nil")
+	ErrNonChannel          = errors.New("destination is not a channel values can be sent on")
+	ErrNilDocument         = errors.New("resulting document was nil")
+	ErrArrayLengthMismatch = errors.New("array length does not match document elements found")
+	ErrCustomUnmarshal     = errors.New("a custom Unmarshaler implementation threw an error")
+	ErrTypeConversion      = errors.New("a type conversion error occurred")
+	ErrMapNotSupported     = errors.New("map fields need an xpath_key or xpath_linkmap tag")
+	ErrMultipleNodes       = errors.New("multiple nodes detected for selector")
+	ErrLabelFieldMissing   = errors.New("no string field to hold the label")
+	ErrEnumValueNotAllowed = errors.New("value is not one of the allowed enum values")
+	ErrTableNeedsStructs   = errors.New("table rows can only be decoded into structs")
+	ErrInvalidTag          = errors.New("invalid struct tag")
+	ErrInvalidURL          = errors.New("value is not a valid URL")
+	ErrLimitExceeded       = errors.New("document exceeds a resource limit")
+	ErrSelectorTimeout     = errors.New("selector evaluation exceeded its time or node budget")
+	ErrValidation          = errors.New("a Validate method rejected the decoded value")
 )
 
 // CannotUnmarshalError represents an error returned by the goqxtag Unmarshaler
@@ -25,14 +37,19 @@ type CannotUnmarshalError struct {
 	Val      string
 	FldOrIdx interface{}
 	V        reflect.Value
-	Reason   string
+	Reason   error
 	XPath    string
+	// Pos locates the node holding Val in the source of the document. It is
+	// only known for documents parsed by the call that failed, see
+	// WithSource.
+	Pos Position
 }
 
 // This type is a mid-level abstraction to help understand the error printing logic
 type errChain struct {
 	chain []*CannotUnmarshalError
 	val   string
+	pos   Position
 	tail  error
 }
 
@@ -64,6 +81,14 @@ func (e errChain) tPath() string {
 	return nest
 }
 
+// reason returns the message of the Reason of e.
+func (e *CannotUnmarshalError) reason() string {
+	if e.Reason == nil {
+		return ""
+	}
+	return e.Reason.Error()
+}
+
 func (e errChain) last() *CannotUnmarshalError {
 	return e.chain[len(e.chain)-1]
 }
@@ -92,7 +117,7 @@ func (e errChain) Error() string {
 			v.Type(),
 			e.tPath(),
 			t,
-			last.Reason,
+			last.reason(),
 		)
 	}
 
@@ -100,6 +125,10 @@ func (e errChain) Error() string {
 		msg += fmt.Sprintf(" tag: '%s'", last.XPath)
 	}
 
+	if e.pos.IsValid() {
+		msg += fmt.Sprintf(" at %s", e.pos)
+	}
+
 	// If a generic error was reported elsewhere, report its message last
 	if e.tail != nil {
 		msg = msg + ": " + e.tail.Error()
@@ -118,6 +147,9 @@ func (e *CannotUnmarshalError) unwind() *errChain {
 		if e.Val != "" {
 			str.val = e.Val
 		}
+		if e.Pos.IsValid() {
+			str.pos = e.Pos
+		}
 
 		// Terminal error was of type *CannotUnmarshalError and had no children
 		if e.Err == nil {
@@ -138,3 +170,64 @@ func (e *CannotUnmarshalError) unwind() *errChain {
 func (e *CannotUnmarshalError) Error() string {
 	return e.unwind().Error()
 }
+
+// Unwrap returns the error that caused e, if any.
+func (e *CannotUnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the Reason of e, so that errors.Is finds the
+// reason of any error along the chain.
+func (e *CannotUnmarshalError) Is(target error) bool {
+	return e.Reason != nil && e.Reason == target
+}
+
+// UnmarshalErrors lists every failure met while decoding with
+// WithCollectErrors, each one with the path of the field, its selector and
+// the offending value.
+type UnmarshalErrors []error
+
+func (e UnmarshalErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d errors occurred:\n\t* %s", len(e), strings.Join(msgs, "\n\t* "))
+}
+
+// Unwrap returns the errors of the list.
+func (e UnmarshalErrors) Unwrap() []error {
+	return e
+}
+
+// flattenErrors splits an error holding nested UnmarshalErrors into one error
+// per failure, each with the whole chain of fields leading to it.
+func flattenErrors(err error) []error {
+	switch e := err.(type) {
+	case UnmarshalErrors:
+		var errs []error
+		for _, err := range e {
+			errs = append(errs, flattenErrors(err)...)
+		}
+		return errs
+	case *CannotUnmarshalError:
+		if e.Err == nil {
+			return []error{e}
+		}
+		inner := flattenErrors(e.Err)
+		if len(inner) == 1 && inner[0] == e.Err {
+			return []error{e}
+		}
+		errs := make([]error, 0, len(inner))
+		for _, err := range inner {
+			c := *e
+			c.Err = err
+			errs = append(errs, &c)
+		}
+		return errs
+	}
+	return []error{err}
+}
@@ -1,10 +1,16 @@
 package goxtag
 
 import (
-	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
 	"golang.org/x/net/html"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -13,36 +19,715 @@ type Unmarshaler interface {
 	UnmarshalHTML([]*html.Node) error
 }
 
-type valFunc func(doc *Document) string
+// UnmarshalerAttr is the interface implemented by types that decode
+// themselves from an attribute, like encoding/xml's UnmarshalerAttr. It is
+// used instead of Unmarshaler when the selector of a field ends in /@name or
+// the attribute is read with xpath_attr.
+type UnmarshalerAttr interface {
+	UnmarshalHTMLAttr(attr html.Attribute) error
+}
+
+// BeforeUnmarshaler is implemented by structs that need to be set up before
+// their fields are decoded. BeforeUnmarshalHTML gets the nodes the struct is
+// decoded from, and an error stops the decoding of the struct.
+type BeforeUnmarshaler interface {
+	BeforeUnmarshalHTML(doc *Document) error
+}
+
+// AfterUnmarshaler is implemented by structs that compute derived fields or
+// validate themselves once their fields are decoded. AfterUnmarshalHTML is
+// only called if all the fields were decoded without error.
+type AfterUnmarshaler interface {
+	AfterUnmarshalHTML() error
+}
+
+// Validator is implemented by structs checking their decoded fields against
+// each other, e.g. that a sale price is below the regular one. Validate is
+// called once the struct is decoded, after AfterUnmarshalHTML, and its error
+// is reported with ErrValidation along the path of the struct.
+type Validator interface {
+	Validate() error
+}
+
+// valFunc reads the raw value of a field from its matched nodes. It fails on
+// values that can't be read, such as those not matching the xpath_regex of a
+// required field, and then returns the offending value along with the error.
+type valFunc func(doc *Document) (string, error)
+
+// decodeState holds the state of a single unmarshaling run.
+type decodeState struct {
+	// report collects notes for the innermost struct having a DecodeReport
+	report *DecodeReport
+	// field is the name of the struct field being decoded
+	field string
+	// ctx is checked between fields and elements, see UnmarshalContext
+	ctx context.Context
+	// plans holds the tags parsed up front by a TypeDecoder
+	plans map[reflect.Type][]fieldPlan
+	// source is the UTF-8 source of the document when the run parsed it,
+	// indexed on demand to locate nodes in errors
+	source      []byte
+	sourceIndex *sourceIndex
+	// root is the top of the tree of the decoded document
+	root *html.Node
+	// base is the base URL of the document once looked up, see documentBase
+	base *resolvedBase
+	// path is the path of the field being decoded, only kept when tracing
+	path []string
+	// unset tells that the last scalar value was left untouched because it
+	// was empty or failed to parse, see decodePtr
+	unset bool
+
+	config
+}
+
+// AttrGetter reads the value of the named attribute of a node and reports
+// whether the node has it. A custom AttrGetter can be set with
+// WithAttrGetter or Decoder.SetAttrGetter to rewrite attribute values (e.g. unescape them)
+// before they are converted.
+type AttrGetter func(node *html.Node, name string) (string, bool)
+
+func defaultAttrGetter(node *html.Node, name string) (string, bool) {
+	return getAttributeValue(name, node)
+}
+
+// attr reads an attribute value with the configured AttrGetter.
+func (d *decodeState) attr(node *html.Node, name string) (string, bool) {
+	if d.attrGetter != nil {
+		return d.attrGetter(node, name)
+	}
+	return defaultAttrGetter(node, name)
+}
 
 type xpathTag struct {
 	tag      string
+	css      bool
 	required bool
+	join     string
+	joined   bool
+	flatten  bool
+	child    string
+	// offset and limit select a window of the matched nodes of collections,
+	// a limit of 0 meaning all of them
+	offset int
+	limit  int
+	// unique drops duplicate nodes or values, see uniqueNode and uniqueValue
+	unique string
+	// sortBy orders the matched nodes of collections by the value it selects
+	// relative to each of them, as numbers if sortNumeric is set
+	sortBy      string
+	sortNumeric bool
+	sortDesc    bool
+
+	// defaults for fields whose selector matches nothing, see applyDefault
+	def        string
+	hasDef     bool
+	defWhen    string
+	defWhenVal string
+	hasDefWhen bool
+
+	linkMap    string
+	skipHidden bool
+	label      string
+	srcset     bool
+	attr       string
+	enum       []string
+	skipErrors bool
+	table      bool
+	col        string
+	key        string
+	value      string
+	valueKey   string
+	timeLayout string
+	regex      *regexp.Regexp
+	strict     bool
+	mode       string
+	space      string
+	split      string
+	numFmt     *numberFormat
+	truthy     []string
+	falsy      []string
+	input      string
+	itemProp   string
+	itemType   string
+	jsonLD     string
+	json       bool
+	requiredIf string
+
+	// selectors compiled up front by a TypeDecoder, see xpathTag.compile
+	expr   *xpath.Expr
+	cssSel cascadia.SelectorGroup
 }
 
 const (
-	tagName     = "xpath"
-	ignoreTag   = "-"
-	requiredTag = "xpath_required"
+	tagName       = "xpath"
+	cssTagName    = "css"
+	thTagName     = "th"
+	ignoreTag     = "-"
+	requiredTag   = "xpath_required"
+	requiredIfTag = "xpath_required_if"
+	joinTag       = "xpath_join"
+	flattenTag    = "xpath_flatten"
+	offsetTag     = "xpath_offset"
+	limitTag      = "xpath_limit"
+	uniqueTag     = "xpath_unique"
+	sortByTag     = "xpath_sort_by"
+	sortTag       = "xpath_sort"
+	childTag      = "xpath_child"
+	defaultTag    = "xpath_default"
+	defWhenTag    = "xpath_default_when"
+	linkMapTag    = "xpath_linkmap"
+	skipHiddenTag = "xpath_skip_hidden"
+	labelTag      = "xpath_label"
+	srcsetTag     = "xpath_srcset"
+	attrTag       = "xpath_attr"
+	enumTag       = "xpath_enum"
+	onErrorTag    = "xpath_on_error"
+	skipErrorsTag = "xpath_skip_errors"
+	tableTag      = "xpath_table"
+	colTag        = "xpath_col"
+	keyTag        = "xpath_key"
+	valueTag      = "xpath_value"
+	valueKeyTag   = "xpath_value_key"
+	timeLayoutTag = "xpath_time_layout"
+	regexTag      = "xpath_regex"
+	strictTag     = "xpath_strict"
+	modeTag       = "xpath_mode"
+	spaceTag      = "xpath_space"
+	splitTag      = "xpath_split"
+	numFmtTag     = "xpath_numfmt"
+	trueTag       = "xpath_true"
+	falseTag      = "xpath_false"
+	inputTag      = "xpath_input"
+	jsonTag       = "xpath_json"
+
+	onErrorFail = "fail"
+	onErrorSkip = "skip"
+
+	modeText      = "text"
+	modeHTML      = "html"
+	modeOuterHTML = "outerhtml"
+	modeURL       = "url"
+	modeOwnText   = "owntext"
+	modeInnerText = "innertext"
+	modeRaw       = "raw"
+	modeTree      = "tree"
+
+	// uniqueNode drops the matched nodes with the same markup as a previous
+	// one, uniqueValue the elements decoded into the same value
+	uniqueNode  = "node"
+	uniqueValue = "value"
+
+	spaceTrim     = "trim"
+	spaceCollapse = "collapse"
+	spacePreserve = "preserve"
+
+	labelFieldSuffix = "Label"
+
+	defaultChildSelector = "./*"
+
+	titleShorthand = "meta:title"
+	metaShorthand  = "meta:"
 )
 
 var (
-	textVal valFunc = func(doc *Document) string {
-		return strings.TrimSpace(doc.Text())
+	errBothXPathAndCSS = errors.New("both xpath and css selectors are set")
+	errEmptyValue      = errors.New("empty value")
+
+	textVal valFunc = func(doc *Document) (string, error) {
+		return doc.Text(), nil
 	}
-	indexRegEx = regexp.MustCompile(`\[\d+\]$`)
+	ownTextVal valFunc = func(doc *Document) (string, error) {
+		return doc.OwnText(), nil
+	}
+	indexRegEx   = regexp.MustCompile(`\[\d+\]$`)
+	attrOptRegEx = regexp.MustCompile(`,\s*attr=([^\s,'"\[\]()]+)\s*$`)
+	attrSelRegEx = regexp.MustCompile(`/@[^/\[\]()]+$`)
 )
 
-func (tag *xpathTag) valFunc() valFunc {
-	return textVal
+// joinVal returns a valFunc joining the values of every node with sep.
+func joinVal(val valFunc, sep string) valFunc {
+	return func(doc *Document) (string, error) {
+		vals := make([]string, 0, doc.Length())
+		for i := range doc.Nodes {
+			s, err := val(doc.Eq(i))
+			if err != nil {
+				return s, err
+			}
+			vals = append(vals, s)
+		}
+		return strings.Join(vals, sep), nil
+	}
+}
+
+// innerTextVal returns the rendered text of the matched nodes, reading
+// attributes with the configured AttrGetter.
+func (d *decodeState) innerTextVal(doc *Document) (string, error) {
+	return innerText(doc, d.attr), nil
+}
+
+// innerHTMLVal renders the children of the matched nodes.
+func innerHTMLVal(doc *Document) (string, error) {
+	// Rendering into a buffer only fails on invalid trees
+	val, _ := doc.OutputHtml(false)
+	return val, nil
+}
+
+// outerHTMLVal renders the matched nodes themselves.
+func outerHTMLVal(doc *Document) (string, error) {
+	val, _ := doc.Html()
+	return val, nil
+}
+
+// attrVal returns a valFunc reading the value of the named attribute of the
+// first node.
+func (d *decodeState) attrVal(name string) valFunc {
+	return func(doc *Document) (string, error) {
+		if doc.IsEmpty() {
+			return "", nil
+		}
+		val, _ := d.attr(doc.Nodes[0], name)
+		return val, nil
+	}
+}
+
+// spaceVal returns a valFunc normalizing the whitespace of the value of val
+// as set by xpath_space.
+func spaceVal(val valFunc, space string) valFunc {
+	switch space {
+	case spaceTrim:
+		return func(doc *Document) (string, error) {
+			s, err := val(doc)
+			return strings.TrimSpace(s), err
+		}
+	case spaceCollapse:
+		return func(doc *Document) (string, error) {
+			s, err := val(doc)
+			return strings.Join(strings.Fields(s), " "), err
+		}
+	}
+	return val
+}
+
+// regexVal returns a valFunc extracting the first capture group of the
+// xpath_regex match (or the whole match if there are no groups) from the
+// value of val. Values that don't match fail required fields and become
+// empty in optional ones.
+func (d *decodeState) regexVal(val valFunc, tag xpathTag) valFunc {
+	return func(doc *Document) (string, error) {
+		s, err := val(doc)
+		if err != nil {
+			return s, err
+		}
+		m := tag.regex.FindStringSubmatch(s)
+		switch {
+		case m == nil:
+			if s == "" {
+				return "", nil
+			}
+			if tag.required {
+				return s, fmt.Errorf("value doesn't match %s", tag.regex)
+			}
+			d.note(tag.tag, fmt.Sprintf("value %q doesn't match %s", s, tag.regex))
+			return "", nil
+		case len(m) > 1:
+			return strings.TrimSpace(m[1]), nil
+		}
+		return strings.TrimSpace(m[0]), nil
+	}
+}
+
+// expandShorthand replaces the "meta:title" and "meta:<name>" tag shorthands
+// with the selectors they stand for. Both read as names with a meta prefix,
+// which match nothing unless the prefix is registered with WithNamespaces, in
+// which case they are left as they are.
+func (d *decodeState) expandShorthand(tag string) string {
+	if _, ok := d.namespaces[strings.TrimSuffix(metaShorthand, ":")]; ok {
+		return tag
+	}
+	switch {
+	case tag == titleShorthand:
+		return titleSelector
+	case strings.HasPrefix(tag, metaShorthand):
+		return metaSelector(strings.TrimPrefix(tag, metaShorthand))
+	}
+	return tag
+}
+
+// splitAttrOption splits the ",attr=<name>" option off the end of a selector.
+func splitAttrOption(sel string) (string, string) {
+	m := attrOptRegEx.FindStringSubmatchIndex(sel)
+	if m == nil {
+		return sel, ""
+	}
+	return strings.TrimSpace(sel[:m[0]]), sel[m[2]:m[3]]
+}
+
+// newXpathTag reads the xpath tag and its companion options of a struct field.
+func (d *decodeState) newXpathTag(field reflect.StructField) (xpathTag, error) {
+	tags := fieldTags{tag: field.Tag, name: d.tagName}
+	tag := xpathTag{
+		tag:      d.expandShorthand(tags.Get(tagName)),
+		required: true,
+		strict:   d.strict,
+	}
+
+	if css := tags.Get(cssTagName); css != "" {
+		if tag.tag != "" {
+			return tag, errBothXPathAndCSS
+		}
+		tag.tag = css
+		tag.css = true
+	}
+
+	for _, key := range []string{itemPropTagName, itemTypeTagName} {
+		val := field.Tag.Get(key)
+		if val == "" {
+			continue
+		}
+		if tag.tag != "" {
+			return tag, fmt.Errorf("%s cannot be combined with another selector", key)
+		}
+		if key == itemPropTagName {
+			tag.itemProp = val
+		} else {
+			tag.itemType = val
+		}
+		tag.tag = pseudoSelector(key, val)
+	}
+
+	if typ := field.Tag.Get(jsonLDTagName); typ != "" {
+		if tag.tag != "" {
+			return tag, fmt.Errorf("%s cannot be combined with another selector", jsonLDTagName)
+		}
+		tag.jsonLD = typ
+		tag.tag = pseudoSelector(jsonLDTagName, typ)
+	}
+
+	tag.tag, tag.attr = splitAttrOption(tag.tag)
+
+	required := tags.Get(requiredTag)
+	if required != "" {
+		var err error
+		tag.required, err = strconv.ParseBool(required)
+		if err != nil {
+			return tag, err
+		}
+	}
+	if tag.requiredIf = tags.Get(requiredIfTag); tag.requiredIf != "" && required != "" {
+		return tag, fmt.Errorf("%s cannot be combined with %s", requiredIfTag, requiredTag)
+	}
+
+	tag.join, tag.joined = tags.Lookup(joinTag)
+
+	if flatten := tags.Get(flattenTag); flatten != "" {
+		var err error
+		tag.flatten, err = strconv.ParseBool(flatten)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	if offset := tags.Get(offsetTag); offset != "" {
+		var err error
+		tag.offset, err = strconv.Atoi(offset)
+		if err != nil || tag.offset < 0 {
+			return tag, fmt.Errorf("%s must be a non-negative number, got %q", offsetTag, offset)
+		}
+	}
+	if limit := tags.Get(limitTag); limit != "" {
+		var err error
+		tag.limit, err = strconv.Atoi(limit)
+		if err != nil || tag.limit <= 0 {
+			return tag, fmt.Errorf("%s must be a positive number, got %q", limitTag, limit)
+		}
+	}
+
+	switch unique := tags.Get(uniqueTag); unique {
+	case "", "false":
+	case "true", uniqueValue:
+		// Decoded values are only compared while filling slices
+		if TypeDeref(field.Type).Kind() != reflect.Slice {
+			return tag, fmt.Errorf("%s %q is only supported on slices, use %q for arrays and channels", uniqueTag, unique, uniqueNode)
+		}
+		tag.unique = uniqueValue
+	case uniqueNode:
+		tag.unique = uniqueNode
+	default:
+		return tag, fmt.Errorf("%s must be %q, %q or %q, got %q", uniqueTag, "true", uniqueValue, uniqueNode, unique)
+	}
+
+	tag.sortBy = tags.Get(sortByTag)
+	if flags := tags.Get(sortTag); flags != "" {
+		if tag.sortBy == "" {
+			return tag, fmt.Errorf("%s needs %s", sortTag, sortByTag)
+		}
+		for _, flag := range strings.Split(flags, ",") {
+			switch flag = strings.TrimSpace(flag); flag {
+			case "string", "asc":
+			case "number":
+				tag.sortNumeric = true
+			case "desc":
+				tag.sortDesc = true
+			default:
+				return tag, fmt.Errorf("%s flags must be %q, %q, %q or %q, got %q", sortTag, "string", "number", "asc", "desc", flag)
+			}
+		}
+	}
+
+	tag.child = tags.Get(childTag)
+	if tag.child == "" {
+		tag.child = defaultChildSelector
+	}
+
+	tag.def, tag.hasDef = tags.Lookup(defaultTag)
+	tag.linkMap = tags.Get(linkMapTag)
+	tag.label = tags.Get(labelTag)
+	if attr := tags.Get(attrTag); attr != "" {
+		if tag.attr != "" && tag.attr != attr {
+			return tag, fmt.Errorf("%s %q conflicts with the attr=%s selector option", attrTag, attr, tag.attr)
+		}
+		tag.attr = attr
+	}
+
+	tag.col = tags.Get(colTag)
+	if th := field.Tag.Get(thTagKey(tags.name)); th != "" {
+		if tag.col != "" && tag.col != th {
+			return tag, fmt.Errorf("%s %q conflicts with %s %q", thTagKey(tags.name), th, colTag, tag.col)
+		}
+		tag.col = th
+	}
+	tag.key = tags.Get(keyTag)
+	tag.value = tags.Get(valueTag)
+	tag.valueKey = tags.Get(valueKeyTag)
+	tag.timeLayout = tags.Get(timeLayoutTag)
+	tag.split = tags.Get(splitTag)
+	tag.input = tags.Get(inputTag)
+
+	if spec := tags.Get(numFmtTag); spec != "" || d.numFmt != "" {
+		if spec == "" {
+			spec = d.numFmt
+		}
+		var err error
+		tag.numFmt, err = parseNumberFormat(spec)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	switch tag.space = tags.Get(spaceTag); tag.space {
+	case "", spaceTrim, spaceCollapse, spacePreserve:
+	default:
+		return tag, fmt.Errorf("%s must be %q, %q or %q, got %q", spaceTag, spaceTrim, spaceCollapse, spacePreserve, tag.space)
+	}
+
+	switch tag.mode = tags.Get(modeTag); tag.mode {
+	case "", modeText, modeURL:
+	case modeHTML, modeOuterHTML, modeOwnText, modeInnerText, modeRaw, modeTree:
+		if tag.attr != "" {
+			return tag, fmt.Errorf("%s %q cannot be combined with an attribute", modeTag, tag.mode)
+		}
+	default:
+		return tag, fmt.Errorf("%s must be %q, %q, %q, %q, %q, %q, %q or %q, got %q", modeTag,
+			modeText, modeOwnText, modeInnerText, modeRaw, modeHTML, modeOuterHTML, modeURL, modeTree, tag.mode)
+	}
+
+	if strict := tags.Get(strictTag); strict != "" {
+		var err error
+		tag.strict, err = strconv.ParseBool(strict)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	if table := tags.Get(tableTag); table != "" {
+		var err error
+		tag.table, err = strconv.ParseBool(table)
+		if err != nil {
+			return tag, err
+		}
+	} else {
+		tag.table = hasHeaderFields(field.Type, tags.name)
+	}
+
+	if re := tags.Get(regexTag); re != "" {
+		var err error
+		tag.regex, err = regexp.Compile(re)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	if enum := tags.Get(enumTag); enum != "" {
+		tag.enum = strings.Split(enum, "|")
+	}
+	if truthy := tags.Get(trueTag); truthy != "" {
+		tag.truthy = strings.Split(truthy, "|")
+	}
+	if falsy := tags.Get(falseTag); falsy != "" {
+		tag.falsy = strings.Split(falsy, "|")
+	}
+
+	switch onError := tags.Get(onErrorTag); onError {
+	case "", onErrorFail:
+	case onErrorSkip:
+		tag.skipErrors = true
+	default:
+		return tag, fmt.Errorf("%s must be %q or %q, got %q", onErrorTag, onErrorFail, onErrorSkip, onError)
+	}
+	// xpath_skip_errors:"true" is a shorthand for xpath_on_error:"skip"
+	if skip := tags.Get(skipErrorsTag); skip != "" {
+		skipErrors, err := strconv.ParseBool(skip)
+		if err != nil {
+			return tag, err
+		}
+		if tags.Get(onErrorTag) != "" && skipErrors != tag.skipErrors {
+			return tag, fmt.Errorf("%s and %s disagree", skipErrorsTag, onErrorTag)
+		}
+		tag.skipErrors = skipErrors
+	}
+
+	if srcset := tags.Get(srcsetTag); srcset != "" {
+		var err error
+		tag.srcset, err = strconv.ParseBool(srcset)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	if js := tags.Get(jsonTag); js != "" {
+		var err error
+		tag.json, err = strconv.ParseBool(js)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	if skip := tags.Get(skipHiddenTag); skip != "" {
+		var err error
+		tag.skipHidden, err = strconv.ParseBool(skip)
+		if err != nil {
+			return tag, err
+		}
+	}
+
+	if when := tags.Get(defWhenTag); when != "" {
+		i := strings.LastIndex(when, "=>")
+		if i < 0 {
+			return tag, fmt.Errorf("%s must look like \"<selector> => <value>\", got %q", defWhenTag, when)
+		}
+		tag.defWhen = strings.TrimSpace(when[:i])
+		tag.defWhenVal = strings.TrimSpace(when[i+2:])
+		tag.hasDefWhen = true
+	}
+
+	return tag, nil
+}
+
+func (tag *xpathTag) hasDefaults() bool {
+	return tag.hasDef || tag.hasDefWhen
+}
+
+// allows reports whether s is one of the xpath_enum values, if there are any.
+func (tag *xpathTag) allows(s string) bool {
+	if len(tag.enum) == 0 {
+		return true
+	}
+	for _, val := range tag.enum {
+		if s == val {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBoolWords reports whether the tag sets the xpath_true or xpath_false
+// vocabularies.
+func (tag *xpathTag) hasBoolWords() bool {
+	return len(tag.truthy) > 0 || len(tag.falsy) > 0
+}
+
+// boolVal reads s with the xpath_true and xpath_false vocabularies, compared
+// case-insensitively. Values in neither are false when only xpath_true is set
+// and true when only xpath_false is set; otherwise they are an error.
+func (tag *xpathTag) boolVal(s string) (bool, error) {
+	for _, word := range tag.truthy {
+		if strings.EqualFold(s, word) {
+			return true, nil
+		}
+	}
+	for _, word := range tag.falsy {
+		if strings.EqualFold(s, word) {
+			return false, nil
+		}
+	}
+	switch {
+	case len(tag.falsy) == 0:
+		return false, nil
+	case len(tag.truthy) == 0:
+		return true, nil
+	}
+	return false, fmt.Errorf("%q is neither one of %s nor of %s", s, trueTag, falseTag)
+}
+
+// valFunc returns the function extracting the raw value of a field from its
+// matched nodes before it is converted into the field type.
+func (d *decodeState) valFunc(tag xpathTag) valFunc {
+	val := textVal
+	space := spaceTrim
+	if tag.attr != "" {
+		val = d.attrVal(tag.attr)
+	} else if tag.itemProp != "" {
+		val = itemPropVal
+	}
+	switch tag.mode {
+	case modeHTML:
+		val, space = innerHTMLVal, spacePreserve
+	case modeOuterHTML:
+		val, space = outerHTMLVal, spacePreserve
+	case modeOwnText:
+		val = ownTextVal
+	case modeInnerText:
+		val = d.innerTextVal
+	case modeRaw:
+		val, space = d.rawTextVal(tag.tag), spacePreserve
+	}
+	if tag.space != "" {
+		space = tag.space
+	}
+	val = spaceVal(val, space)
+	if tag.regex != nil {
+		val = d.regexVal(val, tag)
+	}
+	if tag.joined {
+		return joinVal(val, tag.join)
+	}
+	return val
+}
+
+// value returns the raw value of the matched nodes of the field v, see
+// valFunc. Values that can't be read fail with ErrTypeConversion.
+func (d *decodeState) value(doc *Document, v reflect.Value, tag xpathTag) (string, error) {
+	str, err := d.valFunc(tag)(doc)
+	if err != nil {
+		return "", &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrTypeConversion,
+			XPath:  tag.tag,
+			Err:    err,
+			Val:    str,
+			Pos:    d.position(doc),
+		}
+	}
+	return str, nil
 }
 
 func (tag *xpathTag) hasIndex() bool {
-	return indexRegEx.MatchString(tag.tag)
+	return !tag.css && indexRegEx.MatchString(tag.tag)
 }
 
 func (tag *xpathTag) hasSuffix(s string) bool {
-	return strings.HasSuffix(tag.tag, s)
+	return !tag.css && strings.HasSuffix(tag.tag, s)
 }
 
 // Unmarshal takes a byte slice and a destination pointer to any
@@ -51,13 +736,7 @@ func (tag *xpathTag) hasSuffix(s string) bool {
 // CannotUnmarshalError, though an initial htmlquery error will pass through
 // directly.
 func Unmarshal(bs []byte, v interface{}) error {
-	root, err := html.Parse(bytes.NewReader(bs))
-
-	if err != nil {
-		return err
-	}
-
-	return UnmarshalSelection(NewDocumentWithNode(root), v)
+	return UnmarshalWithOptions(bs, v)
 }
 
 func wrapUnmErr(err error, v reflect.Value) error {
@@ -67,58 +746,164 @@ func wrapUnmErr(err error, v reflect.Value) error {
 
 	return &CannotUnmarshalError{
 		V:      v,
-		Reason: customUnmarshalError,
+		Reason: ErrCustomUnmarshal,
 		Err:    err,
 	}
 }
 
+// UnmarshalSelection unmarshals an already parsed document into the
+// destination pointer following the same rules as Unmarshal.
 func UnmarshalSelection(doc *Document, iface interface{}) error {
+	return UnmarshalSelectionWithOptions(doc, iface)
+}
+
+func (d *decodeState) unmarshal(doc *Document, iface interface{}) error {
 	v := reflect.ValueOf(iface)
 
 	// Must come before v.IsNil() else IsNil panics on NonPointer value
 	if v.Kind() != reflect.Ptr {
 		return &CannotUnmarshalError{
 			V:      v,
-			Reason: nonPointer,
+			Reason: ErrNonPointer,
 		}
 	}
 
 	if iface == nil || v.IsNil() {
 		return &CannotUnmarshalError{
 			V:      v,
-			Reason: nilDestination,
+			Reason: ErrNilDestination,
 		}
 	}
 
+	if !doc.IsEmpty() {
+		d.root = doc.Nodes[0]
+		for d.root.Parent != nil {
+			d.root = d.root.Parent
+		}
+	}
+	if d.coverage != nil {
+		d.coverage.roots = append(d.coverage.roots, doc.Nodes...)
+	}
+
 	u, v := indirect(v)
 
 	if u != nil {
 		return wrapUnmErr(u.UnmarshalHTML(doc.Nodes), v)
 	}
 
-	return unmarshalByType(doc, v, xpathTag{})
+	err := d.unmarshalByType(doc, v, xpathTag{})
+	if err != nil {
+		// Report cancellation as is rather than wrapped into the field errors
+		if cerr := d.canceled(); cerr != nil {
+			return cerr
+		}
+		if d.collectErrors {
+			return UnmarshalErrors(flattenErrors(err))
+		}
+	}
+	return err
 }
 
 func findByTag(doc *Document, tag xpathTag) (*Document, error) {
+	if tag.isMicrodata() {
+		return findMicrodata(doc, tag), nil
+	}
 	if tag.tag != "" {
-		return doc.Find(tag.tag), nil
+		switch {
+		case tag.expr != nil:
+			return doc.findAll(func(n *html.Node) []*html.Node {
+				return htmlquery.QuerySelectorAll(n, tag.expr)
+			}), nil
+		case tag.cssSel != nil:
+			return doc.findCSSSelector(tag.cssSel), nil
+		}
+		if tag.css {
+			return doc.findCSS(tag.tag)
+		}
+		return doc.FindErr(tag.tag)
 	}
 	return doc, nil
 }
 
 func findOneByTag(doc *Document, tag xpathTag) (*Document, error) {
+	if tag.isMicrodata() {
+		sel := findMicrodata(doc, tag)
+		if sel.Length() > 1 {
+			sel = sel.Eq(0)
+		}
+		return sel, nil
+	}
 	if tag.tag != "" {
+		if tag.expr != nil {
+			return doc.findFirst(func(n *html.Node) *html.Node {
+				return htmlquery.QuerySelector(n, tag.expr)
+			}), nil
+		}
+		if tag.css {
+			sel, err := doc.findCSS(tag.tag)
+			if err != nil {
+				return nil, err
+			}
+			if sel.Length() > 1 {
+				sel = sel.Eq(0)
+			}
+			return sel, nil
+		}
 		return doc.FindOne(tag.tag)
 	}
 	return doc, nil
 }
 
-func findForTypeByTag(doc *Document, v reflect.Value, tag xpathTag) (*Document, error) {
+// flattenChildren collects the nodes matched by the child selector in every
+// container node into a single selection, keeping container order.
+func (d *decodeState) flattenChildren(containers *Document, child string) (*Document, error) {
+	var nodes []*html.Node
+	for i := range containers.Nodes {
+		children, err := d.findAll(containers.Eq(i), child)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, children.Nodes...)
+	}
+	return NewDocumentWithNodes(nodes), nil
+}
+
+// visibleNodes drops the nodes that are hidden according to isHidden.
+func (d *decodeState) visibleNodes(doc *Document) *Document {
+	var nodes []*html.Node
+	for _, node := range doc.Nodes {
+		if !isHidden(node, d.attr) {
+			nodes = append(nodes, node)
+		}
+	}
+	return NewDocumentWithNodes(nodes)
+}
+
+// nodesWithAttr drops the nodes that don't have the named attribute, so that a
+// missing attribute is treated like a node that was not found.
+func (d *decodeState) nodesWithAttr(doc *Document, name string) *Document {
+	var nodes []*html.Node
+	for _, node := range doc.Nodes {
+		if _, ok := d.attr(node, name); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return NewDocumentWithNodes(nodes)
+}
+
+func (d *decodeState) findForTypeByTag(doc *Document, v reflect.Value, tag xpathTag) (*Document, error) {
 	var sel *Document
 	var err error
+	if len(d.params) > 0 {
+		if tag, err = d.expandParams(tag); err != nil {
+			return nil, err
+		}
+	}
 	hasIndex := tag.hasIndex()
 	hasTextSuffix := tag.hasSuffix("text()")
 	switch {
+	case (d.customQuery() || hasVars(tag.tag)) && tag.tag != "" && !tag.css && !tag.isMicrodata():
+		sel, err = d.query(doc, tag, hasIndex && !hasTextSuffix)
 	case hasIndex && !hasTextSuffix:
 		sel, err = findOneByTag(doc, tag)
 	default:
@@ -127,42 +912,282 @@ func findForTypeByTag(doc *Document, v reflect.Value, tag xpathTag) (*Document,
 	if err != nil {
 		return nil, err
 	}
+	// The multiple nodes check below looks at the unfiltered selection
+	all := sel
+
+	if tag.flatten {
+		sel, err = d.flattenChildren(sel, tag.child)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tag.skipHidden {
+		sel = d.visibleNodes(sel)
+	}
+
+	if tag.attr != "" {
+		sel = d.nodesWithAttr(sel, tag.attr)
+	}
 
 	t := v.Type()
 	//type may have custom Unmarshal, check unsupported types later
-	switch t.Kind() {
-	case reflect.Struct:
-		return sel, nil
-	case reflect.Slice:
-		return sel, nil
-	case reflect.Array:
-		return sel, nil
-	case reflect.Map:
-		return sel, nil
-	case reflect.Interface:
-		return sel, nil
-	case reflect.Ptr:
-		return sel, nil
-	default:
-		if hasIndex || hasTextSuffix {
+	if !isScalarType(t) && d.converter(TypeDeref(t)) == nil {
+		switch t.Kind() {
+		case reflect.Struct:
+			return sel, nil
+		case reflect.Slice, reflect.Array, reflect.Chan:
+			if tag.sortBy != "" {
+				if sel, err = d.sortNodes(sel, tag); err != nil {
+					return nil, err
+				}
+			}
+			return tag.window(sel), nil
+		case reflect.Map:
+			return sel, nil
+		case reflect.Interface:
+			return sel, nil
+		case reflect.Ptr:
 			return sel, nil
 		}
-		_sel, err := findByTag(doc, tag)
+	}
+
+	if hasIndex || hasTextSuffix || tag.joined {
+		return sel, nil
+	}
+	if all.Length() > 1 {
+		return nil, &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrMultipleNodes,
+			XPath:  tag.tag,
+		}
+	}
+	return sel, nil
+}
+
+// window returns the nodes of sel selected by the offset and limit of tag,
+// once the duplicates are dropped with xpath_unique:"node". With
+// xpath_unique:"value", the limit is applied by unmarshalSlice instead.
+func (tag *xpathTag) window(sel *Document) *Document {
+	if tag.unique == uniqueNode {
+		sel = uniqueNodes(sel)
+	}
+	if tag.offset == 0 && tag.limit == 0 {
+		return sel
+	}
+	start := tag.offset
+	if start > sel.Length() {
+		start = sel.Length()
+	}
+	end := sel.Length()
+	if tag.limit > 0 && tag.unique != uniqueValue && start+tag.limit < end {
+		end = start + tag.limit
+	}
+	return sel.Slice(start, end)
+}
+
+// sortNodes returns the nodes of sel stably sorted by the trimmed value the
+// xpath_sort_by selector of tag finds relative to each of them. With
+// numeric sorting, values that are not numbers come last.
+func (d *decodeState) sortNodes(sel *Document, tag xpathTag) (*Document, error) {
+	type sortKey struct {
+		node   *html.Node
+		str    string
+		num    float64
+		hasNum bool
+	}
+	keys := make([]sortKey, sel.Length())
+	for i, n := range sel.Nodes {
+		found, err := d.findOne(sel.Eq(i), tag.sortBy)
 		if err != nil {
 			return nil, err
 		}
-		if _sel.Length() > 1 {
-			return nil, &CannotUnmarshalError{
-				V:      v,
-				Reason: multipleNodesDetected,
-				XPath:  tag.tag,
+		keys[i] = sortKey{node: n, str: strings.TrimSpace(found.Text())}
+		if tag.sortNumeric {
+			num, err := strconv.ParseFloat(keys[i].str, 64)
+			keys[i].num, keys[i].hasNum = num, err == nil
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if tag.sortNumeric {
+			if a.hasNum != b.hasNum {
+				return a.hasNum
+			}
+			if !a.hasNum || a.num == b.num {
+				return false
 			}
+			return (a.num < b.num) != tag.sortDesc
 		}
-		return sel, nil
+		if a.str == b.str {
+			return false
+		}
+		return (a.str < b.str) != tag.sortDesc
+	})
+
+	nodes := make([]*html.Node, len(keys))
+	for i, k := range keys {
+		nodes[i] = k.node
 	}
+	return NewDocumentWithNodes(nodes), nil
 }
 
-func unmarshalByType(doc *Document, v reflect.Value, tag xpathTag) error {
+// findOne returns the first node an XPath sub-selector of a tag, e.g.
+// xpath_sort_by, matches relative to doc, see findAll.
+func (d *decodeState) findOne(doc *Document, selector string) (*Document, error) {
+	return d.findSelector(doc, selector, true)
+}
+
+// findAll returns the nodes an XPath selector other than the one of a tag,
+// e.g. xpath_child, matches relative to doc, evaluated like the main selector
+// with the params, variables, namespaces and guards of d.
+func (d *decodeState) findAll(doc *Document, selector string) (*Document, error) {
+	return d.findSelector(doc, selector, false)
+}
+
+func (d *decodeState) findSelector(doc *Document, selector string, one bool) (*Document, error) {
+	sub := xpathTag{tag: selector}
+	if len(d.params) > 0 {
+		var err error
+		if sub, err = d.expandParams(sub); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case d.customQuery() || hasVars(sub.tag):
+		return d.query(doc, sub, one)
+	case one:
+		return doc.FindOne(sub.tag)
+	}
+	return doc.FindErr(sub.tag)
+}
+
+// uniqueNodes drops the nodes of sel rendering to the same markup as a
+// previous one, e.g. a link repeated in the header and the footer.
+func uniqueNodes(sel *Document) *Document {
+	seen := make(map[string]bool, sel.Length())
+	nodes := make([]*html.Node, 0, sel.Length())
+	for i, n := range sel.Nodes {
+		markup, err := sel.Eq(i).OuterHtml()
+		if err == nil && seen[markup] {
+			continue
+		}
+		seen[markup] = true
+		nodes = append(nodes, n)
+	}
+	return NewDocumentWithNodes(nodes)
+}
+
+// valueSet records the values of a slice for xpath_unique:"value".
+type valueSet struct {
+	keys   map[interface{}]bool
+	values []reflect.Value
+}
+
+// add adds v, reporting false if an equal value was already added.
+func (s *valueSet) add(v reflect.Value) bool {
+	if hashable(v.Type()) {
+		key := v.Interface()
+		if s.keys == nil {
+			s.keys = map[interface{}]bool{}
+		}
+		if s.keys[key] {
+			return false
+		}
+		s.keys[key] = true
+		return true
+	}
+	for _, seen := range s.values {
+		if reflect.DeepEqual(seen.Interface(), v.Interface()) {
+			return false
+		}
+	}
+	s.values = append(s.values, v)
+	return true
+}
+
+// hashable reports whether values of t can be map keys: comparable types
+// holding no interfaces, whose dynamic values may not be comparable.
+func hashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return false
+	case reflect.Array:
+		return hashable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !hashable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return t.Comparable()
+}
+
+// selectedAttr returns the attribute a field is decoded from, if any: the one
+// read with xpath_attr or the one selected by a selector ending in /@name.
+func (d *decodeState) selectedAttr(doc *Document, tag xpathTag) (html.Attribute, bool) {
+	if doc.IsEmpty() {
+		return html.Attribute{}, false
+	}
+	n := doc.Nodes[0]
+	switch {
+	case tag.attr != "":
+		val, _ := d.attr(n, tag.attr)
+		return html.Attribute{Key: tag.attr, Val: val}, true
+	case !tag.css && attrSelRegEx.MatchString(tag.tag):
+		// htmlquery returns attributes as detached elements named after them
+		return html.Attribute{Key: n.Data, Val: NewDocumentWithNode(n).Text()}, true
+	}
+	return html.Attribute{}, false
+}
+
+// decodePtr decodes a field like unmarshalByType. Nil pointers to literals
+// are only set once a value is found: values that are empty (for numbers and
+// times) or that fail to parse in an optional field leave them nil, so that
+// missing values can be told apart from zero ones.
+func (d *decodeState) decodePtr(doc *Document, v reflect.Value, tag xpathTag) error {
+	if v.Kind() != reflect.Ptr || !v.IsNil() || !isLiteralType(v.Type().Elem()) {
+		return d.unmarshalByType(doc, v, tag)
+	}
+
+	p := reflect.New(v.Type().Elem())
+	d.unset = false
+	if err := d.unmarshalByType(doc, p.Elem(), tag); err != nil {
+		return err
+	}
+	if !d.unset {
+		v.Set(p)
+	}
+	return nil
+}
+
+func (d *decodeState) unmarshalByType(doc *Document, v reflect.Value, tag xpathTag) error {
+	if tag.json {
+		return d.unmarshalJSON(doc, v, tag)
+	}
+
+	if attr, ok := d.selectedAttr(doc, tag); ok {
+		if ua, ok := attrUnmarshaler(v); ok {
+			return wrapUnmErr(ua.UnmarshalHTMLAttr(attr), v)
+		}
+	}
+
+	if v.Type() == nodePtrType {
+		// Holds the first matched node, the multiple nodes check being done
+		// by findForTypeByTag. Elements of collections come allocated and
+		// can't be pointed at another node.
+		if !v.CanSet() {
+			return errors.New("*html.Node is only supported for fields, use []*html.Node for several nodes")
+		}
+		if !doc.IsEmpty() {
+			v.Set(reflect.ValueOf(doc.Nodes[0]))
+		}
+		return nil
+	}
+
 	u, v := indirect(v)
 
 	if u != nil {
@@ -179,37 +1204,202 @@ func unmarshalByType(doc *Document, v reflect.Value, tag xpathTag) error {
 
 	t := v.Type()
 
+	if fn := d.converter(t); fn != nil {
+		return d.unmarshalConverted(doc, v, tag, fn)
+	}
+
+	if tag.mode == modeTree {
+		switch {
+		case isTreeType(t):
+			return d.unmarshalTree(doc, v, tag)
+		case t.Kind() != reflect.Slice && t.Kind() != reflect.Array:
+			return &CannotUnmarshalError{
+				V:      v,
+				Reason: ErrTypeConversion,
+				XPath:  tag.tag,
+				Err:    fmt.Errorf("%s %q needs an interface{} or map[string]interface{} field", modeTag, modeTree),
+				Pos:    d.position(doc),
+			}
+		}
+	}
+
+	if d.rawText && t.Kind() == reflect.String && (tag.mode == "" || tag.mode == modeText) &&
+		tag.attr == "" && tag.itemProp == "" {
+		tag.mode = modeRaw
+	}
+
+	switch t {
+	case timeType:
+		return d.unmarshalTime(doc, v, tag)
+	case durationType:
+		return d.unmarshalDuration(doc, v, tag)
+	case bigIntType, bigFloatType, bigRatType:
+		return d.unmarshalBig(doc, v, tag)
+	case urlType:
+		return d.unmarshalURL(doc, v, tag)
+	case srcsetType:
+		return d.unmarshalSrcset(doc, v)
+	case urlValuesType:
+		v.Set(reflect.ValueOf(doc.Form().Values))
+		return nil
+	}
+	if valT, ok := nullValueTypes[t]; ok {
+		return d.unmarshalNull(doc, v, valT, tag)
+	}
+
+	if tu, ok := textUnmarshaler(v); ok {
+		str, err := d.value(doc, v, tag)
+		if err != nil {
+			return err
+		}
+		if err := tu.UnmarshalText([]byte(str)); err != nil {
+			return &CannotUnmarshalError{
+				V:      v,
+				Reason: ErrTypeConversion,
+				XPath:  tag.tag,
+				Err:    err,
+				Val:    str,
+				Pos:    d.position(doc),
+			}
+		}
+		return nil
+	}
+
+	if isBytesType(t) {
+		return d.unmarshalBytes(doc, v, tag)
+	}
+
 	switch t.Kind() {
 	case reflect.Struct:
-		return unmarshalStruct(doc, v)
+		return d.unmarshalStruct(doc, v, nil)
 	case reflect.Slice:
-		return unmarshalSlice(doc, v, tag)
+		if tag.srcset {
+			return d.unmarshalSrcset(doc, v)
+		}
+		if tag.table {
+			return d.unmarshalTable(doc, v, tag)
+		}
+		if tag.split != "" {
+			return d.unmarshalSplit(doc, v, tag)
+		}
+		return d.unmarshalSlice(doc, v, tag)
 	case reflect.Array:
-		return unmarshalArray(doc, v, tag)
+		return d.unmarshalArray(doc, v, tag)
+	case reflect.Chan:
+		return d.unmarshalChan(doc, v, tag)
 	case reflect.Map:
+		if tag.linkMap != "" {
+			return d.unmarshalLinkMap(doc, v, tag)
+		}
+		if tag.key != "" {
+			return d.unmarshalMap(doc, v, tag)
+		}
 		return &CannotUnmarshalError{
 			V:      v,
-			Reason: mapIsNotSupportedError,
+			Reason: ErrMapNotSupported,
 			XPath:  tag.tag,
 		}
-	default:
-		vf := tag.valFunc()
-		str := vf(doc)
-		err := unmarshalLiteral(str, v, tag.required)
+	default:
+		str, err := d.value(doc, v, tag)
+		if err != nil {
+			return err
+		}
+		if !tag.allows(str) {
+			return &CannotUnmarshalError{
+				V:      v,
+				Reason: ErrEnumValueNotAllowed,
+				XPath:  tag.tag,
+				Val:    str,
+				Pos:    d.position(doc),
+			}
+		}
+		if tag.mode == modeURL && str != "" {
+			u, err := d.resolveURL(str)
+			if err != nil {
+				return &CannotUnmarshalError{
+					V:      v,
+					Reason: ErrInvalidURL,
+					XPath:  tag.tag,
+					Err:    err,
+					Val:    str,
+					Pos:    d.position(doc),
+				}
+			}
+			str = u.String()
+		}
+		if v.Kind() == reflect.Bool && tag.hasBoolWords() {
+			b, err := tag.boolVal(str)
+			if err != nil {
+				return &CannotUnmarshalError{
+					V:      v,
+					Reason: ErrTypeConversion,
+					XPath:  tag.tag,
+					Err:    err,
+					Val:    str,
+					Pos:    d.position(doc),
+				}
+			}
+			v.SetBool(b)
+			return nil
+		}
+		lit := str
+		if tag.numFmt != nil && isNumberKind(v.Kind()) {
+			lit = tag.numFmt.normalize(str)
+		}
+		err = unmarshalLiteral(lit, v)
+		if err == nil && isNumberKind(v.Kind()) && strings.TrimSpace(str) == "" {
+			if tag.strict {
+				err = errEmptyValue
+			}
+			d.unset = true
+		}
+		if err != nil && !tag.required && !tag.strict && isNumberKind(v.Kind()) {
+			// Optional numbers that fail to parse are left as zero
+			d.note(tag.tag, fmt.Sprintf("value %q left as zero: %v", str, err))
+			d.unset = true
+			err = nil
+		}
 		if err != nil {
 			return &CannotUnmarshalError{
 				V:      v,
-				Reason: typeConversionError,
+				Reason: ErrTypeConversion,
 				XPath:  tag.tag,
 				Err:    err,
 				Val:    str,
+				Pos:    d.position(doc),
 			}
 		}
 		return nil
 	}
 }
 
-func unmarshalLiteral(s string, v reflect.Value, required bool) error {
+// isLiteralType reports whether t is decoded from a single value by
+// unmarshalLiteral or as a time, URL or big number.
+func isLiteralType(t reflect.Type) bool {
+	if t == timeType || t == urlType || isBigType(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String:
+		return true
+	}
+	return isNumberKind(t.Kind())
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// unmarshalLiteral converts s into the kind of v. Empty strings leave numbers
+// untouched; conversion errors are returned as is and it is up to the caller
+// to decide whether they are fatal.
+func unmarshalLiteral(s string, v reflect.Value) error {
 	t := v.Type()
 
 	trimmedValue := strings.TrimSpace(s)
@@ -233,10 +1423,7 @@ func unmarshalLiteral(s string, v reflect.Value, required bool) error {
 		}
 		i, err := strconv.ParseInt(trimmedValue, 10, 64)
 		if err != nil {
-			if required {
-				return err
-			}
-			return nil
+			return err
 		}
 		v.SetInt(i)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -245,10 +1432,7 @@ func unmarshalLiteral(s string, v reflect.Value, required bool) error {
 		}
 		i, err := strconv.ParseUint(trimmedValue, 10, 64)
 		if err != nil {
-			if required {
-				return err
-			}
-			return nil
+			return err
 		}
 		v.SetUint(i)
 	case reflect.Float32, reflect.Float64:
@@ -257,10 +1441,7 @@ func unmarshalLiteral(s string, v reflect.Value, required bool) error {
 		}
 		i, err := strconv.ParseFloat(trimmedValue, 64)
 		if err != nil {
-			if required {
-				return err
-			}
-			return nil
+			return err
 		}
 		v.SetFloat(i)
 	case reflect.String:
@@ -269,123 +1450,575 @@ func unmarshalLiteral(s string, v reflect.Value, required bool) error {
 	return nil
 }
 
-func unmarshalStruct(doc *Document, v reflect.Value) error {
+// unmarshalStruct decodes the fields of the struct v from doc, or from the
+// cells of row for the rows of tables, see unmarshalTable.
+func (d *decodeState) unmarshalStruct(doc *Document, v reflect.Value, row *tableRow) error {
 	t := v.Type()
 
+	var hooks interface{}
+	if v.CanAddr() && v.Addr().CanInterface() {
+		hooks = v.Addr().Interface()
+	}
+	if b, ok := hooks.(BeforeUnmarshaler); ok {
+		// doc may come from the pool of the decoding loops, the hook gets a
+		// Document of its own it can keep
+		own := NewDocumentWithNodes(doc.Nodes[:len(doc.Nodes):len(doc.Nodes)])
+		if err := b.BeforeUnmarshalHTML(own); err != nil {
+			return wrapUnmErr(err, v)
+		}
+	}
+
+	prevField := d.field
+	defer func() { d.field = prevField }()
+
 	for i := 0; i < t.NumField(); i++ {
-		tag := xpathTag{
-			tag:      t.Field(i).Tag.Get(tagName),
-			required: true,
+		if t.Field(i).Type == reportType {
+			prevReport := d.report
+			report := &DecodeReport{}
+			d.report = report
+			defer func(field reflect.Value) {
+				field.Set(reflect.ValueOf(*report))
+				d.report = prevReport
+			}(v.Field(i))
 		}
+	}
 
-		if tag.tag == ignoreTag {
-			continue
+	var errs UnmarshalErrors
+	for i := 0; i < t.NumField(); i++ {
+		if err := d.canceled(); err != nil {
+			return err
 		}
 
-		if tag.tag == "" {
-			if u, _ := indirect(v.Field(i)); u == nil {
-				continue
+		if err := d.unmarshalField(doc, v, i, row); err != nil {
+			if !d.collectErrors {
+				return err
 			}
+			errs = append(errs, err)
 		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 
-		// If tag is empty and the object doesn't implement Unmarshaler, skip
-		if tag.tag == "" {
-			if u, _ := indirect(v.Field(i)); u == nil {
-				continue
+	if a, ok := hooks.(AfterUnmarshaler); ok {
+		if err := a.AfterUnmarshalHTML(); err != nil {
+			return wrapUnmErr(err, v)
+		}
+	}
+	if val, ok := hooks.(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return &CannotUnmarshalError{
+				V:      v,
+				Reason: ErrValidation,
+				Err:    err,
+				Pos:    d.position(doc),
 			}
 		}
+	}
+	return nil
+}
 
-		required := t.Field(i).Tag.Get(requiredTag)
-		if required != "" {
-			var err error
-			tag.required, err = strconv.ParseBool(required)
-			if err != nil {
-				return err
+// isFlattenedField reports whether field is an embedded struct decoded against
+// the selection of its parent when it has no tag. Embedded pointers to
+// unexported structs cannot be allocated and are skipped.
+func isFlattenedField(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+	ft := field.Type
+	if ft.Kind() == reflect.Ptr {
+		if field.PkgPath != "" {
+			return false
+		}
+		ft = ft.Elem()
+	}
+	return ft.Kind() == reflect.Struct && ft != reportType && !isScalarType(ft) &&
+		!reflect.PtrTo(ft).Implements(unmarshalerType)
+}
+
+// unmarshalField decodes the i-th field of the struct v, looking it up in
+// row if set.
+func (d *decodeState) unmarshalField(doc *Document, v reflect.Value, i int, row *tableRow) error {
+	t := v.Type()
+	d.field = t.Field(i).Name
+	defer d.pushPath("." + d.field)()
+
+	tag, err := d.fieldTag(t, i)
+	if err != nil {
+		return &CannotUnmarshalError{
+			V:        v,
+			Reason:   ErrInvalidTag,
+			Err:      err,
+			FldOrIdx: t.Field(i).Name,
+		}
+	}
+
+	if tag.tag == ignoreTag {
+		return nil
+	}
+
+	if tag.requiredIf != "" {
+		if tag.required, err = d.siblingMatched(doc, v, i, tag); err != nil {
+			return err
+		}
+	}
+
+	if row != nil {
+		return d.unmarshalCell(doc, v, i, tag, row)
+	}
+
+	// Form controls are read from the selection of the struct
+	if tag.tag == "" && tag.input != "" {
+		if err := d.unmarshalInput(doc, v.Field(i), tag); err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				Err:      err,
+				FldOrIdx: t.Field(i).Name,
+			}
+		}
+		return nil
+	}
+
+	// Untagged embedded structs are flattened: their fields are looked up in
+	// the selection of the parent
+	if tag.tag == "" && !tag.hasDefaults() && isFlattenedField(t.Field(i)) {
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if err := d.unmarshalStruct(doc, fv, nil); err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				Err:      err,
+				FldOrIdx: t.Field(i).Name,
+			}
+		}
+		return nil
+	}
+
+	// If tag is empty and the object doesn't implement Unmarshaler, skip
+	// unless the field is derived from its defaults
+	if tag.tag == "" && !tag.hasDefaults() {
+		if u, _ := indirect(v.Field(i)); u == nil {
+			return nil
+		}
+	}
+
+	// A field without a selector but with defaults is derived from them
+	if tag.tag == "" && tag.hasDefaults() {
+		if _, err := d.applyDefault(doc, v.Field(i), tag); err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				Err:      err,
+				FldOrIdx: t.Field(i).Name,
+			}
+		}
+		return nil
+	}
+
+	if tag.jsonLD != "" {
+		found, err := d.unmarshalJSONLD(doc, v.Field(i), tag)
+		if err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: t.Field(i).Name,
+			}
+		}
+		if !found && tag.required {
+			return &CannotUnmarshalError{
+				V:      v,
+				Reason: ErrNodeNotFound,
+				XPath:  tag.tag,
+			}
+		}
+		if !found {
+			d.note(tag.tag, "optional node not found")
+		}
+		return nil
+	}
+
+	sel, err := d.findForTypeByTag(doc, v.Field(i), tag)
+	if errors.Is(err, ErrSelectorTimeout) {
+		return &CannotUnmarshalError{
+			V:        v,
+			Reason:   ErrSelectorTimeout,
+			XPath:    tag.tag,
+			Err:      err,
+			FldOrIdx: t.Field(i).Name,
+		}
+	}
+	if err != nil {
+		return err
+	}
+	d.traceField(sel, v.Field(i).Type(), tag, tag.tag)
+	d.cover(doc, sel, v.Field(i).Type(), tag)
+	return d.decodeField(doc, sel, v, i, tag)
+}
+
+// decodeField decodes the nodes sel matched in doc for the i-th field of the
+// struct v, falling back to its defaults when there are none.
+func (d *decodeState) decodeField(doc, sel *Document, v reflect.Value, i int, tag xpathTag) error {
+	t := v.Type()
+	if sel.IsEmpty() && tag.hasDefaults() {
+		applied, err := d.applyDefault(doc, v.Field(i), tag)
+		if err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: t.Field(i).Name,
+			}
+		}
+		if applied {
+			return nil
+		}
+	}
+
+	// Null types are optional, they stay NULL
+	if (!tag.required || isNullType(v.Field(i).Type())) && sel.IsEmpty() {
+		d.note(tag.tag, "optional node not found")
+		return nil
+	}
+
+	if sel.IsEmpty() {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrNodeNotFound,
+			XPath:  tag.tag,
+		}
+	}
+
+	if err := d.decodePtr(sel, v.Field(i), tag); err != nil {
+		return &CannotUnmarshalError{
+			V:        v,
+			Reason:   ErrTypeConversion,
+			XPath:    tag.tag,
+			Err:      err,
+			FldOrIdx: t.Field(i).Name,
+		}
+	}
+
+	if tag.label != "" {
+		if err := d.setLabel(sel, v, t.Field(i).Name, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setLabel stores the text of the node matched by the xpath_label selector,
+// evaluated relative to the first matched node of the field, into the string
+// field named after the field with a "Label" suffix.
+func (d *decodeState) setLabel(sel *Document, v reflect.Value, name string, tag xpathTag) error {
+	labelName := name + labelFieldSuffix
+	field := v.FieldByName(labelName)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return &CannotUnmarshalError{
+			V:        v,
+			Reason:   ErrLabelFieldMissing,
+			XPath:    tag.label,
+			FldOrIdx: labelName,
+		}
+	}
+
+	label, err := d.findOne(sel.Eq(0), tag.label)
+	if err != nil {
+		return err
+	}
+	field.SetString(strings.TrimSpace(label.Text()))
+	return nil
+}
+
+// applyDefault sets a field whose selector matched nothing, or that has no
+// selector at all, from its xpath_default_when and xpath_default tags. The
+// xpath_default_when condition is evaluated relative to the element the
+// struct is decoded from: if it matches at least one node its value is used,
+// otherwise the plain xpath_default value is used if there is one, decoded
+// like the text of a matched node. It reports whether a default was applied.
+func (d *decodeState) applyDefault(doc *Document, v reflect.Value, tag xpathTag) (bool, error) {
+	val, ok := tag.def, tag.hasDef
+	if tag.hasDefWhen {
+		cond, err := d.findAll(doc, tag.defWhen)
+		if err != nil {
+			return false, err
+		}
+		if !cond.IsEmpty() {
+			val, ok = tag.defWhenVal, true
+		}
+	}
+
+	if !ok {
+		return false, nil
+	}
+
+	// The value is read as is from a text node, whatever the attribute,
+	// mode or regex the selector reads matched nodes with
+	def := tag
+	def.attr, def.itemProp, def.regex, def.joined = "", "", nil, false
+	if def.mode != modeURL {
+		def.mode = modeOwnText
+	}
+	node := NewDocumentWithNode(&html.Node{Type: html.TextNode, Data: val})
+	if err := d.decodePtr(node, v, def); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// unmarshalMap fills a map with an entry per matched node. The key is the
+// trimmed text found by the xpath_key selector relative to the node (an
+// attribute like "@name" or a sub-selector) and the value is decoded with the
+// usual rules from the node itself, or from what the xpath_value selector
+// finds relative to it. Nodes without a key or a value are skipped and later
+// entries overwrite earlier ones.
+func (d *decodeState) unmarshalMap(doc *Document, v reflect.Value, tag xpathTag) error {
+	t := v.Type()
+	eleT := t.Elem()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+
+	valTag := tag
+	valTag.tag = tag.value
+	valTag.expr = nil
+	valTag.cssSel = nil
+	valTag.css = false
+	// Map values are keyed by xpath_value_key, or by the same selector as
+	// the outer map, e.g. for named groups of named items
+	valTag.key = tag.valueKey
+	if valTag.key == "" && TypeDeref(eleT).Kind() == reflect.Map {
+		valTag.key = tag.key
+	}
+	valTag.value = ""
+	valTag.valueKey = ""
+	valTag.flatten = false
+
+	for i := range doc.Nodes {
+		if err := d.canceled(); err != nil {
+			return err
+		}
+
+		item := doc.Eq(i)
+
+		keySel, err := d.findOne(item, tag.key)
+		if err != nil {
+			return err
+		}
+		if keySel.IsEmpty() {
+			d.note(tag.key, fmt.Sprintf("node %d skipped: key not found", i))
+			continue
+		}
+		key := strings.TrimSpace(keySel.Text())
+
+		kv := reflect.New(t.Key()).Elem()
+		if err := unmarshalLiteral(key, kv); err != nil {
+			return &CannotUnmarshalError{
+				V:      v,
+				Reason: ErrTypeConversion,
+				XPath:  tag.key,
+				Err:    err,
+				Val:    key,
+				Pos:    d.position(keySel),
 			}
 		}
 
-		sel, err := findForTypeByTag(doc, v.Field(i), tag)
+		newV := reflect.New(TypeDeref(eleT))
+
+		valSel, err := d.findForTypeByTag(item, newV.Elem(), valTag)
 		if err != nil {
 			return err
 		}
+		if valSel.IsEmpty() {
+			d.note(tag.value, fmt.Sprintf("key %q skipped: value not found", key))
+			continue
+		}
+
+		if err := d.unmarshalByType(valSel, newV, valTag); err != nil {
+			return &CannotUnmarshalError{
+				V:        v,
+				Reason:   ErrTypeConversion,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: key,
+			}
+		}
+
+		if eleT.Kind() != reflect.Ptr {
+			newV = newV.Elem()
+		}
+		v.SetMapIndex(kv, newV)
+	}
+
+	return nil
+}
+
+// unmarshalLinkMap fills a map from link-like elements: the key is the value of
+// the tag.linkMap attribute (e.g. rel or itemprop) and the value is the href
+// of the element, or its content if it has no href. Elements without the key
+// attribute are skipped and later elements overwrite earlier ones.
+func (d *decodeState) unmarshalLinkMap(doc *Document, v reflect.Value, tag xpathTag) error {
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
 
-		if !tag.required && sel.IsEmpty() {
+	for _, node := range doc.Nodes {
+		key, ok := d.attr(node, tag.linkMap)
+		if !ok {
 			continue
 		}
+		val, ok := d.attr(node, "href")
+		if !ok {
+			val, _ = d.attr(node, "content")
+		}
 
-		if sel.IsEmpty() {
+		kv := reflect.New(t.Key()).Elem()
+		if err := unmarshalLiteral(strings.TrimSpace(key), kv); err != nil {
 			return &CannotUnmarshalError{
 				V:      v,
-				Reason: nodeNotFound,
+				Reason: ErrTypeConversion,
 				XPath:  tag.tag,
+				Err:    err,
+				Val:    key,
+				Pos:    d.position(NewDocumentWithNode(node)),
 			}
 		}
 
-		if err := unmarshalByType(sel, v.Field(i), tag); err != nil {
+		vv := reflect.New(t.Elem()).Elem()
+		if err := unmarshalLiteral(strings.TrimSpace(val), vv); err != nil {
 			return &CannotUnmarshalError{
 				V:        v,
-				Reason:   typeConversionError,
+				Reason:   ErrTypeConversion,
 				XPath:    tag.tag,
 				Err:      err,
-				FldOrIdx: t.Field(i).Name,
+				Val:      val,
+				FldOrIdx: key,
+				Pos:      d.position(NewDocumentWithNode(node)),
 			}
 		}
+
+		v.SetMapIndex(kv, vv)
 	}
+
 	return nil
 }
 
-func unmarshalArray(doc *Document, v reflect.Value, tag xpathTag) error {
+func (d *decodeState) unmarshalArray(doc *Document, v reflect.Value, tag xpathTag) error {
 	if v.Type().Len() != len(doc.Nodes) {
 		return &CannotUnmarshalError{
 			V:      v,
-			Reason: arrayLengthMismatch,
+			Reason: ErrArrayLengthMismatch,
 			XPath:  tag.tag,
 		}
 	}
 
+	var errs UnmarshalErrors
 	for i := 0; i < v.Type().Len(); i++ {
-		err := unmarshalByType(doc.Eq(i), v.Index(i), tag)
+		if err := d.canceled(); err != nil {
+			return err
+		}
+
+		elem := doc.pooledEq(i)
+		err := d.unmarshalByType(elem, v.Index(i), tag)
+		putDocument(elem)
 		if err != nil {
-			return &CannotUnmarshalError{
+			err = &CannotUnmarshalError{
 				V:        v,
-				Reason:   typeConversionError,
+				Reason:   ErrTypeConversion,
 				XPath:    tag.tag,
 				Err:      err,
 				FldOrIdx: i,
 			}
+			if !d.collectErrors {
+				return err
+			}
+			errs = append(errs, err)
 		}
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func unmarshalSlice(doc *Document, v reflect.Value, tag xpathTag) error {
+func (d *decodeState) unmarshalSlice(doc *Document, v reflect.Value, tag xpathTag) error {
 	slice := v
 	eleT := v.Type().Elem()
 
 	v.SetLen(0)
+	var errs UnmarshalErrors
+	var seen valueSet
+	decoded := d.decodeElementsParallel(doc, eleT, tag)
 	for i := 0; i < doc.Length(); i++ {
-		newV := reflect.New(TypeDeref(eleT))
+		if err := d.canceled(); err != nil {
+			return err
+		}
+
+		var newV reflect.Value
+		var err error
+		if decoded != nil {
+			newV, err = decoded[i].v, decoded[i].err
+		} else {
+			newV = reflect.New(TypeDeref(eleT))
+			pop := d.pushPath(fmt.Sprintf("[%d]", i))
+			elem := doc.pooledEq(i)
+			err = d.unmarshalByType(elem, newV, tag)
+			putDocument(elem)
+			pop()
+		}
 
-		err := unmarshalByType(doc.Eq(i), newV, tag)
+		if err != nil && tag.skipErrors {
+			d.note(tag.tag, fmt.Sprintf("element %d skipped: %v", i, err))
+			continue
+		}
 
 		if err != nil {
-			return &CannotUnmarshalError{
+			err = &CannotUnmarshalError{
 				V:        v,
-				Reason:   typeConversionError,
+				Reason:   ErrTypeConversion,
 				XPath:    tag.tag,
 				Err:      err,
 				FldOrIdx: i,
 			}
+			if !d.collectErrors {
+				return err
+			}
+			// Keep the element as far as it could be decoded
+			errs = append(errs, err)
 		}
 
+		if tag.unique == uniqueValue && !seen.add(newV.Elem()) {
+			continue
+		}
+		// Empty links are left out rather than decoded into empty URLs
+		if err == nil && newV.Elem().Type() == urlType && newV.Elem().IsZero() {
+			d.note(tag.tag, fmt.Sprintf("element %d skipped: empty URL", i))
+			continue
+		}
 		if eleT.Kind() != reflect.Ptr {
 			newV = newV.Elem()
 		}
 
 		v = reflect.Append(v, newV)
+		if tag.unique == uniqueValue && tag.limit > 0 && v.Len() == tag.limit {
+			break
+		}
 	}
 
 	slice.Set(v)
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
@@ -1,11 +1,17 @@
 package goxtag
 
 import (
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/html"
+	"math/big"
+	"net"
+	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 const testPage = `<!DOCTYPE html>
@@ -264,6 +270,701 @@ func TestRequiredSlice(t *testing.T) {
 	asrt.Equal("could not unmarshal into 'struct { Orders []int \"xpath:\\\".//blabla\\\"\" }' (type struct { Orders []int \"xpath:\\\".//blabla\\\"\" }): node not found in document tag: './/blabla'", err.Error())
 }
 
+func TestJoin(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Names string `xpath:".//*[@id='resources']//*[contains(concat(' ',normalize-space(@class),' '),' name ')]" xpath_join:", "`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal("Foo, Bar, Baz, Bang, Zip", a.Names)
+}
+
+func TestTitleAndMetaShorthands(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Title   string `xpath:"meta:title"`
+		Charset string `xpath:"meta:charset"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal("", a.Title)
+	asrt.Equal("utf-8", a.Charset)
+
+	// A plain "title" is a relative XPath like any other
+	var b struct {
+		Title string `xpath:"meta:title"`
+		Items []struct {
+			Title string `xpath:"title"`
+		} `xpath:"//item"`
+	}
+	page := `<html><head><title>Feed</title></head><body><item><title>First</title></item><item><title>Second</title></item></body></html>`
+	asrt.NoError(UnmarshalWithOptions([]byte(page), &b, WithSyntax(SyntaxXML)))
+	asrt.Equal("Feed", b.Title)
+	if asrt.Len(b.Items, 2) {
+		asrt.Equal("First", b.Items[0].Title)
+		asrt.Equal("Second", b.Items[1].Title)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Items    []string `xpath:".//*[@id='nested-map']/ul" xpath_flatten:"true"`
+		Names    []string `xpath:".//*[@id='nested-map']/ul" xpath_flatten:"true" xpath_child:"./li/@name"`
+		NotFound []string `xpath:".//*[@id='nested-map']/ol" xpath_flatten:"true" xpath_required:"false"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal([]string{"foo", "bar", "baz", "bang", "ring", "fling"}, a.Items)
+	asrt.Equal([]string{"foo", "bar", "baz", "bang", "ring", "fling"}, a.Names)
+	asrt.Nil(a.NotFound)
+}
+
+func TestDefaultWhen(t *testing.T) {
+	asrt := assert.New(t)
+
+	type item struct {
+		Name   string `xpath:"./b"`
+		Status string `xpath_default_when:"./span[@class='featured'] => featured" xpath_default:"normal"`
+		Stock  int    `xpath:"./i" xpath_default:"-1"`
+	}
+
+	var a struct {
+		Items []item `xpath:"//li"`
+	}
+
+	page := `<ul>
+		<li><b>First</b><span class="featured">*</span><i>3</i></li>
+		<li><b>Second</b></li>
+	</ul>`
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal([]item{
+		{Name: "First", Status: "featured", Stock: 3},
+		{Name: "Second", Status: "normal", Stock: -1},
+	}, a.Items)
+}
+
+func TestDefaultTypes(t *testing.T) {
+	asrt := assert.New(t)
+
+	// Defaults are decoded like matched values, whatever the field type
+	var a struct {
+		Date    time.Time     `xpath:"//time" xpath_time_layout:"2006-01-02" xpath_default:"2021-03-14"`
+		Wait    time.Duration `xpath:"//i" xpath_default:"PT1M"`
+		Link    url.URL       `xpath:"//a/@href" xpath_default:"/home"`
+		Big     *big.Int      `xpath:"//b" xpath_default:"123456789012345678901234567890"`
+		IP      net.IP        `xpath:"//s" xpath_default:"127.0.0.1"`
+		Price   float64       `xpath:"//p" xpath_attr:"data-price" xpath_numfmt:"fr" xpath_default:"1 234,5"`
+		Ignored string        `xpath:"//p" xpath_regex:"\\d+" xpath_default:"none"`
+	}
+	base, _ := url.Parse("https://example.com/a/")
+	asrt.NoError(UnmarshalWithOptions([]byte(`<div></div>`), &a, WithBaseURL(base)))
+	asrt.Equal(time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC), a.Date)
+	asrt.Equal(time.Minute, a.Wait)
+	asrt.Equal("https://example.com/home", a.Link.String())
+	asrt.Equal("123456789012345678901234567890", a.Big.String())
+	asrt.Equal(net.ParseIP("127.0.0.1"), a.IP)
+	asrt.Equal(1234.5, a.Price)
+	asrt.Equal("none", a.Ignored)
+
+	var b struct {
+		Date time.Time `xpath:"//time" xpath_default:"soon"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(`<div></div>`), &b), ErrTypeConversion))
+}
+
+func TestDecodeReport(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Report     DecodeReport
+		NotExisted int    `xpath:".//navbar" xpath_required:"false"`
+		Coerced    int    `xpath:".//*[contains(concat(' ',normalize-space(@class),' '),' some-div ')]" xpath_required:"false"`
+		Existed    string `xpath:".//*[contains(concat(' ',normalize-space(@class),' '),' some-div ')]"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal("Some div", a.Existed)
+	asrt.Len(a.Report.Notes, 2)
+	asrt.Equal(DecodeNote{
+		Field:   "NotExisted",
+		XPath:   ".//navbar",
+		Message: "optional node not found",
+	}, a.Report.Notes[0])
+	asrt.Equal("Coerced", a.Report.Notes[1].Field)
+	asrt.Contains(a.Report.Notes[1].Message, `value "Some div" left as zero`)
+}
+
+func TestStrict(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<p class="price">n/a</p><p class="stock"> </p>`)
+
+	var a struct {
+		Price float64 `xpath:"//p[@class='price']" xpath_required:"false" xpath_strict:"true"`
+	}
+	err := Unmarshal(page, &a)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Contains(err.Error(), `value "n/a"`)
+
+	var b struct {
+		Stock int `xpath:"//p[@class='stock']" xpath_strict:"true"`
+	}
+	err = Unmarshal(page, &b)
+	asrt.True(errors.Is(err, errEmptyValue))
+
+	var c struct {
+		Price float64 `xpath:"//p[@class='price']" xpath_required:"false"`
+		Stock int     `xpath:"//p[@class='stock']"`
+	}
+	asrt.NoError(Unmarshal(page, &c))
+}
+
+func TestModeHTML(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<div class="post"><p>Some <b>bold</b> &amp; text</p><br></div>`)
+
+	var a struct {
+		Body  string `xpath:"//div[@class='post']" xpath_mode:"html"`
+		Raw   []byte `xpath:"//div[@class='post']/p" xpath_mode:"html"`
+		Plain string `xpath:"//div[@class='post']" xpath_mode:"text"`
+		Outer string `xpath:"//div[@class='post']/p" xpath_mode:"outerhtml"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal(`<p>Some <b>bold</b> &amp; text</p>`, a.Outer)
+	asrt.Equal(`<p>Some <b>bold</b> &amp; text</p><br/>`, a.Body)
+	asrt.Equal([]byte(`Some <b>bold</b> &amp; text`), a.Raw)
+	asrt.Equal("Some bold & text", a.Plain)
+
+	var b struct {
+		Body string `xpath:"//div" xpath_mode:"markdown"`
+	}
+	asrt.Error(Unmarshal(page, &b))
+}
+
+func TestLinkMap(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<html><head>
+		<meta itemprop="name" content="Test page" />
+		<link rel="canonical" href="https://example.com/page" />
+		<link rel="alternate" hreflang="de" href="https://example.com/de/page" />
+		<link rel="icon" href="/favicon.ico" />
+		<link href="/no-rel.css" />
+	</head><body><h1>Test</h1></body></html>`
+
+	var a struct {
+		Links map[string]string `xpath:"//link" xpath_linkmap:"rel"`
+		Props map[string]string `xpath:"//*[@itemprop]" xpath_linkmap:"itemprop"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal(map[string]string{
+		"canonical": "https://example.com/page",
+		"alternate": "https://example.com/de/page",
+		"icon":      "/favicon.ico",
+	}, a.Links)
+	asrt.Equal(map[string]string{"name": "Test page"}, a.Props)
+}
+
+func TestSkipHidden(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Rows []string `xpath:"//tr/td" xpath_skip_hidden:"true"`
+		All  []string `xpath:"//tr/td"`
+	}
+
+	page := `<table>
+		<tr><td>one</td></tr>
+		<tr><td style="display:none">{{template}}</td></tr>
+		<tr><td>two</td></tr>
+	</table>`
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal([]string{"one", "two"}, a.Rows)
+	asrt.Len(a.All, 3)
+}
+
+func TestLabel(t *testing.T) {
+	asrt := assert.New(t)
+
+	type input struct {
+		Email      string `xpath:".//input[@name='email']/following-sibling::span[1]" xpath_label:"preceding::label[1]"`
+		EmailLabel string
+	}
+
+	page := `<form>
+		<label>Name</label><input name="name"><span>John</span>
+		<label for="email">E-mail address</label><input name="email"><span>john@example.com</span>
+	</form>`
+
+	var a input
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal("john@example.com", a.Email)
+	asrt.Equal("E-mail address", a.EmailLabel)
+
+	var b struct {
+		Email string `xpath:".//input[@name='email']/following-sibling::span[1]" xpath_label:"preceding::label[1]"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(page), &b))
+	asrt.Equal(ErrLabelFieldMissing, e.Reason)
+}
+
+func TestSrcset(t *testing.T) {
+	asrt := assert.New(t)
+
+	type candidate struct {
+		URL   string
+		Width int
+	}
+
+	var a struct {
+		Images []candidate `xpath:"//img" xpath_srcset:"true"`
+		URLs   []string    `xpath:"//img/@srcset" xpath_srcset:"true"`
+	}
+
+	page := `<img src="small.jpg" srcset="small.jpg 480w, /img/medium.jpg?w=800,h=600 800w,
+		https://example.com/large.jpg 1600w">`
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal([]candidate{
+		{URL: "small.jpg", Width: 480},
+		{URL: "/img/medium.jpg?w=800,h=600", Width: 800},
+		{URL: "https://example.com/large.jpg", Width: 1600},
+	}, a.Images)
+	asrt.Equal([]string{"small.jpg", "/img/medium.jpg?w=800,h=600", "https://example.com/large.jpg"}, a.URLs)
+}
+
+func TestAttrTag(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<div id="widget" data-active="true" data-count=" 5 " data-ratio="0.5" data-tags="a"></div>
+		<span data-tags="b"></span>
+		<div id="broken" data-active="maybe" data-count="many"></div>`
+
+	var a struct {
+		Active   bool     `xpath:"//*[@id='widget']" xpath_attr:"data-active"`
+		Count    int      `xpath:"//*[@id='widget']" xpath_attr:"data-count"`
+		Ratio    float64  `xpath:"//*[@id='widget']" xpath_attr:"data-ratio"`
+		Tags     []string `xpath:"//*" xpath_attr:"data-tags"`
+		Missing  int      `xpath:"//*[@id='widget']" xpath_attr:"data-missing" xpath_required:"false"`
+		Optional int      `xpath:"//*[@id='broken']" xpath_attr:"data-count" xpath_required:"false"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.True(a.Active)
+	asrt.Equal(5, a.Count)
+	asrt.Equal(0.5, a.Ratio)
+	asrt.Equal([]string{"a", "b"}, a.Tags)
+	asrt.Equal(0, a.Missing)
+	asrt.Equal(0, a.Optional)
+
+	// Attribute values go through the same conversions as text
+	var b struct {
+		Active bool `xpath:"//*[@id='broken']" xpath_attr:"data-active"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(page), &b)).unwind()
+	asrt.Equal("maybe", e.val)
+	asrt.Contains(e.Error(), "invalid syntax")
+
+	var c struct {
+		Count int `xpath:"//*[@id='broken']" xpath_attr:"data-count"`
+	}
+	asrt.Error(Unmarshal([]byte(page), &c))
+
+	var m struct {
+		Missing int `xpath:"//*[@id='widget']" xpath_attr:"data-missing"`
+	}
+	e2 := checkErr(asrt, Unmarshal([]byte(page), &m))
+	asrt.Equal(ErrNodeNotFound, e2.Reason)
+}
+
+func TestAttrOption(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<a href="/first" title="One">1</a>
+		<a href="/second">2</a>
+		<p class="intro" data-id="7">Hello, world</p>`
+
+	var a struct {
+		First string   `xpath:"(//a)[1],attr=href"`
+		Links []string `xpath:"//a, attr=href"`
+		Title []string `css:"a,attr=title"`
+		ID    int      `xpath:"//p[contains(., 'Hello, world')],attr=data-id"`
+		Same  string   `xpath:"(//a)[2],attr=href" xpath_attr:"href"`
+		Text  string   `xpath:"//p[contains(., 'Hello, world')]"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal("/first", a.First)
+	asrt.Equal([]string{"/first", "/second"}, a.Links)
+	asrt.Equal([]string{"One"}, a.Title)
+	asrt.Equal(7, a.ID)
+	asrt.Equal("/second", a.Same)
+	asrt.Equal("Hello, world", a.Text)
+
+	var b struct {
+		Link string `xpath:"(//a)[1],attr=href" xpath_attr:"title"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(page), &b))
+	asrt.Equal(ErrInvalidTag, e.Reason)
+}
+
+func TestRegex(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<p class="stock">In stock: 12 items</p>
+		<p class="price">Price: $ 19.99</p>
+		<ul><li>Size 10</li><li>Size 12</li><li>One size</li></ul>
+		<a href="/item?id=42">item</a>`
+
+	var a struct {
+		Stock  int     `xpath:"//p[@class='stock']" xpath_regex:"stock: (\\d+)"`
+		Price  float64 `xpath:"//p[@class='price']" xpath_regex:"\\$\\s*([\\d.]+)"`
+		Sizes  []int   `xpath:"//li" xpath_regex:"\\d+" xpath_required:"false"`
+		ID     int     `xpath:"//a,attr=href" xpath_regex:"id=(\\d+)"`
+		Joined string  `xpath:"//li" xpath_regex:"\\d+" xpath_join:"," xpath_required:"false"`
+		Report DecodeReport
+	}
+
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal(12, a.Stock)
+	asrt.Equal(19.99, a.Price)
+	asrt.Equal([]int{10, 12, 0}, a.Sizes)
+	asrt.Equal(42, a.ID)
+	asrt.Equal("10,12,", a.Joined)
+	asrt.NotEmpty(a.Report.Notes)
+
+	// Required fields fail on values that don't match
+	var c struct {
+		Stock int `xpath:"//p[@class='price']" xpath_regex:"stock: (\\d+)"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(page), &c))
+	asrt.Equal(ErrTypeConversion, e.Reason)
+	asrt.Contains(e.Error(), "Price: $ 19.99")
+
+	var d struct {
+		Sizes []int `xpath:"//li" xpath_regex:"\\d+"`
+	}
+	asrt.Equal(ErrTypeConversion, checkErr(asrt, Unmarshal([]byte(page), &d)).Reason)
+	asrt.Equal(ErrTypeConversion, checkErr(asrt, UnmarshalWithOptions([]byte(strings.Repeat(page, 4)), &d, WithParallelism(4))).Reason)
+
+	// The mismatch fails the element only, which can then be skipped
+	var skip struct {
+		Sizes  []int `xpath:"//li" xpath_regex:"\\d+" xpath_on_error:"skip"`
+		Report DecodeReport
+	}
+	asrt.NoError(Unmarshal([]byte(`<li>a1</li><li>b</li><li>c3</li>`), &skip))
+	asrt.Equal([]int{1, 3}, skip.Sizes)
+	asrt.Len(skip.Report.Notes, 1)
+	asrt.Contains(skip.Report.Notes[0].Message, "element 1 skipped")
+	asrt.NoError(UnmarshalWithOptions([]byte(strings.Repeat(`<li>a1</li><li>b</li><li>c3</li>`, 4)), &skip, WithParallelism(4)))
+	asrt.Equal([]int{1, 3, 1, 3, 1, 3, 1, 3}, skip.Sizes)
+
+	var b struct {
+		Stock int `xpath:"//p[@class='stock']" xpath_regex:"(\\d+"`
+	}
+	e = checkErr(asrt, Unmarshal([]byte(page), &b))
+	asrt.Equal(ErrInvalidTag, e.Reason)
+}
+
+type Status string
+
+func TestEnumSlice(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<ul>
+		<li>active</li>
+		<li> banned </li>
+		<li>deleted</li>
+		<li>inactive</li>
+	</ul>`
+
+	var fail struct {
+		Statuses []Status `xpath:"//li" xpath_enum:"active|inactive|banned"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(page), &fail)).unwind()
+	asrt.Equal(ErrEnumValueNotAllowed, e.last().Reason)
+	asrt.Equal("deleted", e.val)
+	asrt.Contains(e.Error(), "Statuses[2]")
+
+	var skip struct {
+		Report   DecodeReport
+		Statuses []Status `xpath:"//li" xpath_enum:"active|inactive|banned" xpath_on_error:"skip"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &skip))
+	asrt.Equal([]Status{"active", "banned", "inactive"}, skip.Statuses)
+	asrt.Len(skip.Report.Notes, 1)
+	asrt.Contains(skip.Report.Notes[0].Message, "element 2 skipped")
+
+	var lenient struct {
+		Report   DecodeReport
+		Statuses []Status `xpath:"//li" xpath_enum:"active|inactive|banned" xpath_skip_errors:"true"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &lenient))
+	asrt.Equal(skip.Statuses, lenient.Statuses)
+	asrt.Equal(skip.Report.Notes, lenient.Report.Notes)
+
+	var conflict struct {
+		Statuses []Status `xpath:"//li" xpath_on_error:"fail" xpath_skip_errors:"true"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &conflict)).Reason)
+}
+
+func TestSliceWindow(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<table>
+		<tr><th>Name</th></tr>
+		<tr><td>a</td></tr>
+		<tr><td>b</td></tr>
+		<tr><td>c</td></tr>
+	</table>`
+
+	var a struct {
+		Rows   []string  `xpath:"//tr" xpath_offset:"1"`
+		Top    []string  `xpath:"//td" xpath_limit:"2"`
+		Middle [1]string `xpath:"//td" xpath_offset:"1" xpath_limit:"1"`
+		None   []string  `xpath:"//td" xpath_offset:"5" xpath_required:"false"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal([]string{"a", "b", "c"}, a.Rows)
+	asrt.Equal([]string{"a", "b"}, a.Top)
+	asrt.Equal([1]string{"b"}, a.Middle)
+	asrt.Empty(a.None)
+
+	var invalid struct {
+		Rows []string `xpath:"//tr" xpath_limit:"0"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &invalid)).Reason)
+}
+
+func TestSortedSlice(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		ByOrder []Resource `xpath:"//ul[@id='resources']/li" xpath_sort_by:"@order" xpath_sort:"number"`
+		ByName  []string   `xpath:"//ul[@id='resources']/li" xpath_sort_by:"./div[@class='name']"`
+		Top     []Resource `xpath:"//ul[@id='resources']/li" xpath_sort_by:"@order" xpath_sort:"number,desc" xpath_limit:"2"`
+	}
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal([]Resource{{"Bar"}, {"Bang"}, {"Foo"}, {"Baz"}, {"Zip"}}, a.ByOrder)
+	asrt.Equal([]string{"Bang", "Bar", "Baz", "Foo", "Zip"}, a.ByName)
+	asrt.Equal([]Resource{{"Zip"}, {"Baz"}}, a.Top)
+
+	var b struct {
+		Prices []string `xpath:"//li" xpath_sort_by:"@data-price" xpath_sort:"number"`
+	}
+	asrt.NoError(Unmarshal([]byte(`<ul><li data-price="10">a</li><li>b</li><li data-price="9.5">c</li><li data-price="10">d</li></ul>`), &b))
+	asrt.Equal([]string{"c", "a", "d", "b"}, b.Prices)
+
+	// The sort selector gets the variables, params and namespaces of the run
+	page := []byte(`<ul xmlns:p="urn:price"><li><p:v cur="eur">2</p:v><p:v cur="usd">1</p:v>a</li>` +
+		`<li><p:v cur="eur">1</p:v><p:v cur="usd">3</p:v>b</li></ul>`)
+	var c struct {
+		ByVar   []string `xpath:"//li" xpath_sort_by:"./*[@cur=$cur]" xpath_sort:"number"`
+		ByParam []string `xpath:"//li" xpath_sort_by:"./*[@cur='{cur}']" xpath_sort:"number"`
+		ByNS    []string `xpath:"//li" xpath_sort_by:"./price:v[@cur='usd']" xpath_sort:"number,desc"`
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &c, WithVars(map[string]string{"cur": "eur"}),
+		WithParams(map[string]string{"cur": "usd"}), WithNamespaces(map[string]string{"price": "urn:price"})))
+	asrt.Equal([]string{"13b", "21a"}, c.ByVar)
+	asrt.Equal([]string{"21a", "13b"}, c.ByParam)
+	asrt.Equal([]string{"13b", "21a"}, c.ByNS)
+	asrt.Error(UnmarshalWithOptions(page, &c))
+
+	var invalid struct {
+		Names []string `xpath:"//li" xpath_sort:"number"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(testPage), &invalid)).Reason)
+}
+
+func TestUniqueSlice(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<nav><a href="/a">A</a><a href="/b">B</a></nav>
+		<p><a href="/a">A</a> <a href="/a">again</a> <a href="/c">C</a></p>
+		<ul><li>go</li><li>html</li><li> go </li></ul>`
+
+	type link struct {
+		Href string `xpath:"./@href"`
+		Text string `xpath:"."`
+	}
+	var a struct {
+		Nodes    []string      `xpath:"//a" xpath_unique:"node"`
+		Hrefs    []string      `xpath:"//a/@href" xpath_unique:"true"`
+		Links    []link        `xpath:"//a" xpath_unique:"value"`
+		Ptrs     []*link       `xpath:"//a" xpath_unique:"value" xpath_limit:"3"`
+		Tags     []string      `xpath:"//li" xpath_unique:"value"`
+		FirstTwo []string      `xpath:"//li" xpath_unique:"value" xpath_offset:"1" xpath_limit:"2"`
+		Any      []interface{} `xpath:"//li" xpath_unique:"value"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal([]string{"A", "B", "again", "C"}, a.Nodes)
+	asrt.Equal([]string{"/a", "/b", "/c"}, a.Hrefs)
+	asrt.Equal([]link{{"/a", "A"}, {"/b", "B"}, {"/a", "again"}, {"/c", "C"}}, a.Links)
+	asrt.Len(a.Ptrs, 3)
+	asrt.Equal(link{"/a", "again"}, *a.Ptrs[2])
+	asrt.Equal([]string{"go", "html"}, a.Tags)
+	asrt.Equal([]string{"html", "go"}, a.FirstTwo)
+	asrt.Equal([]interface{}{"go", "html"}, a.Any)
+
+	var invalid struct {
+		Tags []string `xpath:"//li" xpath_unique:"text"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &invalid)).Reason)
+
+	var array struct {
+		Tags [3]string `xpath:"//li" xpath_unique:"value"`
+	}
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &array)).Reason)
+
+	var ch struct {
+		Tags chan string `xpath:"//li" xpath_unique:"true" xpath_limit:"2"`
+	}
+	ch.Tags = make(chan string, 10)
+	asrt.Equal(ErrInvalidTag, checkErr(asrt, Unmarshal([]byte(page), &ch)).Reason)
+	asrt.Len(ch.Tags, 0)
+}
+
+func TestCSS(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Resources []Resource `css:"#resources .resource"`
+		Header    string     `css:"h2#anchor-header > a"`
+		Names     []string   `css:"ul#resources li .name"`
+		Orders    []int      `css:"#resources li" xpath_attr:"order"`
+		Mixed     struct {
+			Float float32 `xpath:".//float"`
+			Int   int     `css:"int"`
+		} `css:"div.foobar"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Len(a.Resources, 5)
+	for i, val := range vals {
+		asrt.Equal(val, a.Resources[i].Name)
+	}
+	asrt.Equal("FOO!!!", a.Header)
+	asrt.Equal(vals, a.Names)
+	asrt.Equal([]int{3, 1, 4, 2, 5}, a.Orders)
+	asrt.Equal(float32(1.2345), a.Mixed.Float)
+	asrt.Equal(-123, a.Mixed.Int)
+}
+
+func TestCSSErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	var both struct {
+		Header string `xpath:".//h2" css:"h2"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(testPage), &both))
+	asrt.Equal(ErrInvalidTag, e.Reason)
+	asrt.Equal(errBothXPathAndCSS, e.Err)
+	asrt.Contains(e.Error(), ".Header")
+
+	var multiple struct {
+		Name string `css:".name"`
+	}
+	e = checkErr(asrt, Unmarshal([]byte(testPage), &multiple))
+	asrt.Equal(ErrMultipleNodes, e.Reason)
+
+	var invalid struct {
+		Name string `css:"div[["`
+	}
+	asrt.Error(Unmarshal([]byte(testPage), &invalid))
+}
+
+func TestMap(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		List   map[string]string   `xpath:".//*[@id='structured-list']/li" xpath_key:"@name"`
+		Nested map[string][]string `xpath:".//*[@id='nested-map']/ul" xpath_key:"@name" xpath_value:"./li"`
+		Orders map[int]string      `xpath:".//*[@id='resources']/li" xpath_key:"@order" xpath_value:".//div"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal(map[string]string{"foo": "foo", "bar": "bar", "baz": "baz"}, a.List)
+	asrt.Equal(map[string][]string{
+		"first":  {"foo", "bar", "baz"},
+		"second": {"bang", "ring", "fling"},
+	}, a.Nested)
+	asrt.Equal(map[int]string{1: "Bar", 2: "Bang", 3: "Foo", 4: "Baz", 5: "Zip"}, a.Orders)
+}
+
+func TestNestedMap(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Groups map[string]map[string]string  `xpath:".//*[@id='nested-map']/ul" xpath_key:"@name" xpath_value:"./li"`
+		Ptrs   map[string]*map[string]string `xpath:".//*[@id='nested-map']/ul" xpath_key:"@name" xpath_value:"./li"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	want := map[string]map[string]string{
+		"first":  {"foo": "foo", "bar": "bar", "baz": "baz"},
+		"second": {"bang": "bang", "ring": "ring", "fling": "fling"},
+	}
+	asrt.Equal(want, a.Groups)
+	asrt.Equal(want["first"], *a.Ptrs["first"])
+
+	var b struct {
+		Orders map[string]map[int]string `xpath:"//ul" xpath_key:"@id" xpath_value:"./li" xpath_value_key:"@order"`
+	}
+	asrt.NoError(Unmarshal([]byte(`<ul id="a"><li order="2">x</li><li order="1">y</li></ul><ul id="b"><li order="1">z</li></ul>`), &b))
+	asrt.Equal(map[string]map[int]string{"a": {1: "y", 2: "x"}, "b": {1: "z"}}, b.Orders)
+}
+
+func TestMapOfStructs(t *testing.T) {
+	asrt := assert.New(t)
+
+	type item struct {
+		Text string `xpath:"."`
+		Val  string `xpath:"@val"`
+	}
+	var a struct {
+		List     map[string]item     `xpath:".//*[@id='structured-list']/li" xpath_key:"@name"`
+		Ptrs     map[string]*item    `xpath:".//*[@id='structured-list']/li" xpath_key:"@name"`
+		Resource map[string]Resource `xpath:".//*[@id='resources']/li" xpath_key:"@order"`
+	}
+
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal(map[string]item{
+		"foo": {"foo", "flip"},
+		"bar": {"bar", "flip"},
+		"baz": {"baz", "flip"},
+	}, a.List)
+	asrt.Len(a.Ptrs, 3)
+	asrt.Equal(item{"baz", "flip"}, *a.Ptrs["baz"])
+	asrt.Equal(Resource{"Bang"}, a.Resource["2"])
+}
+
+func TestMapErrors(t *testing.T) {
+	asrt := assert.New(t)
+
+	var noKey struct {
+		List map[string]string `xpath:".//*[@id='structured-list']/li"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(testPage), &noKey))
+	asrt.Equal(ErrMapNotSupported, checkErr(asrt, e.Err).Reason)
+
+	var badValue struct {
+		List map[string]int `xpath:".//*[@id='structured-list']/li" xpath_key:"@name"`
+	}
+	err := Unmarshal([]byte(testPage), &badValue)
+	asrt.Error(err)
+	asrt.Contains(err.Error(), `List["foo"]`)
+}
+
 func TestIgnore(t *testing.T) {
 	asrt := assert.New(t)
 
@@ -310,6 +1011,86 @@ func TestUnmarshalError(t *testing.T) {
 	asrt.Equal(`could not unmarshal : A wild error appeared`, e2.Error())
 }
 
+func TestErrorsIsAndAs(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Items []struct {
+			N int `xpath:"./b"`
+		} `xpath:"//li"`
+	}
+	err := Unmarshal([]byte(`<ul><li><b>1</b></li><li><b>x</b></li></ul>`), &a)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.False(errors.Is(err, ErrNodeNotFound))
+
+	var numErr *strconv.NumError
+	asrt.True(errors.As(err, &numErr))
+	asrt.Equal("x", numErr.Num)
+
+	var ce *CannotUnmarshalError
+	asrt.True(errors.As(err, &ce))
+	asrt.Equal(ErrTypeConversion, ce.Reason)
+
+	var b struct {
+		Missing string `xpath:"//p"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(`<ul></ul>`), &b), ErrNodeNotFound))
+
+	err = UnmarshalWithOptions([]byte(`<b>x</b>`), &struct {
+		A int `xpath:"//b"`
+		B int `xpath:"//i"`
+	}{}, WithCollectErrors())
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.True(errors.Is(err, ErrNodeNotFound))
+}
+
+func TestErrorPosition(t *testing.T) {
+	asrt := assert.New(t)
+
+	src := "<ul>\n  <li><b>1</b></li>\n  <li><b>x</b></li>\n</ul>"
+	var a struct {
+		Items []struct {
+			N int `xpath:"./b"`
+		} `xpath:"//li"`
+	}
+	err := Unmarshal([]byte(src), &a)
+
+	var ce *CannotUnmarshalError
+	asrt.True(errors.As(err, &ce))
+	for {
+		inner, ok := ce.Err.(*CannotUnmarshalError)
+		if !ok {
+			break
+		}
+		ce = inner
+	}
+	asrt.Equal(Position{Offset: 31, Line: 3, Column: 7}, ce.Pos)
+	asrt.Equal("<b>", src[ce.Pos.Offset:ce.Pos.Offset+3])
+	asrt.Contains(err.Error(), "at line 3, column 7")
+
+	// Elements added by the parser are not located
+	var b struct {
+		N int `xpath:"//body"`
+	}
+	err = Unmarshal([]byte("x"), &b)
+	asrt.True(errors.As(err, &ce))
+	asrt.False(ce.Pos.IsValid())
+	asrt.NotContains(err.Error(), " at line")
+
+	// Streamed documents are only located with WithSource
+	err = NewDecoder(strings.NewReader(src)).Decode(&a)
+	asrt.Error(err)
+	asrt.NotContains(err.Error(), " at line")
+	err = NewDecoder(strings.NewReader(src), WithSource()).Decode(&a)
+	asrt.Contains(err.Error(), "at line 3, column 7")
+
+	// Documents not parsed by the call cannot be located
+	root, _ := html.Parse(strings.NewReader(src))
+	err = UnmarshalSelection(NewDocumentWithNode(root), &a)
+	asrt.Error(err)
+	asrt.NotContains(err.Error(), " at line")
+}
+
 func TestNilUnmarshal(t *testing.T) {
 	asrt := assert.New(t)
 
@@ -317,7 +1098,7 @@ func TestNilUnmarshal(t *testing.T) {
 
 	err := Unmarshal([]byte{}, a)
 	e := checkErr(asrt, err)
-	asrt.Equal(nilDestination, e.Reason)
+	asrt.Equal(ErrNilDestination, e.Reason)
 }
 
 func TestNonPointer(t *testing.T) {
@@ -325,7 +1106,7 @@ func TestNonPointer(t *testing.T) {
 
 	var a Page
 	e := checkErr(asrt, Unmarshal([]byte{}, a))
-	asrt.Equal(nonPointer, e.Reason)
+	asrt.Equal(ErrNonPointer, e.Reason)
 }
 
 func TestWrongArrayLength(t *testing.T) {
@@ -338,9 +1119,9 @@ func TestWrongArrayLength(t *testing.T) {
 	err := Unmarshal([]byte(testPage), &a)
 
 	e := checkErr(asrt, err)
-	asrt.Equal(typeConversionError, e.Reason)
+	asrt.Equal(ErrTypeConversion, e.Reason)
 	e2 := checkErr(asrt, e.Err)
-	asrt.Equal(arrayLengthMismatch, e2.Reason)
+	asrt.Equal(ErrArrayLengthMismatch, e2.Reason)
 
 	asrt.Contains(e.Error(), "Resource")
 	asrt.Contains(e.Error(), "array length")
@@ -363,8 +1144,8 @@ func TestInvalidLiteral(t *testing.T) {
 	asrt.Contains(err.Error(), "\"true\"")
 	asrt.Equal("true", e.val)
 
-	asrt.Equal(typeConversionError, e.chain[0].Reason)
-	asrt.Equal(typeConversionError, e.chain[1].Reason)
+	asrt.Equal(ErrTypeConversion, e.chain[0].Reason)
+	asrt.Equal(ErrTypeConversion, e.chain[1].Reason)
 }
 
 func TestInvalidArrayEleType(t *testing.T) {
@@ -390,6 +1171,29 @@ func TestDirectInsertion(t *testing.T) {
 	asrt.Len(a.Nodes, 5)
 }
 
+func TestNodeField(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		List    *html.Node `xpath:".//ul[@id='resources']"`
+		Missing *html.Node `xpath:".//ul[@id='missing']" xpath_required:"false"`
+	}
+	asrt.NoError(Unmarshal([]byte(testPage), &a))
+	asrt.Equal("ul", a.List.Data)
+	asrt.Equal("li", a.List.FirstChild.NextSibling.Data)
+	asrt.Nil(a.Missing)
+
+	var b struct {
+		Resource *html.Node `xpath:".//ul[@id='resources']/li"`
+	}
+	asrt.Equal(ErrMultipleNodes, checkErr(asrt, Unmarshal([]byte(testPage), &b)).Reason)
+
+	var c struct {
+		ByOrder map[string]*html.Node `xpath:".//ul[@id='resources']/li" xpath_key:"@order"`
+	}
+	asrt.Equal(ErrTypeConversion, checkErr(asrt, Unmarshal([]byte(testPage), &c)).Reason)
+}
+
 func TestInterfaceDecode(t *testing.T) {
 	asrt := assert.New(t)
 	var a struct {
@@ -399,9 +1203,319 @@ func TestInterfaceDecode(t *testing.T) {
 	asrt.Equal("bar", a.IF.(string))
 }
 
+type Level int
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func TestTextUnmarshaler(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<html><body>
+<span class="ip">192.168.0.1</span>
+<span class="level">High</span>
+<span class="level">low</span>
+<span class="bad">medium</span>
+</body></html>`
+
+	var a struct {
+		IP     net.IP  `xpath:"//span[@class='ip']"`
+		Level  *Level  `xpath:"(//span[@class='level'])[1]"`
+		Levels []Level `xpath:"//span[@class='level']"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal("192.168.0.1", a.IP.String())
+	asrt.Equal(Level(2), *a.Level)
+	asrt.Equal([]Level{2, 1}, a.Levels)
+
+	var b struct {
+		Level Level `xpath:"//span[@class='bad']"`
+	}
+	e := checkErr(asrt, Unmarshal([]byte(page), &b)).unwind()
+	asrt.Equal(ErrTypeConversion, e.chain[len(e.chain)-1].Reason)
+	asrt.Equal("medium", e.chain[len(e.chain)-1].Val)
+
+	var c struct {
+		IP net.IP `xpath:"//span"`
+	}
+	e = checkErr(asrt, Unmarshal([]byte(page), &c)).unwind()
+	asrt.Equal(ErrMultipleNodes, e.chain[len(e.chain)-1].Reason)
+}
+
+// DataAttr keeps the name of the attribute it was decoded from.
+type DataAttr struct {
+	Name  string
+	Value string
+}
+
+func (a *DataAttr) UnmarshalHTMLAttr(attr html.Attribute) error {
+	if attr.Val == "" {
+		return errors.New("empty attribute")
+	}
+	a.Name, a.Value = attr.Key, attr.Val
+	return nil
+}
+
+func TestUnmarshalerAttr(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := `<ul>
+<li data-id="1" data-kind="a">One</li>
+<li data-id="2" data-kind="">Two</li>
+</ul>`
+
+	var a struct {
+		ID    DataAttr    `xpath:"//li[1]/@data-id"`
+		Kind  *DataAttr   `xpath:"//li[1]" xpath_attr:"data-kind"`
+		IDs   []DataAttr  `xpath:"//li/@data-id"`
+		Items []*DataAttr `xpath:"//li,attr=data-id"`
+	}
+	asrt.NoError(Unmarshal([]byte(page), &a))
+	asrt.Equal(DataAttr{Name: "data-id", Value: "1"}, a.ID)
+	asrt.Equal(&DataAttr{Name: "data-kind", Value: "a"}, a.Kind)
+	asrt.Equal([]DataAttr{{"data-id", "1"}, {"data-id", "2"}}, a.IDs)
+	asrt.Len(a.Items, 2)
+	asrt.Equal("2", a.Items[1].Value)
+
+	var b struct {
+		Kind DataAttr `xpath:"//li[2]/@data-kind"`
+	}
+	err := Unmarshal([]byte(page), &b)
+	asrt.True(errors.Is(err, ErrCustomUnmarshal))
+	asrt.Contains(err.Error(), "empty attribute")
+
+	var c struct {
+		ID DataAttr `xpath:"//li/@data-id"`
+	}
+	asrt.True(errors.Is(Unmarshal([]byte(page), &c), ErrMultipleNodes))
+}
+
 func checkErr(asrt *assert.Assertions, err error) *CannotUnmarshalError {
 	asrt.Error(err)
 	asrt.IsType((*CannotUnmarshalError)(nil), err)
 
 	return err.(*CannotUnmarshalError)
 }
+
+func TestPointerScalars(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<p class="empty"> </p><p class="bad">n/a</p><p class="n">7</p>`)
+
+	var a struct {
+		Missing *int       `xpath:"//p[@class='none']" xpath_required:"false"`
+		Empty   *int       `xpath:"//p[@class='empty']"`
+		Bad     *float64   `xpath:"//p[@class='bad']" xpath_required:"false"`
+		When    *time.Time `xpath:"//p[@class='empty']"`
+		N       *int       `xpath:"//p[@class='n']"`
+		Text    *string    `xpath:"//p[@class='empty']"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Nil(a.Missing)
+	asrt.Nil(a.Empty)
+	asrt.Nil(a.Bad)
+	asrt.Nil(a.When)
+	asrt.Equal(7, *a.N)
+	asrt.Equal("", *a.Text)
+}
+
+type pageMeta struct {
+	Title       string `xpath:"//title"`
+	Description string `xpath:"//meta[@name='description']/@content" xpath_required:"false"`
+}
+
+type author struct {
+	Name string `xpath:".//span[@class='name']"`
+}
+
+type Breadcrumbs struct {
+	Items []string `xpath:".//li"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<html><head><title>Post</title></head><body>
+<ul class="crumbs"><li>Home</li><li>Blog</li></ul>
+<article><span class="name">Ann</span><p>Body</p></article>
+</body></html>`)
+
+	var a struct {
+		pageMeta
+		*Breadcrumbs `xpath:"//ul[@class='crumbs']"`
+		author
+		Body string `xpath:"//article/p"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal("Post", a.Title)
+	asrt.Equal([]string{"Home", "Blog"}, a.Items)
+	asrt.Equal("Ann", a.Name)
+	asrt.Equal("Body", a.Body)
+
+	var b struct {
+		author
+	}
+	err := Unmarshal([]byte(`<p></p>`), &b)
+	asrt.True(errors.Is(err, ErrNodeNotFound))
+}
+
+func TestSpace(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte("<p title=\" a \"> Some\n   spaced \t text </p>")
+
+	var a struct {
+		Trim     string `xpath:"//p"`
+		Collapse string `xpath:"//p" xpath_space:"collapse"`
+		Preserve string `xpath:"//p" xpath_space:"preserve"`
+		Attr     string `xpath:"//p" xpath_attr:"title" xpath_space:"preserve"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.Equal("Some\n   spaced \t text", a.Trim)
+	asrt.Equal("Some spaced text", a.Collapse)
+	asrt.Equal(" Some\n   spaced \t text ", a.Preserve)
+	asrt.Equal(" a ", a.Attr)
+
+	var b struct {
+		P string `xpath:"//p" xpath_space:"squash"`
+	}
+	asrt.Error(Unmarshal(page, &b))
+}
+
+func TestBoolWords(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<p class="stock">In stock</p><p class="ru">нет</p><p class="sale">-</p>`)
+
+	var a struct {
+		InStock bool  `xpath:"//p[@class='stock']" xpath_true:"in stock"`
+		Ru      bool  `xpath:"//p[@class='ru']" xpath_true:"да" xpath_false:"нет"`
+		Sale    bool  `xpath:"//p[@class='sale']" xpath_true:"yes"`
+		NotSale bool  `xpath:"//p[@class='sale']" xpath_false:"no"`
+		Ptr     *bool `xpath:"//p[@class='stock']" xpath_true:"In Stock|available"`
+	}
+	asrt.NoError(Unmarshal(page, &a))
+	asrt.True(a.InStock)
+	asrt.False(a.Ru)
+	asrt.False(a.Sale)
+	asrt.True(a.NotSale)
+	asrt.True(*a.Ptr)
+
+	var b struct {
+		Sale bool `xpath:"//p[@class='sale']" xpath_true:"yes" xpath_false:"no"`
+	}
+	err := Unmarshal(page, &b)
+	asrt.True(errors.Is(err, ErrTypeConversion))
+	asrt.Contains(err.Error(), `value "-"`)
+}
+
+type hookedItem struct {
+	ID    string `xpath:"." xpath_attr:"data-id"`
+	Price int    `xpath:"./i"`
+	Qty   int    `xpath:"./b" xpath_required:"false"`
+	Total int    `xpath:"-"`
+}
+
+func (it *hookedItem) BeforeUnmarshalHTML(doc *Document) error {
+	if doc.AttrOr("data-id", "") == "" {
+		return errors.New("item without id")
+	}
+	it.Qty = 1
+	return nil
+}
+
+func (it *hookedItem) AfterUnmarshalHTML() error {
+	if it.Price < 0 {
+		return fmt.Errorf("item %s: negative price", it.ID)
+	}
+	it.Total = it.Price * it.Qty
+	return nil
+}
+
+func TestUnmarshalHooks(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Items []hookedItem `xpath:"//li"`
+	}
+	asrt.NoError(Unmarshal([]byte(`<li data-id="a"><i>3</i><b>2</b></li><li data-id="b"><i>5</i></li>`), &a))
+	asrt.Equal([]hookedItem{{"a", 3, 2, 6}, {"b", 5, 1, 5}}, a.Items)
+
+	err := Unmarshal([]byte(`<li><i>3</i></li>`), &a)
+	asrt.True(errors.Is(err, ErrCustomUnmarshal))
+	asrt.Contains(err.Error(), "item without id")
+
+	err = Unmarshal([]byte(`<li data-id="c"><i>-1</i></li>`), &a)
+	asrt.True(errors.Is(err, ErrCustomUnmarshal))
+	asrt.Contains(err.Error(), "item c: negative price")
+}
+
+type docKeeper struct {
+	Name string    `xpath:"."`
+	doc  *Document `xpath:"-"`
+}
+
+func (k *docKeeper) BeforeUnmarshalHTML(doc *Document) error {
+	k.doc = doc
+	return nil
+}
+
+func TestBeforeUnmarshalKeepsDocument(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<ul>` + strings.Repeat(`<li>a</li><li>b</li>`, 10) + `</ul>`)
+	for _, opts := range [][]Option{nil, {WithParallelism(4)}} {
+		var a struct {
+			Items []docKeeper  `xpath:"//li"`
+			Pair  [2]docKeeper `xpath:"(//li)[position() <= 2]"`
+		}
+		asrt.NoError(UnmarshalWithOptions(page, &a, opts...))
+		asrt.Len(a.Items, 20)
+		for i, it := range append(a.Items, a.Pair[:]...) {
+			asrt.Equal(1, it.doc.Length())
+			asrt.Equal(it.Name, it.doc.Text())
+			if i > 0 {
+				asrt.False(it.doc == a.Items[0].doc)
+			}
+		}
+	}
+}
+
+type validatedOffer struct {
+	Price float64 `xpath:"./i"`
+	Sale  float64 `xpath:"./b" xpath_required:"false"`
+}
+
+var errSaleAbovePrice = errors.New("sale price above regular price")
+
+func (o validatedOffer) Validate() error {
+	if o.Sale > o.Price {
+		return errSaleAbovePrice
+	}
+	return nil
+}
+
+func TestValidateHook(t *testing.T) {
+	asrt := assert.New(t)
+
+	var a struct {
+		Offers []validatedOffer `xpath:"//li"`
+	}
+	asrt.NoError(Unmarshal([]byte(`<li><i>10</i><b>8</b></li><li><i>5</i></li>`), &a))
+	asrt.Equal([]validatedOffer{{10, 8}, {5, 0}}, a.Offers)
+
+	err := Unmarshal([]byte(`<li><i>10</i></li><li><i>10</i><b>12</b></li>`), &a)
+	asrt.True(errors.Is(err, ErrValidation))
+	asrt.True(errors.Is(err, errSaleAbovePrice))
+	asrt.Contains(err.Error(), "Offers[1]")
+	asrt.Contains(err.Error(), "line 1")
+}
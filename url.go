@@ -0,0 +1,93 @@
+package goxtag
+
+import (
+	"github.com/antchfx/htmlquery"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+var urlType = reflect.TypeOf(url.URL{})
+
+// WithBaseURL sets the URL the document was fetched from. Links decoded with
+// xpath_mode:"url" are resolved against it, or against the <base href> of the
+// document which is itself resolved against base.
+func WithBaseURL(base *url.URL) Option {
+	return func(c *config) {
+		c.baseURL = base
+	}
+}
+
+// documentBase returns the URL relative links of the document are resolved
+// against: its <base href> resolved against the configured base URL, or the
+// configured base URL if there is no usable <base>. It is looked up once per
+// run.
+func (d *decodeState) documentBase() *url.URL {
+	if d.base != nil {
+		return d.base.url
+	}
+	d.base = &resolvedBase{url: d.baseURL}
+	if d.root == nil {
+		return d.base.url
+	}
+
+	base := htmlquery.FindOne(d.root, "//base[@href]")
+	if base == nil {
+		return d.base.url
+	}
+	href, _ := d.attr(base, "href")
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return d.base.url
+	}
+	if d.baseURL != nil {
+		u = d.baseURL.ResolveReference(u)
+	}
+	d.base.url = u
+	return u
+}
+
+// resolvedBase caches the result of documentBase, which may be nil.
+type resolvedBase struct {
+	url *url.URL
+}
+
+// resolveURL parses the link s and resolves it against the base of the
+// document, if any.
+func (d *decodeState) resolveURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if base := d.documentBase(); base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u, nil
+}
+
+// unmarshalURL parses the value of the matched nodes into a url.URL, resolved
+// against the base of the document. Empty values leave the field untouched.
+func (d *decodeState) unmarshalURL(doc *Document, v reflect.Value, tag xpathTag) error {
+	str, err := d.value(doc, v, tag)
+	if err != nil {
+		return err
+	}
+	if str == "" {
+		d.unset = true
+		return nil
+	}
+
+	u, err := d.resolveURL(str)
+	if err != nil {
+		return &CannotUnmarshalError{
+			V:      v,
+			Reason: ErrInvalidURL,
+			XPath:  tag.tag,
+			Err:    err,
+			Val:    str,
+			Pos:    d.position(doc),
+		}
+	}
+	v.Set(reflect.ValueOf(*u))
+	return nil
+}
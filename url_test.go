@@ -0,0 +1,77 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestModeURL(t *testing.T) {
+	asrt := assert.New(t)
+
+	type links struct {
+		Next  string   `xpath:"//a[@rel='next']/@href" xpath_mode:"url"`
+		Image string   `xpath:"//img" xpath_attr:"src" xpath_mode:"url"`
+		All   []string `xpath:"//a/@href" xpath_mode:"url"`
+		Raw   string   `xpath:"//a[@rel='next']/@href"`
+	}
+	page := []byte(`<a rel="next" href="?page=2">Next</a><a href="//cdn.example.com/x">X</a><img src="/img/a.png">`)
+	base, _ := url.Parse("https://example.com/list/")
+
+	var a links
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithBaseURL(base)))
+	asrt.Equal("https://example.com/list/?page=2", a.Next)
+	asrt.Equal("https://example.com/img/a.png", a.Image)
+	asrt.Equal([]string{"https://example.com/list/?page=2", "https://cdn.example.com/x"}, a.All)
+	asrt.Equal("?page=2", a.Raw)
+
+	withBase := append([]byte(`<head><base href="/shop/"></head>`), page...)
+	var b links
+	asrt.NoError(UnmarshalWithOptions(withBase, &b, WithBaseURL(base)))
+	asrt.Equal("https://example.com/shop/?page=2", b.Next)
+
+	var c links
+	asrt.NoError(Unmarshal(page, &c))
+	asrt.Equal("?page=2", c.Next)
+
+	var d struct {
+		Link string `xpath:"//a" xpath_attr:"href" xpath_mode:"url"`
+	}
+	err := Unmarshal([]byte(`<a href="http://[::1">x</a>`), &d)
+	asrt.True(errors.Is(err, ErrInvalidURL))
+}
+
+func TestURLFields(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<a class="home" href="https://example.com/">Home</a>
+<a class="rel" href="/about">About</a><a class="empty" href="">x</a>`)
+	base, _ := url.Parse("https://example.com/blog/")
+
+	var a struct {
+		Home  url.URL    `xpath:"//a[@class='home']/@href"`
+		About *url.URL   `xpath:"//a[@class='rel']/@href"`
+		Empty *url.URL   `xpath:"//a[@class='empty']" xpath_attr:"href"`
+		All   []*url.URL `xpath:"//a/@href"`
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithBaseURL(base)))
+	asrt.Equal("https://example.com/", a.Home.String())
+	asrt.Equal("https://example.com/about", a.About.String())
+	asrt.Nil(a.Empty)
+	asrt.Len(a.All, 2)
+
+	var values struct {
+		All []url.URL `xpath:"//a/@href"`
+	}
+	asrt.NoError(Unmarshal(page, &values))
+	asrt.Len(values.All, 2)
+
+	var b struct {
+		Link url.URL `xpath:"//a" xpath_attr:"href"`
+	}
+	err := Unmarshal([]byte(`<a href="http://[::1">x</a>`), &b)
+	asrt.True(errors.Is(err, ErrInvalidURL))
+	var urlErr *url.Error
+	asrt.True(errors.As(err, &urlErr))
+}
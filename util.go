@@ -1,6 +1,18 @@
 package goxtag
 
-import "reflect"
+import (
+	"encoding"
+	"golang.org/x/net/html"
+	"reflect"
+	"strings"
+)
+
+var (
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	unmarshalerAttrType = reflect.TypeOf((*UnmarshalerAttr)(nil)).Elem()
+	nodePtrType         = reflect.TypeOf((*html.Node)(nil))
+)
 
 // TypeDeref returns the underlying type if the given type is a pointer.
 func TypeDeref(t reflect.Type) reflect.Type {
@@ -43,3 +55,56 @@ func indirect(v reflect.Value) (Unmarshaler, reflect.Value) {
 	}
 	return nil, v
 }
+
+// textUnmarshaler returns v as an encoding.TextUnmarshaler if its pointer
+// implements it.
+func textUnmarshaler(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if !v.CanAddr() {
+		return nil, false
+	}
+	tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return tu, ok
+}
+
+// attrUnmarshaler returns v, or the value it points to, as an UnmarshalerAttr
+// if its pointer implements it. Nil pointers on the way are allocated.
+func attrUnmarshaler(v reflect.Value) (UnmarshalerAttr, bool) {
+	if !reflect.PtrTo(TypeDeref(v.Type())).Implements(unmarshalerAttrType) {
+		return nil, false
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if !v.CanAddr() {
+		return nil, false
+	}
+	return v.Addr().Interface().(UnmarshalerAttr), true
+}
+
+// isScalarType reports whether t is decoded from the value of a single node
+// even though its kind may not be a literal one, like time.Time, url.URL,
+// the database/sql Null types, *html.Node or types implementing
+// encoding.TextUnmarshaler or UnmarshalerAttr.
+func isScalarType(t reflect.Type) bool {
+	if t == timeType || t == urlType || isNullType(t) || t == nodePtrType || t == nodePtrType.Elem() {
+		return true
+	}
+	pt := reflect.PtrTo(t)
+	return !pt.Implements(unmarshalerType) &&
+		(pt.Implements(textUnmarshalerType) || pt.Implements(unmarshalerAttrType))
+}
+
+// xpathLiteral quotes s as an XPath string literal. XPath 1.0 has no escape
+// sequences, so a string holding both quote kinds is built with concat().
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	return "concat('" + strings.Replace(s, "'", `', "'", '`, -1) + "')"
+}
@@ -0,0 +1,79 @@
+package goxtag
+
+import (
+	"reflect"
+)
+
+// ValidateType checks the tags of t and of the structs it nests without any
+// document: every option is parsed and every selector compiled, with the
+// options of opts (e.g. WithTagName). Unlike NewTypeDecoder it goes on after
+// the first problem and returns them all as UnmarshalErrors, each one a
+// CannotUnmarshalError with ErrInvalidTag and the path of the field, so that
+// tests can check scraping structs without sample pages.
+func ValidateType(t reflect.Type, opts ...Option) error {
+	d := &decodeState{config: newConfig(opts)}
+	if errs := d.validateType(t, map[reflect.Type]bool{}); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate is ValidateType for the type parameter T.
+func Validate[T any](opts ...Option) error {
+	return ValidateType(reflect.TypeOf((*T)(nil)).Elem(), opts...)
+}
+
+// validateType walks t like planType but collects the errors of every field.
+// Each struct type is checked once, at the first path it is found at.
+func (d *decodeState) validateType(t reflect.Type, seen map[reflect.Type]bool) UnmarshalErrors {
+	t = TypeDeref(t)
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan, reflect.Map:
+		return d.validateType(t.Elem(), seen)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	if seen[t] || isScalarType(t) || d.converter(t) != nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	seen[t] = true
+
+	var errs UnmarshalErrors
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, err := d.newXpathTag(field)
+		if err == nil {
+			err = tag.compile()
+		}
+		if err == nil && tag.requiredIf != "" {
+			_, err = requiredIfField(t, i, tag)
+		}
+		if err != nil {
+			errs = append(errs, &CannotUnmarshalError{
+				V:        reflect.New(t).Elem(),
+				Reason:   ErrInvalidTag,
+				XPath:    tag.tag,
+				Err:      err,
+				FldOrIdx: field.Name,
+			})
+			continue
+		}
+
+		if tag.tag == ignoreTag {
+			continue
+		}
+		for _, err := range d.validateType(field.Type, seen) {
+			errs = append(errs, &CannotUnmarshalError{
+				V:        reflect.New(t).Elem(),
+				Reason:   ErrInvalidTag,
+				Err:      err,
+				FldOrIdx: field.Name,
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,49 @@
+package goxtag
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type validateOffer struct {
+	Price    float64 `xpath:"./span[@class='price'"`
+	Currency string  `xpath:"./@data-currency" xpath_required:"maybe"`
+}
+
+type validatePage struct {
+	Title  string           `xpath:"meta:title"`
+	Name   string           `css:"h1["`
+	Offers []*validateOffer `xpath:"//div[@class='offer']"`
+	Best   validateOffer    `xpath:"(//div[@class='offer'])[1]"`
+	Tags   []string         `xpath:"//li" xpath_split:","`
+	Items  map[string]int   `xpath:"//li" xpath_key:"@id"`
+}
+
+func TestValidate(t *testing.T) {
+	asrt := assert.New(t)
+
+	asrt.NoError(Validate[typeDecoderPage]())
+	asrt.NoError(ValidateType(reflect.TypeOf([]*typeDecoderItem{})))
+
+	err := Validate[validatePage]()
+	var errs UnmarshalErrors
+	if !asrt.True(errors.As(err, &errs)) {
+		return
+	}
+	asrt.Len(errs, 3)
+	for _, err := range errs {
+		asrt.True(errors.Is(err, ErrInvalidTag))
+	}
+	asrt.Contains(errs[0].Error(), "validatePage.Name")
+	asrt.Contains(errs[1].Error(), "validatePage.Offers.Price")
+	asrt.Contains(errs[2].Error(), "validatePage.Offers.Currency")
+
+	// Tags are read from the renamed companion tags
+	type renamed struct {
+		Name string `html:"//h1" html_required:"perhaps"`
+	}
+	asrt.NoError(Validate[renamed]())
+	asrt.Error(Validate[renamed](WithTagName("html")))
+}
@@ -0,0 +1,78 @@
+package goxtag
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// WithVars binds the $name variables of the selectors of fields, so that one
+// struct can be used for variants of a page: with WithVars(map[string]string{
+// "lang": "en"}), `xpath:"//div[@lang=$lang]/h1"` selects the English title.
+// Values are strings, quoted as XPath literals; references to variables
+// without a value fail.
+func WithVars(vars map[string]string) Option {
+	return func(c *config) {
+		m := make(map[string]string, len(c.vars)+len(vars))
+		for k, v := range c.vars {
+			m[k] = v
+		}
+		for k, v := range vars {
+			m[k] = v
+		}
+		c.vars = m
+		c.exprs = &sync.Map{}
+	}
+}
+
+// hasVars reports whether selector may reference variables.
+func hasVars(selector string) bool {
+	return strings.IndexByte(selector, '$') >= 0
+}
+
+// bindVars replaces the variable references of selector, outside of string
+// literals, with the values of vars as literals. With nil vars every
+// variable is bound to an empty string, to check the selector.
+func bindVars(selector string, vars map[string]string) (string, error) {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(selector); i++ {
+		c := selector[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$':
+			j := i + 1
+			for j < len(selector) && isVarNameByte(selector[j], j == i+1) {
+				j++
+			}
+			name := selector[i+1 : j]
+			if name == "" {
+				return "", fmt.Errorf("missing variable name at offset %d in %q", i, selector)
+			}
+			val, ok := vars[name]
+			if !ok && vars != nil {
+				return "", fmt.Errorf("undeclared variable $%s in %q", name, selector)
+			}
+			b.WriteString(xpathLiteral(val))
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+func isVarNameByte(c byte, first bool) bool {
+	switch {
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		return true
+	case first:
+		return false
+	}
+	return c == '-' || c == '.' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,100 @@
+package goxtag
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testLocalizedPage struct {
+	Title string   `xpath:"//div[@lang=$lang]/h1"`
+	Tags  []string `xpath:"//div[@lang=$lang]//li[@data-section=$section or $section='']"`
+	Price string   `xpath:"//span[@title='$lang']"`
+}
+
+func TestWithVars(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<div lang="en"><h1>Hello</h1><ul><li data-section="a">x</li><li data-section="b">y</li></ul></div>
+		<div lang="fr"><h1>Bonjour</h1><ul><li data-section="a">z</li></ul></div>
+		<div lang="it's"><h1>Ciao</h1></div>
+		<div lang=""><h1>Unknown</h1></div>
+		<span title="$lang">5</span>`)
+
+	var a testLocalizedPage
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithVars(map[string]string{"lang": "fr", "section": ""})))
+	asrt.Equal(testLocalizedPage{Title: "Bonjour", Tags: []string{"z"}, Price: "5"}, a)
+
+	a = testLocalizedPage{}
+	asrt.NoError(UnmarshalWithOptions(page, &a, WithVars(map[string]string{"lang": "en"}), WithVars(map[string]string{"section": "b"})))
+	asrt.Equal(testLocalizedPage{Title: "Hello", Tags: []string{"y"}, Price: "5"}, a)
+
+	var b struct {
+		Title string `xpath:"//div[@lang=$lang]/h1"`
+	}
+	asrt.NoError(UnmarshalWithOptions(page, &b, WithVars(map[string]string{"lang": "it's"})))
+	asrt.Equal("Ciao", b.Title)
+
+	// Undeclared variables fail rather than match empty values
+	err := UnmarshalWithOptions(page, &b)
+	if asrt.Error(err) {
+		asrt.False(errors.Is(err, ErrNodeNotFound))
+		asrt.Contains(err.Error(), "undeclared variable $lang")
+	}
+	err = UnmarshalWithOptions(page, &b, WithVars(map[string]string{"section": "a"}))
+	if asrt.Error(err) {
+		asrt.Contains(err.Error(), "undeclared variable $lang")
+	}
+
+	_, err = NewTypeDecoder(reflect.TypeOf(testLocalizedPage{}))
+	asrt.NoError(err)
+	asrt.Equal(`//a[@x=concat('it', "'", 's "x"')]`, mustBindVars(t, `//a[@x=$v]`, map[string]string{"v": `it's "x"`}))
+	asrt.Equal(`//a[@x='$v' or @y='"']`, mustBindVars(t, `//a[@x='$v' or @y=$v]`, map[string]string{"v": `"`}))
+}
+
+func mustBindVars(t *testing.T, selector string, vars map[string]string) string {
+	s, err := bindVars(selector, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestVarsInSubSelectors(t *testing.T) {
+	asrt := assert.New(t)
+
+	page := []byte(`<ul class="en"><li><b lang="en">k1</b><b lang="fr">c1</b><i>v1</i></li></ul>
+		<ul class="fr"><li><b lang="en">k2</b><b lang="fr">c2</b><i>v2</i></li></ul>
+		<p><label lang="fr">Nom</label><span>x</span><em lang="fr"></em></p>`)
+	opts := []Option{WithVars(map[string]string{"lang": "fr"}), WithParams(map[string]string{"class": "fr"})}
+
+	var a struct {
+		Map       map[string]string `xpath:"//li" xpath_key:"./b[@lang=$lang]" xpath_value:"./i"`
+		Items     []string          `xpath:"//ul[@class='{class}']" xpath_flatten:"true" xpath_child:"./li/b[@lang=$lang]"`
+		Name      string            `xpath:"//span" xpath_label:"../label[@lang=$lang]"`
+		NameLabel string
+		Kind      string `xpath_default_when:".//p/em[@lang=$lang] => french"`
+	}
+	a.Map = map[string]string{}
+	asrt.NoError(UnmarshalWithOptions(page, &a, opts...))
+	asrt.Equal(map[string]string{"c1": "v1", "c2": "v2"}, a.Map)
+	asrt.Equal([]string{"c2"}, a.Items)
+	asrt.Equal("Nom", a.NameLabel)
+	asrt.Equal("french", a.Kind)
+
+	var b struct {
+		Key string `xpath:"./b[@lang=$lang]"`
+	}
+	asrt.NoError(NewDecoder(bytes.NewReader(page), opts...).DecodeSelection("//ul[@class='{class}']/li", &b))
+	asrt.Equal("c2", b.Key)
+
+	ch := make(chan string, 2)
+	asrt.NoError(NewDecoder(bytes.NewReader(page), opts...).Stream("//ul[@class='{class}']//b[@lang=$lang]", ch))
+	asrt.Equal("c2", <-ch)
+
+	vs, err := DecodeAll[string](parseTestDocument(t, string(page)), "//b[@lang=$lang]", opts...)
+	asrt.NoError(err)
+	asrt.Equal([]string{"c1", "c2"}, vs)
+}
@@ -0,0 +1,89 @@
+package goxtag
+
+import (
+	"github.com/antchfx/xmlquery"
+	"golang.org/x/net/html"
+	"io"
+)
+
+// SyntaxMode selects how documents are parsed, see WithSyntax.
+type SyntaxMode int
+
+const (
+	// SyntaxHTML parses documents the way browsers do, fixing up tag soup
+	// along the way. It is the default.
+	SyntaxHTML SyntaxMode = iota
+	// SyntaxXML parses documents as XML with xmlquery: the markup is kept as
+	// written, names keep their case and no element is added or moved.
+	SyntaxXML
+)
+
+// WithSyntax selects how documents are parsed. With SyntaxXML, sitemaps, RSS
+// feeds and other XML exports can be decoded with the same xpath-tagged
+// structs; the encoding is then read from the XML declaration and
+// WithContentType and WithFragment are ignored.
+//
+// Elements and attributes are named as written, prefix included, so
+// namespaced ones are selected by their qualified name with name(), e.g.
+// "./*[name()='atom:link']/@href", while "./link" only matches the
+// unprefixed <link>. Default namespaces are ignored. Register the prefixes
+// used by selectors with WithNamespaces to match elements by namespace URI
+// instead.
+func WithSyntax(mode SyntaxMode) Option {
+	return func(c *config) {
+		c.syntax = mode
+	}
+}
+
+// UnmarshalXML is like Unmarshal for XML documents, see WithSyntax.
+func UnmarshalXML(bs []byte, v interface{}, opts ...Option) error {
+	return UnmarshalWithOptions(bs, v, append(opts, WithSyntax(SyntaxXML))...)
+}
+
+// parseXML parses the XML document read from r and returns it as an HTML
+// tree, so that it is queried and decoded like any other document.
+func parseXML(r io.Reader) (*html.Node, error) {
+	doc, err := xmlquery.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return xmlToHTML(doc), nil
+}
+
+// xmlToHTML converts n and its descendants. Declarations are dropped, CDATA
+// sections become text.
+func xmlToHTML(n *xmlquery.Node) *html.Node {
+	var node *html.Node
+	switch n.Type {
+	case xmlquery.DocumentNode:
+		node = &html.Node{Type: html.DocumentNode}
+	case xmlquery.ElementNode:
+		node = &html.Node{Type: html.ElementNode, Data: qualifiedName(n.Prefix, n.Data)}
+		for _, attr := range n.Attr {
+			node.Attr = append(node.Attr, html.Attribute{
+				Key: qualifiedName(attr.Name.Space, attr.Name.Local),
+				Val: attr.Value,
+			})
+		}
+	case xmlquery.TextNode, xmlquery.CharDataNode:
+		return &html.Node{Type: html.TextNode, Data: n.Data}
+	case xmlquery.CommentNode:
+		return &html.Node{Type: html.CommentNode, Data: n.Data}
+	default:
+		return nil
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if child := xmlToHTML(c); child != nil {
+			node.AppendChild(child)
+		}
+	}
+	return node
+}
+
+func qualifiedName(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
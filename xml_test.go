@@ -0,0 +1,67 @@
+package goxtag
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Feed</title>
+    <link>https://example.com/</link>
+    <atom:link href="https://example.com/feed.xml" rel="self"/>
+    <item>
+      <title>First</title>
+      <link>https://example.com/1</link>
+      <description><![CDATA[<p>Hello</p>]]></description>
+      <pubDate>2021-01-02T03:04:05Z</pubDate>
+    </item>
+    <item>
+      <title>Second</title>
+      <link>https://example.com/2</link>
+    </item>
+  </channel>
+</rss>`
+
+type rssFeed struct {
+	Title string `xpath:"/rss/channel/title"`
+	Link  string `xpath:"/rss/channel/link"`
+	Self  string `xpath:"/rss/channel/*[name()='atom:link']/@href"`
+	Items []struct {
+		Title       string    `xpath:"./title"`
+		Link        string    `xpath:"./link"`
+		Description string    `xpath:"./description" xpath_required:"false"`
+		PubDate     time.Time `xpath:"./pubDate" xpath_required:"false"`
+	} `xpath:"//item"`
+}
+
+func TestUnmarshalXML(t *testing.T) {
+	asrt := assert.New(t)
+
+	var feed rssFeed
+	asrt.NoError(UnmarshalXML([]byte(testRSS), &feed))
+	asrt.Equal("Feed", feed.Title)
+	// HTML parsing would have turned <link> into a void element
+	asrt.Equal("https://example.com/", feed.Link)
+	asrt.Equal("https://example.com/feed.xml", feed.Self)
+	asrt.Len(feed.Items, 2)
+	asrt.Equal("https://example.com/2", feed.Items[1].Link)
+	asrt.Equal("<p>Hello</p>", feed.Items[0].Description)
+	asrt.Equal(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), feed.Items[0].PubDate)
+
+	var sitemap struct {
+		URLs []string `xpath:"/urlset/url/loc"`
+	}
+	err := NewDecoder(bytes.NewReader([]byte(`<?xml version="1.0"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/a</loc></url>
+  <url><loc>https://example.com/b</loc></url>
+</urlset>`)), WithSyntax(SyntaxXML)).Decode(&sitemap)
+	asrt.NoError(err)
+	asrt.Equal([]string{"https://example.com/a", "https://example.com/b"}, sitemap.URLs)
+
+	asrt.Error(UnmarshalXML([]byte(`<a><b></a>`), &sitemap))
+}